@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/deref/exo/internal/core/api"
 	"github.com/spf13/cobra"
@@ -13,10 +15,25 @@ import (
 func init() {
 	rootCmd.AddCommand(applyCmd)
 	applyCmd.Flags().StringVar(&applyFlags.Format, "format", "", "exo, compose, procfile")
+	applyCmd.Flags().BoolVar(&applyFlags.Adopt, "adopt", false, "take over containers, networks, and volumes created by docker-compose")
+	applyCmd.Flags().StringVar(&applyFlags.Profile, "profile", "", "name of profile to apply to the manifest")
+	applyCmd.Flags().BoolVar(&applyFlags.WatchManifest, "watch-manifest", false, "re-apply whenever the manifest or .env file changes")
+	applyCmd.Flags().StringVarP(&applyFlags.Formation, "formation", "m", "", "procfile only: number of each process to run, such as web=2,worker=1")
+	applyCmd.Flags().StringSliceVarP(&applyFlags.EnvFiles, "env", "e", nil, "procfile only: env files to give every process")
+	applyCmd.Flags().BoolVar(&applyFlags.Plan, "plan", false, "show what would change without changing anything")
 }
 
 var applyFlags struct {
-	Format string
+	Format        string
+	Adopt         bool
+	Profile       string
+	WatchManifest bool
+	// Set when the watch-manifest flag is given explicitly, so that the setting
+	// of the workspace is otherwise left as is.
+	WatchManifestSet bool
+	Formation        string
+	EnvFiles         []string
+	Plan             bool
 }
 
 var applyCmd = &cobra.Command{
@@ -43,7 +60,38 @@ var applyCmd = &cobra.Command{
 	The expected procfile name 'Procfile'.
 	
 	If a manifest format will be guessed from the manifest filename.  This can be
-	overidden explicitly with the --format flag.`,
+	overidden explicitly with the --format flag.
+
+	When migrating a project from docker-compose, the --adopt flag takes over
+	the containers that docker-compose created for each service, as identified
+	by their com.docker.compose.project and com.docker.compose.service labels,
+	instead of replacing them. Existing networks and volumes are always adopted.
+
+	The --profile flag layers the overlays of a named profile on to the manifest.
+	Overlays are declared in 'profile "<name>" { ... }' blocks of the manifest,
+	or in a file beside it, such as 'exo.ci.hcl' for the "ci" profile of
+	'exo.hcl'. Overlays may remove, add and patch components and environment
+	variables. The workspace remembers the profile until the next apply.
+
+	The --watch-manifest flag enables a workspace setting that re-applies the
+	manifest whenever it, a profile overlay, or the .env file in the workspace
+	root changes. Warnings and errors are reported to the workspace's event
+	stream. Use --watch-manifest=false to disable watching again.
+
+	Procfiles are imported like foreman runs them. Options are read from a
+	'.foreman' file beside the Procfile, which may set the base 'port', the
+	'formation' (or 'concurrency'), and a comma separated list of 'env' files.
+	The --formation and --env flags take precedence over the .foreman file.
+	Processes with more than one instance are named like 'web-1', 'web-2', and
+	so on, and each instance is assigned the next port after the previous one.
+	Unlike the .foreman file, the flags are not used when a watched manifest is
+	re-applied.
+
+	The --plan flag shows what applying would do to each component, without
+	changing anything. Components are created, updated in place, replaced, or
+	deleted. Spec changes are shown as diffs. Replacing a component also
+	replaces the components that depend on it, and the plan shows which
+	replaced dependency caused each such replacement.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := newContext()
@@ -53,20 +101,32 @@ var applyCmd = &cobra.Command{
 		kernel := cl.Kernel()
 		workspace := requireCurrentWorkspace(ctx, cl)
 
+		applyFlags.WatchManifestSet = cmd.Flags().Changed("watch-manifest")
 		return apply(ctx, kernel, workspace, args)
 	},
 }
 
 func apply(ctx context.Context, kernel api.Kernel, workspace api.Workspace, args []string) error {
 	input := &api.ApplyInput{
-		Format: applyFlags.Format,
+		Format:    applyFlags.Format,
+		Adopt:     applyFlags.Adopt,
+		Profile:   applyFlags.Profile,
+		Formation: applyFlags.Formation,
+		EnvFiles:  applyFlags.EnvFiles,
+		Plan:      applyFlags.Plan,
+	}
+	if applyFlags.WatchManifestSet {
+		input.WatchManifest = &applyFlags.WatchManifest
 	}
 	if len(args) > 0 {
-		manifestPath := args[0]
+		// We're not necessarily in the workspace root here, so send an absolute
+		// path and the file contents too.
+		manifestPath, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("resolving manifest path: %w", err)
+		}
 		input.ManifestPath = &manifestPath
 
-		// We're not necessarily in the workspace root here,
-		// so send the file contents too.
 		bs, err := ioutil.ReadFile(manifestPath)
 		if err != nil {
 			return fmt.Errorf("reading manifest file: %w", err)
@@ -84,5 +144,32 @@ func apply(ctx context.Context, kernel api.Kernel, workspace api.Workspace, args
 	if err != nil {
 		return err
 	}
+	if applyFlags.Plan {
+		printPlan(output.Plan)
+		return nil
+	}
 	return watchJob(ctx, kernel, output.JobID)
 }
+
+var planActionSymbols = map[string]string{
+	"create":  "+",
+	"update":  "~",
+	"replace": "-/+",
+	"delete":  "-",
+}
+
+func printPlan(plan []api.ComponentPlan) {
+	if len(plan) == 0 {
+		fmt.Println("No changes.")
+		return
+	}
+	for _, p := range plan {
+		fmt.Printf("%3s %s %s (%s): %s\n", planActionSymbols[p.Action], p.Action, p.Name, p.Type, p.Reason)
+		if p.SpecDiff != "" {
+			for _, line := range strings.SplitAfter(strings.TrimSuffix(p.SpecDiff, "\n"), "\n") {
+				fmt.Printf("      %s", line)
+			}
+			fmt.Println()
+		}
+	}
+}
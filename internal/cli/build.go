@@ -9,6 +9,13 @@ import (
 
 func init() {
 	rootCmd.AddCommand(buildCmd)
+	buildCmd.Flags().BoolVar(&buildFlags.NoCache, "no-cache", false, "Do not use cache when building images")
+	buildCmd.Flags().BoolVar(&buildFlags.Pull, "pull", false, "Always attempt to pull newer versions of base images")
+}
+
+var buildFlags struct {
+	NoCache bool
+	Pull    bool
 }
 
 var buildCmd = &cobra.Command{
@@ -19,14 +26,19 @@ var buildCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return controlComponents(args, func(ctx context.Context, ws api.Workspace, refs []string) (jobID string, err error) {
 			if refs == nil {
-				output, err := ws.Build(ctx, &api.BuildInput{})
+				output, err := ws.Build(ctx, &api.BuildInput{
+					NoCache: buildFlags.NoCache,
+					Pull:    buildFlags.Pull,
+				})
 				if output != nil {
 					jobID = output.JobID
 				}
 				return jobID, err
 			} else {
 				output, err := ws.BuildComponents(ctx, &api.BuildComponentsInput{
-					Refs: refs,
+					Refs:    refs,
+					NoCache: buildFlags.NoCache,
+					Pull:    buildFlags.Pull,
 				})
 				if output != nil {
 					jobID = output.JobID
@@ -0,0 +1,28 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/deref/exo/internal/lsp"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(lspCmd)
+}
+
+var lspCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "Runs a language server for exo.hcl files",
+	Long: `Runs a language server for exo.hcl manifest files, speaking the Language
+Server Protocol over stdin and stdout.
+
+Configure your editor to launch this command for exo.hcl files to get
+diagnostics, completion, hover documentation, go-to-definition for
+depends_on, and formatting.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := newContext()
+		return lsp.NewServer(os.Stdin, os.Stdout).Serve(ctx)
+	},
+}
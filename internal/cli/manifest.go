@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
+	"strings"
 
 	"github.com/deref/exo/internal/manifest"
 	"github.com/deref/exo/internal/manifest/exohcl"
@@ -18,10 +20,12 @@ func init() {
 	rootCmd.AddCommand(manifestCmd)
 	manifestCmd.AddCommand(makeHelpSubcmd())
 	manifestCmd.PersistentFlags().StringVar(&manifestFlags.Format, "format", "", "exo, compose, procfile")
+	manifestCmd.PersistentFlags().StringVar(&manifestFlags.Profile, "profile", "", "name of profile to apply to the manifest")
 }
 
 var manifestFlags struct {
-	Format string
+	Format  string
+	Profile string
 }
 
 var manifestCmd = &cobra.Command{
@@ -43,6 +47,10 @@ func loadManifest(ctx context.Context, diagW io.Writer, name string) (*exohcl.Ma
 		Format:        manifestFlags.Format,
 		Filename:      name,
 		Bytes:         bs,
+		Profile:       manifestFlags.Profile,
+		// Manifest tools run without a workspace, so env() reads the
+		// environment of this process.
+		Env: osEnvironment(),
 	}
 
 	analysisContext := &exohcl.AnalysisContext{
@@ -53,7 +61,16 @@ func loadManifest(ctx context.Context, diagW io.Writer, name string) (*exohcl.Ma
 
 	// Print diagnostics.
 	diags := analysisContext.Diagnostics
-	files := map[string]*hcl.File{} // TODO: Populate map for .hcl input files.
+	files := map[string]*hcl.File{}
+	format := loader.Format
+	if format == "" {
+		format = manifest.GuessFormat(name)
+	}
+	if m != nil && m.File != nil && format == "exo" {
+		// Source snippets are only meaningful for files that were not imported
+		// from another format.
+		files[name] = m.File
+	}
 	width, _ := term.GetSize()
 	enableColor := true // https://github.com/deref/exo/issues/179
 	diagTextW := hcl.NewDiagnosticTextWriter(diagW, files, uint(width), enableColor)
@@ -67,3 +84,12 @@ func loadManifest(ctx context.Context, diagW io.Writer, name string) (*exohcl.Ma
 
 	return m, nil
 }
+
+func osEnvironment() map[string]string {
+	env := make(map[string]string)
+	for _, assign := range os.Environ() {
+		parts := strings.SplitN(assign, "=", 2)
+		env[parts[0]] = parts[1]
+	}
+	return env
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/deref/exo/internal/core/api"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	manifestCmd.AddCommand(manifestExportCmd)
+	// Distinct from the inherited --format flag, which is the format of the
+	// manifest being read.
+	manifestExportCmd.Flags().StringVar(&manifestExportFlags.OutputFormat, "output-format", "compose", "compose, procfile")
+}
+
+var manifestExportFlags struct {
+	OutputFormat string
+}
+
+var manifestExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Exports the workspace's components as a manifest",
+	Long: `Renders the components of the current workspace as a manifest in the given
+format, given by --output-format, and prints it.
+
+The compose format includes container, network, and volume components, so
+that the same stack can be run with docker-compose. The procfile format
+includes process components.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := newContext()
+		checkOrEnsureServer()
+
+		cl := newClient()
+		workspace := requireCurrentWorkspace(ctx, cl)
+		switch manifestExportFlags.OutputFormat {
+		case "compose":
+			output, err := workspace.ExportCompose(ctx, &api.ExportComposeInput{})
+			if err != nil {
+				return err
+			}
+			for _, warning := range output.Warnings {
+				fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+			}
+			fmt.Print(output.Compose)
+		case "procfile":
+			output, err := workspace.ExportProcfile(ctx, &api.ExportProcfileInput{})
+			if err != nil {
+				return err
+			}
+			fmt.Print(output.Procfile)
+		default:
+			return fmt.Errorf("unsupported output format: %q", manifestExportFlags.OutputFormat)
+		}
+		return nil
+	},
+}
@@ -15,6 +15,11 @@ import (
 func init() {
 	rootCmd.AddCommand(runCmd)
 	runCmd.Flags().StringVar(&applyFlags.Format, "format", "", "see `exo help apply`")
+	runCmd.Flags().BoolVar(&applyFlags.Adopt, "adopt", false, "see `exo help apply`")
+	runCmd.Flags().StringVar(&applyFlags.Profile, "profile", "", "see `exo help apply`")
+	runCmd.Flags().BoolVar(&applyFlags.WatchManifest, "watch-manifest", false, "see `exo help apply`")
+	runCmd.Flags().StringVarP(&applyFlags.Formation, "formation", "m", "", "see `exo help apply`")
+	runCmd.Flags().StringSliceVarP(&applyFlags.EnvFiles, "env", "e", nil, "see `exo help apply`")
 }
 
 var runCmd = &cobra.Command{
@@ -56,6 +61,7 @@ If a workspace does not exist, one will be created in the current directory.
 		}
 
 		// Apply manifest.
+		applyFlags.WatchManifestSet = cmd.Flags().Changed("watch-manifest")
 		if err := apply(ctx, kernel, workspace, args); err != nil {
 			return fmt.Errorf("applying manifest: %w", err)
 		}
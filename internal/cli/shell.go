@@ -0,0 +1,222 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/deref/exo/internal/core/api"
+	"github.com/deref/exo/internal/core/client"
+	"github.com/deref/exo/internal/providers/docker"
+	"github.com/deref/exo/internal/providers/docker/components/container"
+	"github.com/deref/exo/internal/providers/unix/components/process"
+	"github.com/deref/exo/internal/util/cmdutil"
+	"github.com/deref/exo/internal/util/term"
+	"github.com/deref/exo/internal/util/which"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(shellCmd)
+}
+
+var shellCmd = &cobra.Command{
+	Use:   "shell [flags] <component> [-- <program> [argument ...]]",
+	Short: "Open a shell in a component",
+	Long: `Opens an interactive shell in the context of a running component.
+
+For containers, the shell is executed inside the container. For processes,
+the shell is started in the process' working directory with its full
+environment.
+
+If a program is provided, it is executed instead of the default shell.`,
+	Args:                  cobra.MinimumNArgs(1),
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		componentRef := args[0]
+		command := args[1:]
+		ctx := newContext()
+		checkOrEnsureServer()
+		cl := newClient()
+
+		workspace := requireCurrentWorkspace(ctx, cl)
+		description, err := workspace.DescribeComponents(ctx, &api.DescribeComponentsInput{
+			Refs: []string{componentRef},
+		})
+		if err != nil {
+			return fmt.Errorf("describing components: %w", err)
+		}
+		if len(description.Components) != 1 {
+			return fmt.Errorf("no such component: %q", componentRef)
+		}
+		component := description.Components[0]
+
+		switch component.Type {
+		case "container":
+			exitCode, err := shellContainer(ctx, component, command)
+			if err != nil {
+				return err
+			}
+			os.Exit(exitCode)
+			panic("unreachable")
+		case "process":
+			return shellProcess(ctx, workspace, component, command)
+		default:
+			return fmt.Errorf("cannot open shell in component of type %q", component.Type)
+		}
+	},
+}
+
+// Returns the exit code of the executed command.
+func shellContainer(ctx context.Context, component api.ComponentDescription, command []string) (int, error) {
+	var state container.State
+	if err := json.Unmarshal([]byte(component.State), &state); err != nil {
+		return 0, fmt.Errorf("unmarshalling state: %w", err)
+	}
+	if state.ContainerID == "" {
+		return 0, fmt.Errorf("container %q has not been created", component.Name)
+	}
+	if len(command) == 0 {
+		command = []string{"/bin/sh"}
+	}
+
+	dockerClient, err := docker.NewRuntime(cfg.Container)
+	if err != nil {
+		return 0, fmt.Errorf("creating container runtime client: %w", err)
+	}
+	defer dockerClient.Close()
+
+	stdin := os.Stdin.Fd()
+	tty := isatty.IsTerminal(stdin)
+
+	created, err := dockerClient.ContainerExecCreate(ctx, state.ContainerID, types.ExecConfig{
+		Tty:          tty,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+		Cmd:          command,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("creating exec: %w", err)
+	}
+
+	attached, err := dockerClient.ContainerExecAttach(ctx, created.ID, types.ExecStartCheck{
+		Tty: tty,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("attaching to exec: %w", err)
+	}
+	defer attached.Close()
+
+	if tty {
+		raw := &term.RawMode{
+			FD: stdin,
+		}
+		if err := raw.Enter(); err != nil {
+			return 0, fmt.Errorf("entering raw mode: %w", err)
+		}
+		defer func() {
+			if err := raw.Exit(); err != nil {
+				cmdutil.Warnf("restoring terminal state: %v", err)
+			}
+		}()
+
+		resize := func() {
+			w, h := term.GetSize()
+			if w == 0 || h == 0 {
+				return
+			}
+			_ = dockerClient.ContainerExecResize(ctx, created.ID, types.ResizeOptions{
+				Width:  uint(w),
+				Height: uint(h),
+			})
+		}
+		resize()
+		winch := make(chan os.Signal, 1)
+		signal.Notify(winch, syscall.SIGWINCH)
+		defer signal.Stop(winch)
+		go func() {
+			for range winch {
+				resize()
+			}
+		}()
+	}
+
+	go func() {
+		_, _ = io.Copy(attached.Conn, os.Stdin)
+		_ = attached.CloseWrite()
+	}()
+
+	if tty {
+		_, err = io.Copy(os.Stdout, attached.Reader)
+	} else {
+		_, err = stdcopy.StdCopy(os.Stdout, os.Stderr, attached.Reader)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("copying output: %w", err)
+	}
+
+	inspection, err := dockerClient.ContainerExecInspect(ctx, created.ID)
+	if err != nil {
+		return 0, fmt.Errorf("inspecting exec: %w", err)
+	}
+	return inspection.ExitCode, nil
+}
+
+func shellProcess(ctx context.Context, workspace *client.Workspace, component api.ComponentDescription, command []string) error {
+	var state process.State
+	if err := json.Unmarshal([]byte(component.State), &state); err != nil {
+		return fmt.Errorf("unmarshalling state: %w", err)
+	}
+	if state.Pid == 0 || state.FullEnvironment == nil {
+		return fmt.Errorf("process %q is not running", component.Name)
+	}
+
+	directory := state.Directory
+	if directory == "" {
+		workspaceDescription, err := workspace.Describe(ctx, &api.DescribeInput{})
+		if err != nil {
+			return fmt.Errorf("describing workspace: %w", err)
+		}
+		directory = workspaceDescription.Description.Root
+	}
+
+	if len(command) == 0 {
+		shell := state.FullEnvironment["SHELL"]
+		if shell == "" {
+			shell = os.Getenv("SHELL")
+		}
+		if shell == "" {
+			shell = "/bin/sh"
+		}
+		command = []string{shell}
+	}
+
+	program, err := which.Query{
+		WorkingDirectory: directory,
+		PathVariable:     state.FullEnvironment["PATH"],
+		Program:          command[0],
+	}.Run()
+	if err != nil {
+		return fmt.Errorf("resolving program: %w", err)
+	}
+
+	envv := make([]string, 0, len(state.FullEnvironment))
+	for k, v := range state.FullEnvironment {
+		envv = append(envv, k+"="+v)
+	}
+
+	if err := os.Chdir(directory); err != nil {
+		return fmt.Errorf("changing directory: %w", err)
+	}
+	err = syscall.Exec(program, command, envv)
+	cmdutil.Fatalf("%v", err)
+	panic("unreachable")
+}
@@ -0,0 +1,17 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(volumeCmd)
+	volumeCmd.AddCommand(makeHelpSubcmd())
+}
+
+var volumeCmd = &cobra.Command{
+	Use:   "volume",
+	Short: "Volume tools",
+	Long:  `Contains subcommands for snapshotting and restoring volume contents.`,
+	Args:  cobra.NoArgs,
+}
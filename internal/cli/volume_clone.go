@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"github.com/deref/exo/internal/core/api"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	volumeCmd.AddCommand(volumeCloneCmd)
+}
+
+var volumeCloneCmd = &cobra.Command{
+	Use:   "clone <source> <target>",
+	Short: "Copies the contents of one volume to another",
+	Long: `Replaces the contents of the target volume with those of the source
+volume.
+
+Containers using the target volume must be stopped first.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := newContext()
+		checkOrEnsureServer()
+		cl := newClient()
+		kernel := cl.Kernel()
+		workspace := requireCurrentWorkspace(ctx, cl)
+		output, err := workspace.CloneVolume(ctx, &api.CloneVolumeInput{
+			Source: args[0],
+			Target: args[1],
+		})
+		if err != nil {
+			return err
+		}
+		return watchJob(ctx, kernel, output.JobID)
+	},
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"github.com/deref/exo/internal/core/api"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	volumeCmd.AddCommand(volumeRestoreCmd)
+}
+
+var volumeRestoreCmd = &cobra.Command{
+	Use:   "restore <volume> <name>",
+	Short: "Restores the contents of a volume from a snapshot",
+	Long: `Replaces the contents of a volume with those of a snapshot taken by
+'exo volume snapshot'.
+
+Containers using the volume must be stopped first.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := newContext()
+		checkOrEnsureServer()
+		cl := newClient()
+		kernel := cl.Kernel()
+		workspace := requireCurrentWorkspace(ctx, cl)
+		output, err := workspace.RestoreVolume(ctx, &api.RestoreVolumeInput{
+			Ref:  args[0],
+			Name: args[1],
+		})
+		if err != nil {
+			return err
+		}
+		return watchJob(ctx, kernel, output.JobID)
+	},
+}
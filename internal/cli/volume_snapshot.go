@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/deref/exo/internal/core/api"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	volumeCmd.AddCommand(volumeSnapshotCmd)
+}
+
+var volumeSnapshotCmd = &cobra.Command{
+	Use:   "snapshot <volume> [name]",
+	Short: "Saves the contents of a volume",
+	Long: `Saves the contents of a volume to a named snapshot, which can later be
+restored with 'exo volume restore'. If no name is given, one is generated from
+the current time. An existing snapshot with the same name is replaced.
+
+Containers using the volume may keep running, but a warning is reported, since
+the snapshot may not be consistent while they write to the volume.
+
+Snapshots are stored in exo's var directory.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := newContext()
+		checkOrEnsureServer()
+		cl := newClient()
+		kernel := cl.Kernel()
+		workspace := requireCurrentWorkspace(ctx, cl)
+		input := &api.SnapshotVolumeInput{
+			Ref: args[0],
+		}
+		if len(args) > 1 {
+			input.Name = args[1]
+		}
+		output, err := workspace.SnapshotVolume(ctx, input)
+		if err != nil {
+			return err
+		}
+		if err := watchJob(ctx, kernel, output.JobID); err != nil {
+			return err
+		}
+		fmt.Println(output.Name)
+		return nil
+	},
+}
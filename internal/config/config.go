@@ -20,6 +20,17 @@ type GUIConfig struct {
 
 type LogConfig struct {
 	SyslogPort uint
+	// How logs are collected from containers. Either "syslog" or "follow".
+	// Defaults to "syslog", or to "follow" with the Podman runtime, which does
+	// not implement the syslog log driver.
+	ContainerMode string
+}
+
+type ContainerConfig struct {
+	// One of "docker" or "podman". Defaults to "docker".
+	Runtime string
+	// Path to the runtime's API socket. Defaults depend on the runtime.
+	Socket string
 }
 
 type TelemetryConfig struct {
@@ -42,6 +53,7 @@ type Config struct {
 	Client    ClientConfig
 	GUI       GUIConfig `toml:"gui"`
 	Log       LogConfig
+	Container ContainerConfig
 	Telemetry TelemetryConfig
 }
 
@@ -68,7 +80,7 @@ func LoadDefault(cfg *Config) error {
 
 	setDefaults(cfg)
 
-	return nil
+	return validate(cfg)
 }
 
 func MustLoadDefault(cfg *Config) {
@@ -119,9 +131,23 @@ func setDefaults(cfg *Config) {
 	if cfg.Log.SyslogPort == 0 {
 		cfg.Log.SyslogPort = 43550
 	}
+	if cfg.Log.ContainerMode == "" {
+		if cfg.Container.Runtime == "podman" {
+			cfg.Log.ContainerMode = "follow"
+		} else {
+			cfg.Log.ContainerMode = "syslog"
+		}
+	}
 
 	// GUI
 	if cfg.GUI.Port == 0 {
 		cfg.GUI.Port = 3000
 	}
 }
+
+func validate(cfg *Config) error {
+	if cfg.Container.Runtime == "podman" && cfg.Log.ContainerMode == "syslog" {
+		return fmt.Errorf("log.containerMode %q is not supported by the podman container runtime; use %q", "syslog", "follow")
+	}
+	return nil
+}
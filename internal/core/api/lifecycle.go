@@ -13,6 +13,10 @@ type Lifecycle interface {
 	Dependencies(context.Context, *DependenciesInput) (*DependenciesOutput, error)
 	Initialize(context.Context, *InitializeInput) (*InitializeOutput, error)
 	Refresh(context.Context, *RefreshInput) (*RefreshOutput, error)
+	// Determines whether a change of spec can be applied to the existing resources by update, or whether the component must be disposed and re-initialized.
+	PlanUpdate(context.Context, *PlanUpdateInput) (*PlanUpdateOutput, error)
+	// Applies a change of spec in place. Only called when plan-update does not require replacement.
+	Update(context.Context, *UpdateInput) (*UpdateOutput, error)
 	Dispose(context.Context, *DisposeInput) (*DisposeOutput, error)
 }
 
@@ -28,6 +32,10 @@ type DependenciesOutput struct {
 
 type InitializeInput struct {
 	Spec string `json:"spec"`
+	// If true, existing resources that docker-compose created for this component are taken over, rather than replaced.
+	Adopt bool `json:"adopt"`
+	// If true, the component is created without being started.
+	NoStart bool `json:"noStart"`
 }
 
 type InitializeOutput struct {
@@ -40,6 +48,23 @@ type RefreshInput struct {
 type RefreshOutput struct {
 }
 
+type PlanUpdateInput struct {
+	OldSpec string `json:"oldSpec"`
+	NewSpec string `json:"newSpec"`
+}
+
+type PlanUpdateOutput struct {
+	Replace bool `json:"replace"`
+}
+
+type UpdateInput struct {
+	OldSpec string `json:"oldSpec"`
+	NewSpec string `json:"newSpec"`
+}
+
+type UpdateOutput struct {
+}
+
 type DisposeInput struct {
 }
 
@@ -56,6 +81,12 @@ func BuildLifecycleMux(b *josh.MuxBuilder, factory func(req *http.Request) Lifec
 	b.AddMethod("refresh", func(req *http.Request) interface{} {
 		return factory(req).Refresh
 	})
+	b.AddMethod("plan-update", func(req *http.Request) interface{} {
+		return factory(req).PlanUpdate
+	})
+	b.AddMethod("update", func(req *http.Request) interface{} {
+		return factory(req).Update
+	})
 	b.AddMethod("dispose", func(req *http.Request) interface{} {
 		return factory(req).Dispose
 	})
@@ -10,12 +10,34 @@ interface "lifecycle" {
 
   method "initialize" {
     input "spec" "string" {}
+    input "adopt" "bool" {
+      doc = "If true, existing resources that docker-compose created for this component are taken over, rather than replaced."
+    }
+    input "no-start" "bool" {
+      doc = "If true, the component is created without being started."
+    }
   }
 
   method "refresh" {
     input "spec" "string" {}
   }
 
+  method "plan-update" {
+    doc = "Determines whether a change of spec can be applied to the existing resources by update, or whether the component must be disposed and re-initialized."
+
+    input "old-spec" "string" {}
+    input "new-spec" "string" {}
+
+    output "replace" "bool" {}
+  }
+
+  method "update" {
+    doc = "Applies a change of spec in place. Only called when plan-update does not require replacement."
+
+    input "old-spec" "string" {}
+    input "new-spec" "string" {}
+  }
+
   method "dispose" {
     // TODO: output promise for awaiting synchronous deletes.
   }
@@ -67,6 +67,11 @@ type Builder interface {
 }
 
 type BuildInput struct {
+
+	// Do not use cached layers when building images.
+	NoCache bool `json:"noCache"`
+	// Attempt to pull newer versions of base images.
+	Pull bool `json:"pull"`
 }
 
 type BuildOutput struct {
@@ -117,8 +122,16 @@ type Workspace interface {
 	RestartComponents(context.Context, *RestartComponentsInput) (*RestartComponentsOutput, error)
 	DescribeProcesses(context.Context, *DescribeProcessesInput) (*DescribeProcessesOutput, error)
 	DescribeVolumes(context.Context, *DescribeVolumesInput) (*DescribeVolumesOutput, error)
+	// Saves the contents of a volume to a named snapshot.
+	SnapshotVolume(context.Context, *SnapshotVolumeInput) (*SnapshotVolumeOutput, error)
+	// Replaces the contents of a volume with those of a snapshot.
+	RestoreVolume(context.Context, *RestoreVolumeInput) (*RestoreVolumeOutput, error)
+	// Replaces the contents of a volume with those of another volume.
+	CloneVolume(context.Context, *CloneVolumeInput) (*CloneVolumeOutput, error)
 	DescribeNetworks(context.Context, *DescribeNetworksInput) (*DescribeNetworksOutput, error)
 	ExportProcfile(context.Context, *ExportProcfileInput) (*ExportProcfileOutput, error)
+	// Renders the container, network, and volume components as a compose file.
+	ExportCompose(context.Context, *ExportComposeInput) (*ExportComposeOutput, error)
 	// Read a file from disk.
 	ReadFile(context.Context, *ReadFileInput) (*ReadFileOutput, error)
 	// Writes a file to disk.
@@ -171,11 +184,26 @@ type ApplyInput struct {
 	ManifestPath *string `json:"manifestPath"`
 	// Contents of the manifest file. Not required if manifest-path is provided.
 	Manifest *string `json:"manifest"`
+	// If true, components being added take over existing resources created by docker-compose for the same project and service.
+	Adopt bool `json:"adopt"`
+	// Name of profile whose overlays are applied to the manifest. Remembered by the workspace until the next apply.
+	Profile string `json:"profile"`
+	// If provided, enables or disables re-applying the manifest whenever it or the .env file of the workspace changes.
+	WatchManifest *bool `json:"watchManifest"`
+	// Procfile only. Number of instances of each process, such as 'web=2,worker=1'. Overrides the formation of a .foreman file.
+	Formation string `json:"formation"`
+	// Procfile only. Env files whose variables are given to every process, relative to the Procfile. Overrides the env files of a .foreman file.
+	EnvFiles []string `json:"envFiles"`
+	// If true, returns the plan without changing any components or workspace settings, and without starting a job.
+	Plan bool `json:"plan"`
 }
 
 type ApplyOutput struct {
 	Warnings []string `json:"warnings"`
-	JobID    string   `json:"jobId"`
+	// Empty when only planning.
+	JobID string `json:"jobId"`
+	// Components that are created, updated, replaced, or deleted. Unchanged components are omitted.
+	Plan []ComponentPlan `json:"plan"`
 }
 
 type ResolveInput struct {
@@ -215,6 +243,11 @@ type CreateComponentInput struct {
 	Type      string   `json:"type"`
 	Spec      string   `json:"spec"`
 	DependsOn []string `json:"dependsOn"`
+	// If true, take over existing resources created by docker-compose, rather than replacing them.
+	Adopt  bool              `json:"adopt"`
+	Labels map[string]string `json:"labels"`
+	// Either auto, the default, or manual. Components with the manual start policy are created without being started, and are skipped when starting or restarting the whole workspace.
+	StartPolicy string `json:"startPolicy"`
 }
 
 type CreateComponentOutput struct {
@@ -352,6 +385,35 @@ type DescribeVolumesOutput struct {
 	Volumes []VolumeDescription `json:"volumes"`
 }
 
+type SnapshotVolumeInput struct {
+	Ref string `json:"ref"`
+	// Defaults to a name derived from the current time. Existing snapshots with the same name are replaced.
+	Name string `json:"name"`
+}
+
+type SnapshotVolumeOutput struct {
+	Name  string `json:"name"`
+	JobID string `json:"jobId"`
+}
+
+type RestoreVolumeInput struct {
+	Ref  string `json:"ref"`
+	Name string `json:"name"`
+}
+
+type RestoreVolumeOutput struct {
+	JobID string `json:"jobId"`
+}
+
+type CloneVolumeInput struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+type CloneVolumeOutput struct {
+	JobID string `json:"jobId"`
+}
+
 type DescribeNetworksInput struct {
 }
 
@@ -366,6 +428,15 @@ type ExportProcfileOutput struct {
 	Procfile string `json:"procfile"`
 }
 
+type ExportComposeInput struct {
+}
+
+type ExportComposeOutput struct {
+	Compose string `json:"compose"`
+	// Describes components that could not be exported.
+	Warnings []string `json:"warnings"`
+}
+
 type ReadFileInput struct {
 
 	// Relative to the workspace directory. May not traverse higher in the filesystem.
@@ -388,7 +459,9 @@ type WriteFileOutput struct {
 }
 
 type BuildComponentsInput struct {
-	Refs []string `json:"refs"`
+	Refs    []string `json:"refs"`
+	NoCache bool     `json:"noCache"`
+	Pull    bool     `json:"pull"`
 }
 
 type BuildComponentsOutput struct {
@@ -497,12 +570,24 @@ func BuildWorkspaceMux(b *josh.MuxBuilder, factory func(req *http.Request) Works
 	b.AddMethod("describe-volumes", func(req *http.Request) interface{} {
 		return factory(req).DescribeVolumes
 	})
+	b.AddMethod("snapshot-volume", func(req *http.Request) interface{} {
+		return factory(req).SnapshotVolume
+	})
+	b.AddMethod("restore-volume", func(req *http.Request) interface{} {
+		return factory(req).RestoreVolume
+	})
+	b.AddMethod("clone-volume", func(req *http.Request) interface{} {
+		return factory(req).CloneVolume
+	})
 	b.AddMethod("describe-networks", func(req *http.Request) interface{} {
 		return factory(req).DescribeNetworks
 	})
 	b.AddMethod("export-procfile", func(req *http.Request) interface{} {
 		return factory(req).ExportProcfile
 	})
+	b.AddMethod("export-compose", func(req *http.Request) interface{} {
+		return factory(req).ExportCompose
+	})
 	b.AddMethod("read-file", func(req *http.Request) interface{} {
 		return factory(req).ReadFile
 	})
@@ -524,6 +609,23 @@ type WorkspaceDescription struct {
 	ID          string `json:"id"`
 	Root        string `json:"root"`
 	DisplayName string `json:"displayName"`
+	// Name of the profile used by the most recent apply, if any.
+	Profile string `json:"profile"`
+	// If true, the manifest is re-applied whenever it or the .env file of the workspace changes.
+	WatchManifest bool `json:"watchManifest"`
+}
+
+type ComponentPlan struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// One of 'create', 'update', 'replace', or 'delete'.
+	Action string `json:"action"`
+	// Why the action is taken.
+	Reason string `json:"reason"`
+	// Unified diff from the current spec to the spec in the manifest. Empty if the spec is unchanged.
+	SpecDiff string `json:"specDiff"`
+	// For a replacement caused by replacing a dependency, the chain of dependencies leading to the replaced one, such as ['api', 'db'].
+	TriggeredBy []string `json:"triggeredBy"`
 }
 
 type ComponentDescription struct {
@@ -534,6 +636,10 @@ type ComponentDescription struct {
 	State     string   `json:"state"`
 	Created   string   `json:"created"`
 	DependsOn []string `json:"dependsOn"`
+	// Digest of the workspace environment variables that the component was created with, or empty if it uses none. A component whose environment changes is replaced on apply.
+	EnvironmentDigest string            `json:"environmentDigest"`
+	Labels            map[string]string `json:"labels"`
+	StartPolicy       string            `json:"startPolicy"`
 }
 
 type StreamDescription struct {
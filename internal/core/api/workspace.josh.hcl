@@ -22,6 +22,12 @@ interface "process" {
 # XXX Same story as above "process" interface.
 interface "builder" {
   method "build" {
+    input "no-cache" "bool" {
+      doc = "Do not use cached layers when building images."
+    }
+    input "pull" "bool" {
+      doc = "Attempt to pull newer versions of base images."
+    }
     output "job-id" "string" {}
   }
 }
@@ -66,9 +72,32 @@ interface "workspace" {
     input "manifest" "*string" {
       doc = "Contents of the manifest file. Not required if manifest-path is provided."
     }
+    input "adopt" "bool" {
+      doc = "If true, components being added take over existing resources created by docker-compose for the same project and service."
+    }
+    input "profile" "string" {
+      doc = "Name of profile whose overlays are applied to the manifest. Remembered by the workspace until the next apply."
+    }
+    input "watch-manifest" "*bool" {
+      doc = "If provided, enables or disables re-applying the manifest whenever it or the .env file of the workspace changes."
+    }
+    input "formation" "string" {
+      doc = "Procfile only. Number of instances of each process, such as 'web=2,worker=1'. Overrides the formation of a .foreman file."
+    }
+    input "env-files" "[]string" {
+      doc = "Procfile only. Env files whose variables are given to every process, relative to the Procfile. Overrides the env files of a .foreman file."
+    }
+    input "plan" "bool" {
+      doc = "If true, returns the plan without changing any components or workspace settings, and without starting a job."
+    }
 
     output "warnings" "[]string" {}
-    output "job-id" "string" {}
+    output "job-id" "string" {
+      doc = "Empty when only planning."
+    }
+    output "plan" "[]ComponentPlan" {
+      doc = "Components that are created, updated, replaced, or deleted. Unchanged components are omitted."
+    }
   }
 
   method "resolve" {
@@ -114,6 +143,13 @@ interface "workspace" {
     input "type" "string" {}
     input "spec" "string" {}
     input "depends-on" "[]string" {}
+    input "adopt" "bool" {
+      doc = "If true, take over existing resources created by docker-compose, rather than replacing them."
+    }
+    input "labels" "map[string]string" {}
+    input "start-policy" "string" {
+      doc = "Either auto, the default, or manual. Components with the manual start policy are created without being started, and are skipped when starting or restarting the whole workspace."
+    }
 
     output "id" "string" {}
     output "job-id" "string" {}
@@ -228,6 +264,30 @@ interface "workspace" {
     output "volumes" "[]VolumeDescription" {}
   }
 
+  method "snapshot-volume" {
+    doc = "Saves the contents of a volume to a named snapshot."
+    input "ref" "string" {}
+    input "name" "string" {
+      doc = "Defaults to a name derived from the current time. Existing snapshots with the same name are replaced."
+    }
+    output "name" "string" {}
+    output "job-id" "string" {}
+  }
+
+  method "restore-volume" {
+    doc = "Replaces the contents of a volume with those of a snapshot."
+    input "ref" "string" {}
+    input "name" "string" {}
+    output "job-id" "string" {}
+  }
+
+  method "clone-volume" {
+    doc = "Replaces the contents of a volume with those of another volume."
+    input "source" "string" {}
+    input "target" "string" {}
+    output "job-id" "string" {}
+  }
+
   method "describe-networks" {
     output "networks" "[]NetworkDescription" {}
   }
@@ -236,6 +296,15 @@ interface "workspace" {
     output "procfile" "string" {}
   }
 
+  method "export-compose" {
+    doc = "Renders the container, network, and volume components as a compose file."
+
+    output "compose" "string" {}
+    output "warnings" "[]string" {
+      doc = "Describes components that could not be exported."
+    }
+  }
+
   method "read-file" {
     doc = "Read a file from disk."
 
@@ -257,6 +326,8 @@ interface "workspace" {
 
   method "build-components" {
     input "refs" "[]string" {}
+    input "no-cache" "bool" {}
+    input "pull" "bool" {}
     output "job-id" "string" {}
   }
 
@@ -273,6 +344,29 @@ struct "workspace-description" {
   field "id" "string" {}
   field "root" "string" {}
   field "display-name" "string" {}
+  field "profile" "string" {
+    doc = "Name of the profile used by the most recent apply, if any."
+  }
+  field "watch-manifest" "bool" {
+    doc = "If true, the manifest is re-applied whenever it or the .env file of the workspace changes."
+  }
+}
+
+struct "component-plan" {
+  field "name" "string" {}
+  field "type" "string" {}
+  field "action" "string" {
+    doc = "One of 'create', 'update', 'replace', or 'delete'."
+  }
+  field "reason" "string" {
+    doc = "Why the action is taken."
+  }
+  field "spec-diff" "string" {
+    doc = "Unified diff from the current spec to the spec in the manifest. Empty if the spec is unchanged."
+  }
+  field "triggered-by" "[]string" {
+    doc = "For a replacement caused by replacing a dependency, the chain of dependencies leading to the replaced one, such as ['api', 'db']."
+  }
 }
 
 struct "component-description" {
@@ -283,6 +377,11 @@ struct "component-description" {
   field "state" "string" {}
   field "created" "string" {}
   field "depends-on" "[]string" {}
+  field "environment-digest" "string" {
+    doc = "Digest of the workspace environment variables that the component was created with, or empty if it uses none. A component whose environment changes is replaced on apply."
+  }
+  field "labels" "map[string]string" {}
+  field "start-policy" "string" {}
 }
 
 struct "stream-description" {
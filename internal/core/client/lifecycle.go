@@ -36,6 +36,16 @@ func (c *Lifecycle) Refresh(ctx context.Context, input *api.RefreshInput) (outpu
 	return
 }
 
+func (c *Lifecycle) PlanUpdate(ctx context.Context, input *api.PlanUpdateInput) (output *api.PlanUpdateOutput, err error) {
+	err = c.client.Invoke(ctx, "plan-update", input, &output)
+	return
+}
+
+func (c *Lifecycle) Update(ctx context.Context, input *api.UpdateInput) (output *api.UpdateOutput, err error) {
+	err = c.client.Invoke(ctx, "update", input, &output)
+	return
+}
+
 func (c *Lifecycle) Dispose(ctx context.Context, input *api.DisposeInput) (output *api.DisposeOutput, err error) {
 	err = c.client.Invoke(ctx, "dispose", input, &output)
 	return
@@ -215,6 +215,21 @@ func (c *Workspace) DescribeVolumes(ctx context.Context, input *api.DescribeVolu
 	return
 }
 
+func (c *Workspace) SnapshotVolume(ctx context.Context, input *api.SnapshotVolumeInput) (output *api.SnapshotVolumeOutput, err error) {
+	err = c.client.Invoke(ctx, "snapshot-volume", input, &output)
+	return
+}
+
+func (c *Workspace) RestoreVolume(ctx context.Context, input *api.RestoreVolumeInput) (output *api.RestoreVolumeOutput, err error) {
+	err = c.client.Invoke(ctx, "restore-volume", input, &output)
+	return
+}
+
+func (c *Workspace) CloneVolume(ctx context.Context, input *api.CloneVolumeInput) (output *api.CloneVolumeOutput, err error) {
+	err = c.client.Invoke(ctx, "clone-volume", input, &output)
+	return
+}
+
 func (c *Workspace) DescribeNetworks(ctx context.Context, input *api.DescribeNetworksInput) (output *api.DescribeNetworksOutput, err error) {
 	err = c.client.Invoke(ctx, "describe-networks", input, &output)
 	return
@@ -225,6 +240,11 @@ func (c *Workspace) ExportProcfile(ctx context.Context, input *api.ExportProcfil
 	return
 }
 
+func (c *Workspace) ExportCompose(ctx context.Context, input *api.ExportComposeInput) (output *api.ExportComposeOutput, err error) {
+	err = c.client.Invoke(ctx, "export-compose", input, &output)
+	return
+}
+
 func (c *Workspace) ReadFile(ctx context.Context, input *api.ReadFileInput) (output *api.ReadFileOutput, err error) {
 	err = c.client.Invoke(ctx, "read-file", input, &output)
 	return
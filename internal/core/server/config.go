@@ -14,6 +14,7 @@ import (
 	"github.com/deref/exo/internal/manifest"
 	"github.com/deref/exo/internal/manifest/exohcl"
 	"github.com/deref/exo/internal/manifest/exohcl/hclgen"
+	"github.com/deref/exo/internal/manifest/procfile"
 	"github.com/deref/exo/internal/util/errutil"
 	"github.com/deref/exo/internal/util/osutil"
 	"github.com/deref/exo/internal/util/pathutil"
@@ -40,7 +41,9 @@ func (ws *Workspace) tryLoadManifest(ctx context.Context) *exohcl.Manifest {
 	if err != nil {
 		return nil
 	}
-	m, _ := ws.loadManifest(ctx, wsDesc.Root, &api.ApplyInput{})
+	m, _ := ws.loadManifest(ctx, wsDesc.Root, &api.ApplyInput{
+		Profile: wsDesc.Profile,
+	})
 	return m
 }
 
@@ -49,6 +52,9 @@ func (ws *Workspace) loadManifest(ctx context.Context, rootDir string, input *ap
 	manifestPath := ""
 	if input.ManifestPath != nil {
 		manifestPath = *input.ManifestPath
+		if !filepath.IsAbs(manifestPath) {
+			manifestPath = filepath.Join(rootDir, manifestPath)
+		}
 	}
 	if input.Manifest == nil {
 		if input.ManifestPath == nil {
@@ -75,9 +81,11 @@ func (ws *Workspace) loadManifest(ctx context.Context, rootDir string, input *ap
 		manifestString = *input.Manifest
 	}
 
-	// TODO: Get official name from workspace description.
-	workspaceName := path.Base(rootDir)
-	workspaceName = exohcl.MangleName(workspaceName)
+	workspaceName := manifestProjectName(rootDir)
+	env, err := ws.getManifestFunctionEnvironment(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting manifest environment: %w", err)
+	}
 
 	analysisContext := &exohcl.AnalysisContext{
 		Context: ctx,
@@ -87,6 +95,15 @@ func (ws *Workspace) loadManifest(ctx context.Context, rootDir string, input *ap
 		Format:        input.Format,
 		Filename:      manifestPath,
 		Bytes:         []byte(manifestString),
+		Profile:       input.Profile,
+		Procfile: procfile.Options{
+			Formation: input.Formation,
+			EnvFiles:  input.EnvFiles,
+		},
+		Env: env,
+		// The daemon may read files that the user cannot, so manifests may only
+		// read files within the workspace.
+		Files: &exohcl.Files{Root: rootDir},
 	}
 	m, err := loader.Load(analysisContext)
 	if err == nil && len(analysisContext.Diagnostics) > 0 {
@@ -146,3 +163,10 @@ func (ws *Workspace) resolveManifest(rootDir, format string) (string, error) {
 	}
 	return "", nil
 }
+
+// manifestProjectName returns the name that prefixes the Docker resources
+// created for imported compose projects.
+func manifestProjectName(rootDir string) string {
+	// TODO: Get official name from workspace description.
+	return exohcl.MangleName(path.Base(rootDir))
+}
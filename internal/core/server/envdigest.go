@@ -0,0 +1,120 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"path/filepath"
+	"sort"
+
+	"github.com/deref/exo/internal/providers/docker/compose"
+	"github.com/deref/exo/internal/providers/docker/compose/template"
+	"github.com/deref/exo/internal/providers/unix/processspec"
+	"github.com/deref/exo/internal/util/jsonutil"
+	"github.com/deref/exo/internal/util/yamlutil"
+)
+
+// environmentDigest summarizes the workspace environment variables that a
+// component of the given type and spec depends on, so that apply can tell
+// when a component must be re-created to observe a changed environment even
+// though its spec is unchanged. Processes inherit the entire environment,
+// along with the variables of their env files. Docker components depend on
+// the variables their spec interpolates and, for containers, the variables
+// passed through by name. Returns an empty string for components that do not
+// depend on the environment. root is the workspace root, against which
+// relative process directories are resolved.
+func environmentDigest(typ string, spec string, root string, env map[string]string) string {
+	var names []string
+	switch typ {
+	case "process":
+		env = withProcessEnvFiles(spec, root, env)
+		for name := range env {
+			names = append(names, name)
+		}
+	case "container":
+		var service compose.Service
+		names = interpolatedVariables(spec, &service, env)
+		for _, item := range service.Environment.Items {
+			if item.Value == "" {
+				names = append(names, item.Key)
+			}
+		}
+	case "volume":
+		names = interpolatedVariables(spec, &compose.Volume{}, env)
+	case "network":
+		names = interpolatedVariables(spec, &compose.Network{}, env)
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for i, name := range names {
+		if i > 0 && names[i-1] == name {
+			continue
+		}
+		value, ok := env[name]
+		if !ok {
+			continue
+		}
+		_, _ = io.WriteString(h, name)
+		_, _ = h.Write([]byte{0})
+		_, _ = io.WriteString(h, value)
+		_, _ = h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// withProcessEnvFiles returns env overlaid with the variables of the env files
+// of a process spec. Unreadable env files are reported by the process when it
+// starts, so are ignored here.
+func withProcessEnvFiles(spec string, root string, env map[string]string) map[string]string {
+	var process processspec.Spec
+	if err := jsonutil.UnmarshalString(spec, &process); err != nil || len(process.EnvFiles) == 0 {
+		return env
+	}
+	dir := process.Directory
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(root, dir)
+	}
+	envFileVars, err := processspec.ReadEnvFiles(dir, process.EnvFiles)
+	if err != nil {
+		return env
+	}
+	merged := make(map[string]string, len(env)+len(envFileVars))
+	for name, value := range env {
+		merged[name] = value
+	}
+	for name, value := range envFileVars {
+		merged[name] = value
+	}
+	return merged
+}
+
+// interpolatedVariables loads spec into v, returning the names of the
+// variables looked up while interpolating it. Specs that fail to load are
+// reported by the component itself, so they are treated as not depending on
+// the environment.
+func interpolatedVariables(spec string, v compose.Interpolator, env map[string]string) []string {
+	if err := yamlutil.UnmarshalString(spec, v); err != nil {
+		return nil
+	}
+	recorder := &recordingEnvironment{
+		Environment: compose.MapEnvironment(env),
+	}
+	if err := v.Interpolate(recorder); err != nil {
+		return nil
+	}
+	return recorder.Names
+}
+
+type recordingEnvironment struct {
+	compose.Environment
+	Names []string
+}
+
+func (env *recordingEnvironment) Lookup(v *template.Variable) (string, error) {
+	env.Names = append(env.Names, v.Name)
+	return env.Environment.Lookup(v)
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deref/exo/internal/core/api"
+	"github.com/deref/exo/internal/deps"
+	"github.com/deref/exo/internal/manifest/exohcl"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvironmentDigest(t *testing.T) {
+	env := map[string]string{"TAG": "1", "TOKEN": "secret", "OTHER": "x"}
+	container := `{"image":"app:${TAG}","environment":["TOKEN"]}`
+	digest := environmentDigest("container", container, "", env)
+	assert.NotEmpty(t, digest)
+
+	// Unreferenced variables do not matter to containers.
+	env["OTHER"] = "y"
+	assert.Equal(t, digest, environmentDigest("container", container, "", env))
+	env["TAG"] = "2"
+	assert.NotEqual(t, digest, environmentDigest("container", container, "", env))
+	env["TAG"] = "1"
+	env["TOKEN"] = "rotated"
+	assert.NotEqual(t, digest, environmentDigest("container", container, "", env))
+
+	// Processes inherit everything.
+	process := environmentDigest("process", `{"program":"api"}`, "", env)
+	env["OTHER"] = "z"
+	assert.NotEqual(t, process, environmentDigest("process", `{"program":"api"}`, "", env))
+
+	assert.Equal(t, "", environmentDigest("volume", `{}`, "", env))
+	assert.Equal(t, "", environmentDigest("unknown", `{}`, "", env))
+}
+
+func TestPlanApplyEnvironmentChanged(t *testing.T) {
+	ctx := context.Background()
+	ws := &Workspace{}
+	spec := `{"program":"api"}`
+	oldEnv := map[string]string{"PORT": "4000"}
+	oldComponents := map[string]api.ComponentDescription{
+		"api": {
+			ID:                "1",
+			Name:              "api",
+			Type:              "process",
+			Spec:              spec,
+			EnvironmentDigest: environmentDigest("process", spec, "", oldEnv),
+		},
+	}
+	newComponents := []*exohcl.Component{{Name: "api", Type: "process", Spec: spec}}
+	graph := deps.New()
+	graph.AddNode(&componentNode{component: newComponents[0]})
+
+	plan, err := ws.planApply(ctx, oldComponents, newComponents, graph, "", oldEnv)
+	if assert.NoError(t, err) {
+		assert.Empty(t, plan)
+	}
+
+	newEnv := map[string]string{"PORT": "5000"}
+	plan, err = ws.planApply(ctx, oldComponents, newComponents, graph, "", newEnv)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []api.ComponentPlan{{
+			Name:   "api",
+			Type:   "process",
+			Action: actionReplace,
+			Reason: "environment changed",
+		}}, plan)
+	}
+}
+
+func TestEnvironmentDigestEnvFiles(t *testing.T) {
+	root, err := ioutil.TempDir("", "exo-envdigest")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(root)
+	envFile := filepath.Join(root, "app", ".env")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(envFile), 0700))
+	assert.NoError(t, ioutil.WriteFile(envFile, []byte("TOKEN=1\n"), 0600))
+
+	env := map[string]string{"HOME": "/home/user"}
+	spec := `{"directory":"app","program":"api","envFiles":[".env"]}`
+	digest := environmentDigest("process", spec, root, env)
+
+	// Env file values are not in the spec, but still require re-creating the
+	// process when they change.
+	assert.NoError(t, ioutil.WriteFile(envFile, []byte("TOKEN=2\n"), 0600))
+	assert.NotEqual(t, digest, environmentDigest("process", spec, root, env))
+}
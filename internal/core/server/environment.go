@@ -14,12 +14,16 @@ import (
 // XXX This now does network requests and non-trivial parsing work. Therefore,
 // it is no longer appropriate to call deep in the call stack.
 func (ws *Workspace) getEnvironment(ctx context.Context) (map[string]api.VariableDescription, error) {
+	return ws.getManifestEnvironment(ctx, ws.tryLoadManifest(ctx))
+}
+
+// getManifestEnvironment is getEnvironment for the given manifest, rather than
+// the manifest of the most recent apply. The manifest may be nil.
+func (ws *Workspace) getManifestEnvironment(ctx context.Context, manifest *exohcl.Manifest) (map[string]api.VariableDescription, error) {
 	var sources []environment.Source
 
-	if manifest := ws.tryLoadManifest(ctx); manifest != nil {
-		manifestEnv := &exohcl.Environment{
-			Blocks: manifest.Environment,
-		}
+	if manifest != nil {
+		manifestEnv := exohcl.NewEnvironment(manifest)
 		diags := exohcl.Analyze(ctx, manifestEnv)
 		if diags.HasErrors() {
 			return nil, diags
@@ -27,39 +31,25 @@ func (ws *Workspace) getEnvironment(ctx context.Context) (map[string]api.Variabl
 		sources = append(sources, manifestEnv)
 	}
 
-	sources = append(sources,
-		environment.Default,
-		&environment.OS{},
-	)
-
-	envPath, err := ws.resolveWorkspacePath(ctx, ".env")
+	localSources, err := ws.getLocalEnvironmentSources(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("resolving env file path: %w", err)
-	}
-	if exists, _ := osutil.Exists(envPath); exists {
-		sources = append(sources, &environment.Dotenv{
-			Path: envPath,
-		})
+		return nil, err
 	}
+	sources = append(sources, localSources...)
 
 	b := &environmentBuilder{
 		Environment: make(map[string]api.VariableDescription),
 	}
 
-	// TODO: Do not use DescribeVaults, instead build up sources from the
-	// environment blocks ASTs. For example, there maybe a `variables` block or
-	// some other environment sources that are not in the DescribeVaults output.
-	describeVaultsResult, err := ws.DescribeVaults(ctx, &api.DescribeVaultsInput{})
-	if err != nil {
-		return nil, fmt.Errorf("getting vaults: %w", err)
-	}
-
+	// TODO: Build up sources from the environment blocks ASTs. For example,
+	// there maybe a `variables` block or some other environment sources that are
+	// not vaults.
 	logger := logging.CurrentLogger(ctx)
-	for _, vault := range describeVaultsResult.Vaults {
+	for _, vaultURL := range manifestVaultURLs(ctx, manifest) {
 		derefSource := &environment.ESV{
 			Client: ws.EsvClient,
-			Name:   vault.URL, // XXX
-			URL:    vault.URL,
+			Name:   vaultURL, // XXX
+			URL:    vaultURL,
 		}
 		if err := derefSource.ExtendEnvironment(b); err != nil {
 			// It's not appropriate to fail on error since this error could just
@@ -67,7 +57,7 @@ func (ws *Workspace) getEnvironment(ctx context.Context) (map[string]api.Variabl
 			// the secret provider.
 			// TODO: this should really alert the user in a more apparent way that
 			// fetching secrets from the vault has failed.
-			logger.Infof("Could not extend environment from vault %q: %v", vault.URL, err)
+			logger.Infof("Could not extend environment from vault %q: %v", vaultURL, err)
 		}
 	}
 
@@ -79,6 +69,69 @@ func (ws *Workspace) getEnvironment(ctx context.Context) (map[string]api.Variabl
 	return b.Environment, nil
 }
 
+// getLocalEnvironmentSources returns the sources of the workspace environment
+// that are not declared by the manifest.
+func (ws *Workspace) getLocalEnvironmentSources(ctx context.Context) ([]environment.Source, error) {
+	sources := []environment.Source{
+		environment.Default,
+		&environment.OS{},
+	}
+
+	envPath, err := ws.resolveWorkspacePath(ctx, ".env")
+	if err != nil {
+		return nil, fmt.Errorf("resolving env file path: %w", err)
+	}
+	if exists, _ := osutil.Exists(envPath); exists {
+		sources = append(sources, &environment.Dotenv{
+			Path: envPath,
+		})
+	}
+	return sources, nil
+}
+
+// getManifestFunctionEnvironment returns the variables read by the env
+// function of the manifest. Since secrets and environment blocks are
+// themselves declared by the manifest, only the local sources of the
+// workspace environment are included.
+func (ws *Workspace) getManifestFunctionEnvironment(ctx context.Context) (map[string]string, error) {
+	sources, err := ws.getLocalEnvironmentSources(ctx)
+	if err != nil {
+		return nil, err
+	}
+	b := &environmentBuilder{
+		Environment: make(map[string]api.VariableDescription),
+	}
+	for _, source := range sources {
+		if err := source.ExtendEnvironment(b); err != nil {
+			return nil, fmt.Errorf("extending environment from %s: %w", source.EnvironmentSource(), err)
+		}
+	}
+	env := make(map[string]string, len(b.Environment))
+	for k, v := range b.Environment {
+		env[k] = v.Value
+	}
+	return env, nil
+}
+
+// getSimpleEnvironment returns the workspace environment as a map of variable
+// names to values.
+func (ws *Workspace) getSimpleEnvironment(ctx context.Context) (map[string]string, error) {
+	return ws.getSimpleManifestEnvironment(ctx, ws.tryLoadManifest(ctx))
+}
+
+// getSimpleManifestEnvironment is getSimpleEnvironment for the given manifest.
+func (ws *Workspace) getSimpleManifestEnvironment(ctx context.Context, manifest *exohcl.Manifest) (map[string]string, error) {
+	env, err := ws.getManifestEnvironment(ctx, manifest)
+	if err != nil {
+		return nil, err
+	}
+	simpleEnv := make(map[string]string, len(env))
+	for k, v := range env {
+		simpleEnv[k] = v.Value
+	}
+	return simpleEnv, nil
+}
+
 type environmentBuilder struct {
 	Environment map[string]api.VariableDescription
 }
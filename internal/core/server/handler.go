@@ -9,26 +9,41 @@ import (
 	"github.com/deref/exo/internal/esv"
 	"github.com/deref/exo/internal/install"
 	josh "github.com/deref/exo/internal/josh/server"
+	"github.com/deref/exo/internal/providers/docker"
 	"github.com/deref/exo/internal/task"
 	taskapi "github.com/deref/exo/internal/task/api"
 	"github.com/deref/exo/internal/token"
 	"github.com/deref/exo/internal/util/errutil"
 	"github.com/deref/exo/internal/util/httputil"
 	"github.com/deref/exo/internal/util/logging"
-	docker "github.com/docker/docker/client"
 )
 
 type Config struct {
-	VarDir      string
-	Store       state.Store
-	Install     *install.Install
-	SyslogPort  uint
-	Docker      *docker.Client
-	Logger      logging.Logger
-	TaskTracker *task.TaskTracker
-	TokenClient token.TokenClient
-	EsvClient   esv.EsvClient
-	ExoVersion  string
+	VarDir           string
+	Store            state.Store
+	Install          *install.Install
+	SyslogPort       uint
+	ContainerLogMode string
+	Docker           docker.Runtime
+	Logger           logging.Logger
+	TaskTracker      *task.TaskTracker
+	TokenClient      token.TokenClient
+	EsvClient        esv.EsvClient
+	ExoVersion       string
+}
+
+func (cfg *Config) newWorkspace(id string) *Workspace {
+	return &Workspace{
+		ID:               id,
+		VarDir:           cfg.VarDir,
+		Logger:           cfg.Logger,
+		Store:            cfg.Store,
+		SyslogPort:       cfg.SyslogPort,
+		ContainerLogMode: cfg.ContainerLogMode,
+		Docker:           cfg.Docker,
+		TaskTracker:      cfg.TaskTracker,
+		EsvClient:        cfg.EsvClient,
+	}
 }
 
 func BuildRootMux(prefix string, cfg *Config) *http.ServeMux {
@@ -80,16 +95,7 @@ func BuildRootMux(prefix string, cfg *Config) *http.ServeMux {
 
 	endWorkspace := b.Begin("workspace")
 	api.BuildWorkspaceMux(b, func(req *http.Request) api.Workspace {
-		return &Workspace{
-			ID:          req.URL.Query().Get("id"),
-			VarDir:      cfg.VarDir,
-			Logger:      cfg.Logger,
-			Store:       cfg.Store,
-			SyslogPort:  cfg.SyslogPort,
-			Docker:      cfg.Docker,
-			TaskTracker: cfg.TaskTracker,
-			EsvClient:   cfg.EsvClient,
-		}
+		return cfg.newWorkspace(req.URL.Query().Get("id"))
 	})
 	endWorkspace()
 
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+
+	"github.com/deref/exo/internal/core/api"
+	"github.com/deref/exo/internal/providers/docker"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// removeUnusedImages removes images built for this workspace that no longer
+// belong to a container component. Superseded builds of existing components
+// are removed when they are rebuilt, but deleted components leave their
+// images behind until this runs.
+func (ws *Workspace) removeUnusedImages(ctx context.Context) {
+	describeOutput, err := ws.DescribeComponents(ctx, &api.DescribeComponentsInput{
+		Types: []string{"container"},
+	})
+	if err != nil {
+		ws.Logger.Infof("describing containers: %v", err)
+		return
+	}
+	repositories := make(map[string]bool, len(describeOutput.Components))
+	for _, component := range describeOutput.Components {
+		repositories[docker.ImageRepository(ws.ID, component.Name)] = true
+	}
+
+	images, err := ws.Docker.ImageList(ctx, types.ImageListOptions{
+		Filters: filters.NewArgs(filters.KeyValuePair{
+			Key:   "label",
+			Value: "io.deref.exo.workspace=" + ws.ID,
+		}),
+	})
+	if err != nil {
+		ws.Logger.Infof("listing workspace images: %v", err)
+		return
+	}
+	for _, img := range images {
+		// Untagged images are superseded builds that could not be removed
+		// while a container was still using them.
+		refs := []string{img.ID}
+		if len(img.RepoTags) > 0 {
+			refs = nil
+			for _, ref := range img.RepoTags {
+				repository, _ := docker.SplitImageTag(ref)
+				if _, ok := docker.ParseImageRepository(ws.ID, repository); ok && !repositories[repository] {
+					refs = append(refs, ref)
+				}
+			}
+		}
+		for _, ref := range refs {
+			if _, err := ws.Docker.ImageRemove(ctx, ref, types.ImageRemoveOptions{
+				PruneChildren: true,
+			}); err != nil {
+				ws.Logger.Infof("removing image %q: %v", ref, err)
+			}
+		}
+	}
+}
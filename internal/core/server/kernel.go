@@ -138,9 +138,11 @@ func (kern *Kernel) DescribeWorkspaces(ctx context.Context, input *api.DescribeW
 	workspaces := make([]api.WorkspaceDescription, len(output.Workspaces))
 	for i, workspace := range output.Workspaces {
 		workspaces[i] = api.WorkspaceDescription{
-			ID:          workspace.ID,
-			Root:        workspace.Root,
-			DisplayName: workspace.DisplayName,
+			ID:            workspace.ID,
+			Root:          workspace.Root,
+			DisplayName:   workspace.DisplayName,
+			Profile:       workspace.Profile,
+			WatchManifest: workspace.WatchManifest,
 		}
 	}
 	return &api.DescribeWorkspacesOutput{
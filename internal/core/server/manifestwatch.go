@@ -0,0 +1,303 @@
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/deref/exo/internal/core/api"
+	state "github.com/deref/exo/internal/core/state/api"
+	"github.com/deref/exo/internal/manifest"
+	"github.com/deref/exo/internal/manifest/exohcl"
+	"github.com/deref/exo/internal/manifest/procfile"
+	"github.com/fsnotify/fsnotify"
+)
+
+// ManifestWatcher re-applies the manifest of each workspace that has opted in
+// to watching, whenever a file read by its most recent apply changes: the
+// manifest, its profile overlay, the files it read such as module sources, the
+// .env file of the workspace, and for Procfiles, the .foreman file and env
+// files. The manifest is re-applied with the same options as the most recent
+// apply. Results are reported to the event stream of the workspace. Re-applies
+// of each workspace run one at a time, independently of other workspaces.
+type ManifestWatcher struct {
+	Config *Config
+	// Debounce is how long to wait for a burst of changes, such as an editor
+	// saving via a temporary file, to settle before re-applying.
+	Debounce time.Duration
+	// PollInterval is how often workspace settings are checked for workspaces
+	// that started or stopped watching, or whose applied manifest changed.
+	PollInterval time.Duration
+
+	watcher *fsnotify.Watcher
+	watched map[string]*watchedWorkspace // Keyed by workspace ID.
+	dirs    map[string]int               // Watched directory -> number of workspaces.
+	// reapply is called with the settings of each workspace whose files
+	// changed. Defaults to re-applying the manifest of the workspace.
+	reapply func(ctx context.Context, desc state.WorkspaceDescription, changed string)
+}
+
+type watchedWorkspace struct {
+	Settings state.WorkspaceDescription
+	// Files are the cleaned absolute paths of files whose changes trigger a
+	// re-apply.
+	Files map[string]bool
+	// reapplies queues the re-applies of the workspace. Its buffer holds one
+	// request, so that changes made during a re-apply queue only one more.
+	reapplies chan reapplyRequest
+}
+
+type reapplyRequest struct {
+	Settings state.WorkspaceDescription
+	Changed  string
+}
+
+func (mw *ManifestWatcher) Run(ctx context.Context) error {
+	var err error
+	mw.watcher, err = fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer mw.watcher.Close()
+	mw.watched = make(map[string]*watchedWorkspace)
+	mw.dirs = make(map[string]int)
+	if mw.reapply == nil {
+		mw.reapply = mw.reapplyManifest
+	}
+	defer func() {
+		for _, w := range mw.watched {
+			close(w.reapplies)
+		}
+	}()
+
+	mw.sync(ctx)
+	ticker := time.NewTicker(mw.PollInterval)
+	defer ticker.Stop()
+
+	// Changed filenames, keyed by workspace ID.
+	pending := make(map[string]string)
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-ticker.C:
+			mw.sync(ctx)
+
+		case event, ok := <-mw.watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			changed := filepath.Clean(event.Name)
+			for id, w := range mw.watched {
+				if w.Files[changed] {
+					pending[id] = changed
+					debounce = time.After(mw.Debounce)
+				}
+			}
+
+		case err, ok := <-mw.watcher.Errors:
+			if !ok {
+				return nil
+			}
+			mw.Config.Logger.Infof("manifest watcher error: %v", err)
+
+		case <-debounce:
+			debounce = nil
+			for id, changed := range pending {
+				w := mw.watched[id]
+				if w == nil {
+					continue
+				}
+				select {
+				case w.reapplies <- reapplyRequest{Settings: w.Settings, Changed: changed}:
+				default:
+					// A re-apply is already queued, and will see this change too.
+				}
+			}
+			pending = make(map[string]string)
+		}
+	}
+}
+
+// sync starts and stops watching workspaces according to their settings.
+func (mw *ManifestWatcher) sync(ctx context.Context) {
+	output, err := mw.Config.Store.DescribeWorkspaces(ctx, &state.DescribeWorkspacesInput{})
+	if err != nil {
+		mw.Config.Logger.Infof("describing workspaces to watch: %v", err)
+		return
+	}
+	desired := make(map[string]bool)
+	for _, desc := range output.Workspaces {
+		if !desc.WatchManifest {
+			continue
+		}
+		if desc.ManifestPath == "" {
+			// The most recent apply was given only the contents of its manifest,
+			// so there is nothing to watch or re-read.
+			continue
+		}
+		desired[desc.ID] = true
+		files := watchedFiles(desc)
+		w := mw.watched[desc.ID]
+		if w == nil {
+			w = &watchedWorkspace{
+				reapplies: make(chan reapplyRequest, 1),
+			}
+			mw.watched[desc.ID] = w
+			go mw.reapplyLoop(ctx, w.reapplies)
+		} else if reflect.DeepEqual(w.Files, files) {
+			w.Settings = desc
+			continue
+		} else {
+			mw.unwatch(w)
+		}
+		w.Settings = desc
+		w.Files = files
+		mw.watch(w)
+	}
+	for id, w := range mw.watched {
+		if desired[id] {
+			continue
+		}
+		mw.unwatch(w)
+		close(w.reapplies)
+		delete(mw.watched, id)
+	}
+}
+
+// reapplyLoop re-applies a workspace for each queued request, one at a time,
+// until the queue is closed.
+func (mw *ManifestWatcher) reapplyLoop(ctx context.Context, reapplies <-chan reapplyRequest) {
+	for req := range reapplies {
+		mw.reapply(ctx, req.Settings, req.Changed)
+	}
+}
+
+// watch adds the directories of the files of w to the watcher. Directories are
+// watched, rather than the files themselves, so that files replaced by renames
+// and files that do not exist yet are seen.
+func (mw *ManifestWatcher) watch(w *watchedWorkspace) {
+	for dir := range watchedDirs(w.Files) {
+		if mw.dirs[dir] == 0 {
+			if err := mw.watcher.Add(dir); err != nil {
+				mw.Config.Logger.Infof("watching %q: %v", dir, err)
+				continue
+			}
+		}
+		mw.dirs[dir]++
+	}
+}
+
+func (mw *ManifestWatcher) unwatch(w *watchedWorkspace) {
+	for dir := range watchedDirs(w.Files) {
+		if mw.dirs[dir] == 0 {
+			continue
+		}
+		mw.dirs[dir]--
+		if mw.dirs[dir] > 0 {
+			continue
+		}
+		delete(mw.dirs, dir)
+		if err := mw.watcher.Remove(dir); err != nil {
+			mw.Config.Logger.Infof("unwatching %q: %v", dir, err)
+		}
+	}
+}
+
+func watchedDirs(files map[string]bool) map[string]bool {
+	dirs := make(map[string]bool)
+	for file := range files {
+		dirs[filepath.Dir(file)] = true
+	}
+	return dirs
+}
+
+// watchedFiles returns the files read when applying the manifest of a
+// workspace with its recorded settings.
+func watchedFiles(desc state.WorkspaceDescription) map[string]bool {
+	manifestPath := desc.ManifestPath
+	if !filepath.IsAbs(manifestPath) {
+		manifestPath = filepath.Join(desc.Root, manifestPath)
+	}
+	manifestPath = filepath.Clean(manifestPath)
+	files := map[string]bool{
+		manifestPath:                     true,
+		filepath.Join(desc.Root, ".env"): true,
+	}
+	if desc.Profile != "" {
+		files[exohcl.ProfileFilename(manifestPath, desc.Profile)] = true
+	}
+
+	format := desc.ManifestFormat
+	if format == "" {
+		format = manifest.GuessFormat(manifestPath)
+	}
+	if format == "procfile" {
+		dir := filepath.Dir(manifestPath)
+		foremanPath := filepath.Join(dir, procfile.ForemanFile)
+		files[foremanPath] = true
+		opts := procfile.Options{
+			Formation: desc.Formation,
+			EnvFiles:  desc.EnvFiles,
+		}
+		if bs, err := ioutil.ReadFile(foremanPath); err == nil {
+			if foreman, err := procfile.ParseForemanFile(bs); err == nil {
+				opts = foreman.Merge(opts)
+			}
+		}
+		for _, envFile := range opts.EnvFiles {
+			if !filepath.IsAbs(envFile) {
+				envFile = filepath.Join(dir, envFile)
+			}
+			files[filepath.Clean(envFile)] = true
+		}
+	}
+	for _, file := range desc.ManifestFiles {
+		files[filepath.Clean(file)] = true
+	}
+	return files
+}
+
+func (mw *ManifestWatcher) reapplyManifest(ctx context.Context, desc state.WorkspaceDescription, changed string) {
+	ws := mw.Config.newWorkspace(desc.ID)
+	if rel, err := filepath.Rel(filepath.Clean(desc.Root), changed); err == nil && !filepath.IsAbs(rel) {
+		changed = rel
+	}
+	ws.logEventf(ctx, "%s changed, re-applying manifest...", changed)
+	manifestPath := desc.ManifestPath
+	output, done, err := ws.apply(ctx, &api.ApplyInput{
+		ManifestPath: &manifestPath,
+		Format:       desc.ManifestFormat,
+		Profile:      desc.Profile,
+		Formation:    desc.Formation,
+		EnvFiles:     desc.EnvFiles,
+	})
+	if output != nil {
+		for _, warning := range output.Warnings {
+			ws.logEventf(ctx, "warning: %s", warning)
+		}
+	}
+	if err != nil {
+		ws.logEventf(ctx, "error re-applying manifest: %v", err)
+		return
+	}
+	// Waiting for the job keeps re-applies of the workspace from overlapping.
+	select {
+	case <-ctx.Done():
+		return
+	case failures := <-done:
+		for _, failure := range failures {
+			ws.logEventf(ctx, "error re-applying manifest: %v", failure)
+		}
+		if len(failures) == 0 {
+			ws.logEventf(ctx, "re-applied manifest")
+		}
+	}
+}
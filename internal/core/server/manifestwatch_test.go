@@ -0,0 +1,197 @@
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	state "github.com/deref/exo/internal/core/state/api"
+	"github.com/deref/exo/internal/util/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchedFiles(t *testing.T) {
+	files := watchedFiles(state.WorkspaceDescription{
+		Root:         "/ws",
+		ManifestPath: "/ws/exo.hcl",
+		Profile:      "dev",
+	})
+	assert.Equal(t, map[string]bool{
+		"/ws/exo.hcl":     true,
+		"/ws/exo.dev.hcl": true,
+		"/ws/.env":        true,
+	}, files)
+
+	files = watchedFiles(state.WorkspaceDescription{
+		Root:          "/ws",
+		ManifestPath:  "/ws/exo.hcl",
+		ManifestFiles: []string{"/ws/api/exo.hcl", "/ws/api/motd.txt"},
+	})
+	assert.Equal(t, map[string]bool{
+		"/ws/exo.hcl":      true,
+		"/ws/api/exo.hcl":  true,
+		"/ws/api/motd.txt": true,
+		"/ws/.env":         true,
+	}, files)
+
+	files = watchedFiles(state.WorkspaceDescription{
+		Root:         "/ws",
+		ManifestPath: "app/Procfile",
+		EnvFiles:     []string{"local.env", "/etc/app.env"},
+	})
+	assert.Equal(t, map[string]bool{
+		"/ws/app/Procfile":  true,
+		"/ws/app/.foreman":  true,
+		"/ws/app/local.env": true,
+		"/etc/app.env":      true,
+		"/ws/.env":          true,
+	}, files)
+}
+
+func TestWatchedFilesForemanEnvFiles(t *testing.T) {
+	root, err := ioutil.TempDir("", "exo-manifestwatch")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(root)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, ".foreman"), []byte("env: shared.env\n"), 0600))
+
+	files := watchedFiles(state.WorkspaceDescription{
+		Root:           root,
+		ManifestPath:   filepath.Join(root, "Procfile"),
+		ManifestFormat: "procfile",
+	})
+	assert.True(t, files[filepath.Join(root, "shared.env")])
+	assert.False(t, files[filepath.Join(root, "compose.yaml")])
+}
+
+type stubWorkspaceStore struct {
+	state.Store
+	workspaces []state.WorkspaceDescription
+}
+
+func (sto *stubWorkspaceStore) DescribeWorkspaces(ctx context.Context, input *state.DescribeWorkspacesInput) (*state.DescribeWorkspacesOutput, error) {
+	return &state.DescribeWorkspacesOutput{Workspaces: sto.workspaces}, nil
+}
+
+func TestManifestWatcherDebounce(t *testing.T) {
+	root, err := ioutil.TempDir("", "exo-manifestwatch")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(root)
+	root, err = filepath.EvalSymlinks(root)
+	if !assert.NoError(t, err) {
+		return
+	}
+	manifestPath := filepath.Join(root, "exo.hcl")
+	assert.NoError(t, ioutil.WriteFile(manifestPath, []byte("exo = \"0.1\"\n"), 0600))
+
+	reapplied := make(chan string, 10)
+	mw := &ManifestWatcher{
+		Config: &Config{
+			Logger: logging.Default(),
+			Store: &stubWorkspaceStore{
+				workspaces: []state.WorkspaceDescription{{
+					ID:            "ws1",
+					Root:          root,
+					WatchManifest: true,
+					ManifestPath:  manifestPath,
+				}},
+			},
+		},
+		Debounce:     100 * time.Millisecond,
+		PollInterval: time.Hour,
+		reapply: func(ctx context.Context, desc state.WorkspaceDescription, changed string) {
+			reapplied <- changed
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go mw.Run(ctx)
+	// Give the watcher time to watch the workspace.
+	time.Sleep(100 * time.Millisecond)
+
+	// Files that the manifest does not read are ignored.
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "compose.yaml"), []byte("services: {}\n"), 0600))
+	// A burst of changes is re-applied once.
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, ioutil.WriteFile(manifestPath, []byte("exo = \"0.1\"\n"), 0600))
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case changed := <-reapplied:
+		assert.Equal(t, manifestPath, changed)
+	case <-time.After(2 * time.Second):
+		t.Fatal("manifest was not re-applied")
+	}
+	select {
+	case changed := <-reapplied:
+		t.Fatalf("unexpected re-apply for %s", changed)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+func TestManifestWatcherConcurrentWorkspaces(t *testing.T) {
+	var manifestPaths []string
+	var workspaces []state.WorkspaceDescription
+	for _, id := range []string{"slow", "fast"} {
+		root, err := ioutil.TempDir("", "exo-manifestwatch")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer os.RemoveAll(root)
+		root, err = filepath.EvalSymlinks(root)
+		if !assert.NoError(t, err) {
+			return
+		}
+		manifestPath := filepath.Join(root, "exo.hcl")
+		assert.NoError(t, ioutil.WriteFile(manifestPath, []byte("exo = \"0.1\"\n"), 0600))
+		manifestPaths = append(manifestPaths, manifestPath)
+		workspaces = append(workspaces, state.WorkspaceDescription{
+			ID:            id,
+			Root:          root,
+			WatchManifest: true,
+			ManifestPath:  manifestPath,
+		})
+	}
+
+	unblock := make(chan struct{})
+	defer close(unblock)
+	reapplied := make(chan string, 10)
+	mw := &ManifestWatcher{
+		Config: &Config{
+			Logger: logging.Default(),
+			Store:  &stubWorkspaceStore{workspaces: workspaces},
+		},
+		Debounce:     10 * time.Millisecond,
+		PollInterval: time.Hour,
+		reapply: func(ctx context.Context, desc state.WorkspaceDescription, changed string) {
+			if desc.ID == "slow" {
+				// Like a long build.
+				<-unblock
+			}
+			reapplied <- desc.ID
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go mw.Run(ctx)
+	time.Sleep(100 * time.Millisecond)
+
+	assert.NoError(t, ioutil.WriteFile(manifestPaths[0], []byte("exo = \"0.1\"\n"), 0600))
+	time.Sleep(100 * time.Millisecond)
+	assert.NoError(t, ioutil.WriteFile(manifestPaths[1], []byte("exo = \"0.1\"\n"), 0600))
+
+	// The fast workspace is re-applied while the slow one is still applying.
+	select {
+	case id := <-reapplied:
+		assert.Equal(t, "fast", id)
+	case <-time.After(2 * time.Second):
+		t.Fatal("manifest was not re-applied")
+	}
+}
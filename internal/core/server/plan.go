@@ -0,0 +1,237 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/deref/exo/internal/core/api"
+	"github.com/deref/exo/internal/deps"
+	"github.com/deref/exo/internal/manifest/exohcl"
+	"github.com/deref/exo/internal/util/diffutil"
+)
+
+// Actions of a component plan.
+const (
+	actionCreate  = "create"
+	actionUpdate  = "update"
+	actionReplace = "replace"
+	actionDelete  = "delete"
+)
+
+// planApply decides what applying a manifest does to each component of the
+// workspace, without changing anything. The graph contains the manifest
+// components and their dependencies. Deletions are planned first, ordered by
+// name, followed by the manifest components in order. Components that are
+// left alone are omitted.
+//
+// Components whose changes can be applied in place are updated. Components
+// that must be replaced are replaced along with all existing components that
+// depend on them, since a dependent may hold on to the replaced resources.
+// Components whose spec is unchanged are also replaced if the workspace
+// environment variables they depend on have changed. See environmentDigest.
+func (ws *Workspace) planApply(ctx context.Context, oldComponents map[string]api.ComponentDescription, newComponents []*exohcl.Component, graph *deps.Graph, root string, env map[string]string) ([]api.ComponentPlan, error) {
+	planner := &applyPlanner{
+		OldComponents: oldComponents,
+		NewComponents: newComponents,
+		Graph:         graph,
+		Root:          root,
+		Env:           env,
+		PlanUpdate:    ws.planUpdate,
+	}
+	return planner.plan(ctx)
+}
+
+// applyPlanner holds the inputs of planApply.
+type applyPlanner struct {
+	OldComponents map[string]api.ComponentDescription
+	NewComponents []*exohcl.Component
+	Graph         *deps.Graph
+	Root          string
+	Env           map[string]string
+	// PlanUpdate reports whether changing the spec of an existing component
+	// requires replacing it. See Workspace.planUpdate.
+	PlanUpdate func(ctx context.Context, oldComponent api.ComponentDescription, newSpec string) (replace bool)
+}
+
+func (planner *applyPlanner) plan(ctx context.Context) ([]api.ComponentPlan, error) {
+	oldComponents := planner.OldComponents
+	newComponents := planner.NewComponents
+	graph := planner.Graph
+	root := planner.Root
+	env := planner.Env
+	var plan []api.ComponentPlan
+
+	var deleted []string
+	for name := range oldComponents {
+		if !graph.HasNode(name) {
+			deleted = append(deleted, name)
+		}
+	}
+	sort.Strings(deleted)
+	// Invert the dependencies for deletions so that we can check whether there is anything
+	// left in the graph that still depends on something that we want to delete.
+	deleteGraph := deps.New()
+	for _, name := range deleted {
+		oldComponent := oldComponents[name]
+		deleteGraph.AddNode(nameNode(name))
+		for _, dependency := range oldComponent.DependsOn {
+			deleteGraph.AddEdge(dependency, name)
+		}
+		plan = append(plan, api.ComponentPlan{
+			Name:     name,
+			Type:     oldComponent.Type,
+			Action:   actionDelete,
+			Reason:   "not in manifest",
+			SpecDiff: diffSpecs(name, oldComponent.Spec, ""),
+		})
+	}
+	unmetDeps := deleteGraph.UnmetDependencies()
+	if len(unmetDeps) > 0 {
+		sort.Strings(unmetDeps)
+		return nil, fmt.Errorf("would remove components that are still depended on: %s", strings.Join(unmetDeps, ", "))
+	}
+
+	planned := make(map[string]*api.ComponentPlan, len(newComponents))
+	var replaced []string
+	for _, newComponent := range newComponents {
+		name := newComponent.Name
+		oldComponent, exists := oldComponents[name]
+		p := &api.ComponentPlan{
+			Name: name,
+			Type: newComponent.Type,
+		}
+		// Compare against the old spec, so that merely referencing another
+		// variable is a spec change rather than an environment change.
+		envChanged := exists && oldComponent.EnvironmentDigest != environmentDigest(oldComponent.Type, oldComponent.Spec, root, env)
+		switch {
+		case !exists:
+			p.Action = actionCreate
+			p.Reason = "not yet created"
+			p.SpecDiff = diffSpecs(name, "", newComponent.Spec)
+		case oldComponent.Type != newComponent.Type:
+			p.Action = actionReplace
+			p.Reason = fmt.Sprintf("type changed from %s", oldComponent.Type)
+			p.SpecDiff = diffSpecs(name, oldComponent.Spec, newComponent.Spec)
+		case oldComponent.Spec == newComponent.Spec && envChanged:
+			p.Action = actionReplace
+			p.Reason = "environment changed"
+		case oldComponent.Spec == newComponent.Spec:
+			switch {
+			case !stringSetsEqual(oldComponent.DependsOn, newComponent.DependsOn):
+				p.Reason = "dependencies changed"
+			case !stringMapsEqual(oldComponent.Labels, newComponent.Labels):
+				p.Reason = "labels changed"
+			case oldComponent.StartPolicy != newComponent.StartPolicy:
+				p.Reason = "start policy changed"
+			default:
+				continue
+			}
+			p.Action = actionUpdate
+		case envChanged:
+			p.Action = actionReplace
+			p.Reason = "spec and environment changed"
+			p.SpecDiff = diffSpecs(name, oldComponent.Spec, newComponent.Spec)
+		case planner.PlanUpdate(ctx, oldComponent, newComponent.Spec):
+			p.Action = actionReplace
+			p.Reason = "spec change cannot be applied in place"
+			p.SpecDiff = diffSpecs(name, oldComponent.Spec, newComponent.Spec)
+		default:
+			p.Action = actionUpdate
+			p.Reason = "spec changed"
+			p.SpecDiff = diffSpecs(name, oldComponent.Spec, newComponent.Spec)
+		}
+		if p.Action == actionReplace {
+			replaced = append(replaced, name)
+		}
+		planned[name] = p
+	}
+
+	manifestComponents := make(map[string]*exohcl.Component, len(newComponents))
+	for _, c := range newComponents {
+		manifestComponents[c.Name] = c
+	}
+	for _, name := range replaced {
+		for dependent := range graph.Dependents(name) {
+			oldComponent, exists := oldComponents[dependent]
+			if !exists {
+				continue
+			}
+			p := planned[dependent]
+			if p != nil && p.Action == actionReplace {
+				continue
+			}
+			newComponent := manifestComponents[dependent]
+			if p == nil {
+				p = &api.ComponentPlan{
+					Name: dependent,
+					Type: newComponent.Type,
+				}
+				planned[dependent] = p
+			}
+			p.Action = actionReplace
+			p.TriggeredBy = dependencyPath(manifestComponents, dependent, name)
+			p.Reason = fmt.Sprintf("depends on %s, which is replaced", strings.Join(p.TriggeredBy, ", which depends on "))
+			p.SpecDiff = diffSpecs(dependent, oldComponent.Spec, newComponent.Spec)
+		}
+	}
+
+	for _, newComponent := range newComponents {
+		if p := planned[newComponent.Name]; p != nil {
+			plan = append(plan, *p)
+		}
+	}
+	return plan, nil
+}
+
+// dependencyPath returns the shortest chain of dependencies from one
+// component to another, excluding from and including to.
+func dependencyPath(components map[string]*exohcl.Component, from, to string) []string {
+	previous := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if name == to {
+			var path []string
+			for ; name != from; name = previous[name] {
+				path = append([]string{name}, path...)
+			}
+			return path
+		}
+		c := components[name]
+		if c == nil {
+			continue
+		}
+		for _, dependency := range c.DependsOn {
+			if _, seen := previous[dependency]; !seen {
+				previous[dependency] = name
+				queue = append(queue, dependency)
+			}
+		}
+	}
+	return []string{to}
+}
+
+// diffSpecs renders the difference between two specs. JSON specs are indented
+// first so that the diff is by property rather than a single line.
+func diffSpecs(name string, oldSpec, newSpec string) string {
+	return diffutil.Unified(name+" (current)", name+" (manifest)", formatSpec(oldSpec), formatSpec(newSpec))
+}
+
+func formatSpec(spec string) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(spec), "", "  "); err != nil {
+		return spec
+	}
+	return buf.String()
+}
+
+type nameNode string
+
+func (n nameNode) ID() string {
+	return string(n)
+}
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/deref/exo/internal/core/api"
+	"github.com/deref/exo/internal/deps"
+	"github.com/deref/exo/internal/manifest/exohcl"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanApplyMetadataChanged(t *testing.T) {
+	ctx := context.Background()
+	ws := &Workspace{}
+	spec := `{"image":"postgres"}`
+	oldComponents := map[string]api.ComponentDescription{
+		"db": {
+			ID:     "1",
+			Name:   "db",
+			Type:   "container",
+			Spec:   spec,
+			Labels: map[string]string{"team": "data"},
+		},
+	}
+	plan := func(c *exohcl.Component) []api.ComponentPlan {
+		graph := deps.New()
+		graph.AddNode(&componentNode{component: c})
+		plan, err := ws.planApply(ctx, oldComponents, []*exohcl.Component{c}, graph, "", nil)
+		assert.NoError(t, err)
+		return plan
+	}
+
+	assert.Empty(t, plan(&exohcl.Component{
+		Name:   "db",
+		Type:   "container",
+		Spec:   spec,
+		Labels: map[string]string{"team": "data"},
+	}))
+	assert.Equal(t, []api.ComponentPlan{{
+		Name:   "db",
+		Type:   "container",
+		Action: actionUpdate,
+		Reason: "labels changed",
+	}}, plan(&exohcl.Component{
+		Name:   "db",
+		Type:   "container",
+		Spec:   spec,
+		Labels: map[string]string{"team": "platform"},
+	}))
+	assert.Equal(t, []api.ComponentPlan{{
+		Name:   "db",
+		Type:   "container",
+		Action: actionUpdate,
+		Reason: "start policy changed",
+	}}, plan(&exohcl.Component{
+		Name:        "db",
+		Type:        "container",
+		Spec:        spec,
+		Labels:      map[string]string{"team": "data"},
+		StartPolicy: exohcl.StartPolicyManual,
+	}))
+}
+
+func TestPlanApply(t *testing.T) {
+	type summary struct {
+		Name        string
+		Action      string
+		Reason      string
+		TriggeredBy []string
+	}
+	tests := []struct {
+		name string
+		old  []api.ComponentDescription
+		new  []*exohcl.Component
+		// replace are the components whose spec changes cannot be applied in
+		// place.
+		replace  []string
+		expected []summary
+		err      string
+	}{
+		{
+			name: "unchanged",
+			old:  []api.ComponentDescription{{Name: "db", Spec: "v1"}},
+			new:  []*exohcl.Component{{Name: "db", Spec: "v1"}},
+		},
+		{
+			name: "create and delete",
+			old:  []api.ComponentDescription{{Name: "old", Spec: "v1"}},
+			new:  []*exohcl.Component{{Name: "new", Spec: "v1"}},
+			expected: []summary{
+				{Name: "old", Action: actionDelete, Reason: "not in manifest"},
+				{Name: "new", Action: actionCreate, Reason: "not yet created"},
+			},
+		},
+		{
+			name: "update in place",
+			old: []api.ComponentDescription{
+				{Name: "db", Spec: "v1"},
+				{Name: "api", Spec: "v1", DependsOn: []string{"db"}},
+			},
+			new: []*exohcl.Component{
+				{Name: "db", Spec: "v2"},
+				{Name: "api", Spec: "v1", DependsOn: []string{"db"}},
+			},
+			expected: []summary{
+				{Name: "db", Action: actionUpdate, Reason: "spec changed"},
+			},
+		},
+		{
+			name: "replacement propagates to dependents",
+			old: []api.ComponentDescription{
+				{Name: "db", Spec: "v1"},
+				{Name: "api", Spec: "v1", DependsOn: []string{"db"}},
+				{Name: "web", Spec: "v1", DependsOn: []string{"api"}},
+				{Name: "cache", Spec: "v1"},
+			},
+			new: []*exohcl.Component{
+				{Name: "db", Spec: "v2"},
+				{Name: "api", Spec: "v1", DependsOn: []string{"db"}},
+				{Name: "web", Spec: "v2", DependsOn: []string{"api"}},
+				{Name: "cache", Spec: "v1"},
+				{Name: "worker", Spec: "v1", DependsOn: []string{"db"}},
+			},
+			replace: []string{"db"},
+			expected: []summary{
+				{Name: "db", Action: actionReplace, Reason: "spec change cannot be applied in place"},
+				{Name: "api", Action: actionReplace, Reason: "depends on db, which is replaced", TriggeredBy: []string{"db"}},
+				{Name: "web", Action: actionReplace, Reason: "depends on api, which depends on db, which is replaced", TriggeredBy: []string{"api", "db"}},
+				{Name: "worker", Action: actionCreate, Reason: "not yet created"},
+			},
+		},
+		{
+			// Deletions are checked with inverted dependencies, so a deleted
+			// component whose dependency remains is reported.
+			name: "delete ordering",
+			old: []api.ComponentDescription{
+				{Name: "db", Spec: "v1"},
+				{Name: "api", Spec: "v1", DependsOn: []string{"db"}},
+			},
+			new: []*exohcl.Component{
+				{Name: "db", Spec: "v1"},
+			},
+			err: "would remove components that are still depended on: db",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			oldComponents := make(map[string]api.ComponentDescription, len(test.old))
+			for _, c := range test.old {
+				c.Type = "test"
+				oldComponents[c.Name] = c
+			}
+			graph := deps.New()
+			for _, c := range test.new {
+				c.Type = "test"
+				graph.AddNode(&componentNode{component: c})
+				for _, dependency := range c.DependsOn {
+					graph.AddEdge(c.Name, dependency)
+				}
+			}
+			replace := make(map[string]bool, len(test.replace))
+			for _, name := range test.replace {
+				replace[name] = true
+			}
+			planner := &applyPlanner{
+				OldComponents: oldComponents,
+				NewComponents: test.new,
+				Graph:         graph,
+				PlanUpdate: func(ctx context.Context, oldComponent api.ComponentDescription, newSpec string) bool {
+					return replace[oldComponent.Name]
+				},
+			}
+			plan, err := planner.plan(context.Background())
+			if test.err != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), test.err)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			var actual []summary
+			for _, p := range plan {
+				actual = append(actual, summary{p.Name, p.Action, p.Reason, p.TriggeredBy})
+			}
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
@@ -11,7 +11,12 @@ import (
 )
 
 func (ws *Workspace) getVaultURLs(ctx context.Context) []string {
-	manifest := ws.tryLoadManifest(ctx)
+	return manifestVaultURLs(ctx, ws.tryLoadManifest(ctx))
+}
+
+// manifestVaultURLs returns the URLs of the secrets vaults declared by a
+// manifest, which may be nil.
+func manifestVaultURLs(ctx context.Context, manifest *exohcl.Manifest) []string {
 	if manifest == nil {
 		return nil
 	}
@@ -19,8 +24,9 @@ func (ws *Workspace) getVaultURLs(ctx context.Context) []string {
 	env := exohcl.NewEnvironment(manifest)
 	_ = exohcl.Analyze(ctx, env)
 
-	res := make([]string, 0, len(env.Secrets))
-	for _, secrets := range env.Secrets {
+	allSecrets := env.AllSecrets()
+	res := make([]string, 0, len(allSecrets))
+	for _, secrets := range allSecrets {
 		if secrets.Source == "" {
 			continue
 		}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/deref/exo/internal/core/api"
+	state "github.com/deref/exo/internal/core/state/api"
+)
+
+// planUpdate reports whether an existing component must be replaced in order
+// to apply newSpec. Components that fail to plan are replaced, as they would
+// have been before in-place updates were supported.
+func (ws *Workspace) planUpdate(ctx context.Context, oldComponent api.ComponentDescription, newSpec string) (replace bool) {
+	var output *api.PlanUpdateOutput
+	if err := ws.query(ctx, oldComponent, &output, &api.PlanUpdateInput{
+		OldSpec: oldComponent.Spec,
+		NewSpec: newSpec,
+	}); err != nil {
+		ws.Logger.Infof("planning update of %q: %v", oldComponent.Name, err)
+		return true
+	}
+	return output.Replace
+}
+
+// updateComponent applies the spec, dependencies, labels and start policy of
+// newComponent to an existing component in place. The caller is responsible
+// for checking planUpdate first. env is the workspace environment that the
+// environment digest of the component is computed from.
+func (ws *Workspace) updateComponent(ctx context.Context, oldComponent api.ComponentDescription, newComponent *api.CreateComponentInput, env map[string]string) error {
+	newSpec := newComponent.Spec
+	if newSpec != oldComponent.Spec {
+		if err := ws.control(ctx, oldComponent, &api.UpdateInput{
+			OldSpec: oldComponent.Spec,
+			NewSpec: newSpec,
+		}); err != nil {
+			return err
+		}
+	}
+	description, err := ws.describe(ctx)
+	if err != nil {
+		return fmt.Errorf("describing workspace: %w", err)
+	}
+	digest := environmentDigest(oldComponent.Type, newSpec, description.Root, env)
+	if _, err := ws.Store.PatchComponent(ctx, &state.PatchComponentInput{
+		ID:                oldComponent.ID,
+		Spec:              newSpec,
+		DependsOn:         &newComponent.DependsOn,
+		EnvironmentDigest: &digest,
+		Labels:            &newComponent.Labels,
+		StartPolicy:       &newComponent.StartPolicy,
+	}); err != nil {
+		return fmt.Errorf("patching component: %w", err)
+	}
+	return nil
+}
+
+func stringMapsEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if other, ok := b[k]; !ok || other != v {
+			return false
+		}
+	}
+	return true
+}
+
+func stringSetsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]string{}, a...)
+	b = append([]string{}, b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
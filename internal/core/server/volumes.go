@@ -0,0 +1,182 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/deref/exo/internal/chrono"
+	"github.com/deref/exo/internal/core/api"
+	"github.com/deref/exo/internal/manifest/exohcl"
+	"github.com/deref/exo/internal/providers/docker/components/volume"
+	"github.com/deref/exo/internal/util/errutil"
+)
+
+type volumeRef struct {
+	ComponentName string
+	VolumeName    string
+}
+
+func (ws *Workspace) resolveVolume(ctx context.Context, ref string) (*volumeRef, error) {
+	query := makeComponentQuery(withRefs(ref), withTypes("volume"))
+	describeOutput, err := ws.DescribeComponents(ctx, query.describeComponentsInput(ws))
+	if err != nil {
+		return nil, fmt.Errorf("describing components: %w", err)
+	}
+	if len(describeOutput.Components) == 0 {
+		return nil, errutil.HTTPErrorf(http.StatusNotFound, "no such volume: %q", ref)
+	}
+	component := describeOutput.Components[0]
+	var state volume.State
+	if err := json.Unmarshal([]byte(component.State), &state); err != nil {
+		return nil, fmt.Errorf("unmarshalling volume state: %w", err)
+	}
+	if state.VolumeName == "" {
+		return nil, fmt.Errorf("volume %q has not been created", component.Name)
+	}
+	return &volumeRef{
+		ComponentName: component.Name,
+		VolumeName:    state.VolumeName,
+	}, nil
+}
+
+// Snapshots are stored per workspace and volume component, so that they
+// survive the volume being recreated.
+func snapshotPath(varDir, workspaceID, componentName, snapshotName string) string {
+	return filepath.Join(varDir, "snapshots", workspaceID, componentName, snapshotName+".tar")
+}
+
+func defaultSnapshotName(now time.Time) string {
+	return "snapshot-" + now.UTC().Format("20060102-150405")
+}
+
+// Names are validated to prevent them from traversing out of the snapshot
+// directory.
+func validateSnapshotName(name string) error {
+	if err := exohcl.ValidateName(name); err != nil {
+		return errutil.HTTPErrorf(http.StatusBadRequest, "snapshot name %q invalid: %w", name, err)
+	}
+	return nil
+}
+
+func (ws *Workspace) SnapshotVolume(ctx context.Context, input *api.SnapshotVolumeInput) (*api.SnapshotVolumeOutput, error) {
+	name := input.Name
+	if name == "" {
+		name = defaultSnapshotName(chrono.Now(ctx))
+	}
+	if err := validateSnapshotName(name); err != nil {
+		return nil, err
+	}
+	vol, err := ws.resolveVolume(ctx, input.Ref)
+	if err != nil {
+		return nil, err
+	}
+	snapshotPath := snapshotPath(ws.VarDir, ws.ID, vol.ComponentName, name)
+
+	// Unlike restoring, snapshotting a volume in use is allowed, since it may
+	// be the only way to capture its contents, but the snapshot may be
+	// inconsistent.
+	running, err := volume.RunningContainers(ctx, ws.Docker, vol.VolumeName)
+	if err != nil {
+		return nil, err
+	}
+
+	job := ws.TaskTracker.StartTask(ctx, "snapshotting "+vol.ComponentName)
+	ws.logEventf(ctx, "snapshotting %s to %s... %s", vol.ComponentName, name, job.JobID())
+	if len(running) > 0 {
+		warning := fmt.Sprintf("volume %s is in use by running container(s) %s; the snapshot may be inconsistent", vol.ComponentName, strings.Join(running, ", "))
+		ws.logEventf(ctx, "warning: %s", warning)
+		job.ReportMessage("warning: " + warning)
+	}
+	go func() {
+		defer job.Finish()
+		if err := os.MkdirAll(filepath.Dir(snapshotPath), 0700); err != nil {
+			job.Fail(fmt.Errorf("making snapshot directory: %w", err))
+			return
+		}
+		// Write to a temporary file, so that a failed snapshot does not replace
+		// an existing one of the same name.
+		f, err := ioutil.TempFile(filepath.Dir(snapshotPath), ".snapshot-*")
+		if err != nil {
+			job.Fail(fmt.Errorf("creating snapshot file: %w", err))
+			return
+		}
+		defer os.Remove(f.Name())
+		err = volume.Snapshot(job, ws.Docker, vol.VolumeName, f)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(f.Name(), snapshotPath)
+		}
+		if err != nil {
+			job.Fail(err)
+		}
+	}()
+	return &api.SnapshotVolumeOutput{
+		Name:  name,
+		JobID: job.JobID(),
+	}, nil
+}
+
+func (ws *Workspace) RestoreVolume(ctx context.Context, input *api.RestoreVolumeInput) (*api.RestoreVolumeOutput, error) {
+	vol, err := ws.resolveVolume(ctx, input.Ref)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateSnapshotName(input.Name); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(snapshotPath(ws.VarDir, ws.ID, vol.ComponentName, input.Name))
+	if os.IsNotExist(err) {
+		return nil, errutil.HTTPErrorf(http.StatusNotFound, "no snapshot %q of volume %q", input.Name, vol.ComponentName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening snapshot: %w", err)
+	}
+
+	job := ws.TaskTracker.StartTask(ctx, "restoring "+vol.ComponentName)
+	ws.logEventf(ctx, "restoring %s from %s... %s", vol.ComponentName, input.Name, job.JobID())
+	go func() {
+		defer job.Finish()
+		defer f.Close()
+		if err := volume.Restore(job, ws.Docker, vol.VolumeName, f); err != nil {
+			job.Fail(err)
+		}
+	}()
+	return &api.RestoreVolumeOutput{
+		JobID: job.JobID(),
+	}, nil
+}
+
+func (ws *Workspace) CloneVolume(ctx context.Context, input *api.CloneVolumeInput) (*api.CloneVolumeOutput, error) {
+	source, err := ws.resolveVolume(ctx, input.Source)
+	if err != nil {
+		return nil, err
+	}
+	target, err := ws.resolveVolume(ctx, input.Target)
+	if err != nil {
+		return nil, err
+	}
+	if source.VolumeName == target.VolumeName {
+		return nil, errutil.HTTPErrorf(http.StatusBadRequest, "cannot clone volume %q to itself", source.ComponentName)
+	}
+
+	job := ws.TaskTracker.StartTask(ctx, "cloning "+source.ComponentName)
+	ws.logEventf(ctx, "cloning %s to %s... %s", source.ComponentName, target.ComponentName, job.JobID())
+	go func() {
+		defer job.Finish()
+		if err := volume.Clone(job, ws.Docker, source.VolumeName, target.VolumeName); err != nil {
+			job.Fail(err)
+		}
+	}()
+	return &api.CloneVolumeOutput{
+		JobID: job.JobID(),
+	}, nil
+}
@@ -0,0 +1,26 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotPath(t *testing.T) {
+	assert.Equal(t, "/var/snapshots/ws1/db/nightly.tar", snapshotPath("/var", "ws1", "db", "nightly"))
+}
+
+func TestDefaultSnapshotName(t *testing.T) {
+	now := time.Date(2021, 10, 19, 8, 4, 5, 0, time.FixedZone("EST", -5*60*60))
+	name := defaultSnapshotName(now)
+	assert.Equal(t, "snapshot-20211019-130405", name)
+	assert.NoError(t, validateSnapshotName(name))
+}
+
+func TestValidateSnapshotName(t *testing.T) {
+	assert.NoError(t, validateSnapshotName("before-migration"))
+	for _, name := range []string{"", "../db", "a/b", "Nightly", "two--dashes", "trailing-"} {
+		assert.Error(t, validateSnapshotName(name), name)
+	}
+}
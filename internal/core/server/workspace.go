@@ -9,7 +9,6 @@ import (
 	"os"
 	"path"
 	"reflect"
-	"strings"
 	"sync"
 
 	"github.com/deref/exo/internal/chrono"
@@ -20,6 +19,7 @@ import (
 	eventd "github.com/deref/exo/internal/eventd/api"
 	"github.com/deref/exo/internal/gensym"
 	josh "github.com/deref/exo/internal/josh/server"
+	composemanifest "github.com/deref/exo/internal/manifest/compose"
 	"github.com/deref/exo/internal/manifest/exohcl"
 	"github.com/deref/exo/internal/manifest/procfile"
 	"github.com/deref/exo/internal/providers/core"
@@ -35,20 +35,20 @@ import (
 	"github.com/deref/exo/internal/util/jsonutil"
 	"github.com/deref/exo/internal/util/logging"
 	"github.com/deref/exo/internal/util/pathutil"
-	dockerclient "github.com/docker/docker/client"
 	"github.com/hashicorp/hcl/v2"
 	"golang.org/x/sync/errgroup"
 )
 
 type Workspace struct {
-	ID          string
-	VarDir      string
-	Store       state.Store
-	SyslogPort  uint
-	Logger      logging.Logger // TODO: Embed in context, so it can be annotated with request info.
-	Docker      *dockerclient.Client
-	TaskTracker *task.TaskTracker
-	EsvClient   esv.EsvClient
+	ID               string
+	VarDir           string
+	Store            state.Store
+	SyslogPort       uint
+	ContainerLogMode string
+	Logger           logging.Logger // TODO: Embed in context, so it can be annotated with request info.
+	Docker           docker.Runtime
+	TaskTracker      *task.TaskTracker
+	EsvClient        esv.EsvClient
 }
 
 var _ api.Workspace = &Workspace{}
@@ -89,9 +89,11 @@ func (ws *Workspace) describe(ctx context.Context) (*api.WorkspaceDescription, e
 	}
 	desc := output.Workspaces[0]
 	return &api.WorkspaceDescription{
-		ID:          ws.ID,
-		Root:        desc.Root,
-		DisplayName: desc.DisplayName,
+		ID:            ws.ID,
+		Root:          desc.Root,
+		DisplayName:   desc.DisplayName,
+		Profile:       desc.Profile,
+		WatchManifest: desc.WatchManifest,
 	}, nil
 }
 
@@ -108,6 +110,7 @@ func (ws *Workspace) Destroy(ctx context.Context, input *api.DestroyInput) (*api
 		if err := job.Wait(); err != nil {
 			return
 		}
+		ws.removeUnusedImages(job)
 		if _, err := ws.Store.RemoveWorkspace(ctx, &state.RemoveWorkspaceInput{
 			ID: ws.ID,
 		}); err != nil {
@@ -121,9 +124,17 @@ func (ws *Workspace) Destroy(ctx context.Context, input *api.DestroyInput) (*api
 }
 
 func (ws *Workspace) Apply(ctx context.Context, input *api.ApplyInput) (*api.ApplyOutput, error) {
+	output, _, err := ws.apply(ctx, input)
+	return output, err
+}
+
+// apply is Apply, but also returns a channel that receives the errors of the
+// components that failed to apply once the apply job finishes. The channel is
+// nil when no job is started.
+func (ws *Workspace) apply(ctx context.Context, input *api.ApplyInput) (*api.ApplyOutput, <-chan []error, error) {
 	description, err := ws.describe(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("describing workspace: %w", err)
+		return nil, nil, fmt.Errorf("describing workspace: %w", err)
 	}
 	m, err := ws.loadManifest(ctx, description.Root, input)
 
@@ -147,13 +158,14 @@ func (ws *Workspace) Apply(ctx context.Context, input *api.ApplyInput) (*api.App
 		invalidManifest = diags.HasErrors()
 	}
 	if invalidManifest {
-		return nil, errutil.WithHTTPStatus(http.StatusBadRequest, err)
+		return nil, nil, errutil.WithHTTPStatus(http.StatusBadRequest, err)
 	}
 	manifestComponents := componentSet.Components
+	ws.addImplicitDependencies(ctx, manifestComponents)
 
 	describeOutput, err := ws.DescribeComponents(ctx, &api.DescribeComponentsInput{})
 	if err != nil {
-		return nil, fmt.Errorf("describing components: %w", err)
+		return nil, nil, fmt.Errorf("describing components: %w", err)
 	}
 
 	oldComponents := make(map[string]api.ComponentDescription, len(describeOutput.Components))
@@ -161,30 +173,21 @@ func (ws *Workspace) Apply(ctx context.Context, input *api.ApplyInput) (*api.App
 		oldComponents[oldComponent.Name] = oldComponent
 	}
 
-	// TODO: Handle partial failures.
-	job := ws.TaskTracker.StartTask(ctx, "applying")
-	ws.logEventf(ctx, "applying manifest... %s", job.JobID())
-
 	// The algorithm for applying a new manifest is as follows:
 	// 1. Build dependency graph for the new manifest, allComponents.
-	// 2. Create empty dependency graphs for deletions, deleteGraph, and for creations, createGraph.
-	// 3. Iterate all components in the old graph, and for each component that does not exist in the new graph:
-	//   3.1. Add a node to deleteGraph
-	//   3.2. For each dependency of node, add an _inverted_ edge for each dependency of that node. This
-	//        allows us to check whether deleting these nodes would leave the graph with unmet dependencies.
-	// 4. Check whether deleteGraph has unmet dependencies. If so, return an error because applying deletions would
-	//    leave the graph with unmet dependencies.
-	// 5. Create a set to track components to update, updateSet.
-	// 6. Walk the new components.
-	//   6.1. If the component already exists, get this component and all components that depend on it. For each,
-	//     6.1.1. Check if the component is in updateSet. If not, add a delete task to deleteGraph and a create task
-	//            to createGraph, and add the component to updateSet.
-	//   6.2. Otherwise, add a task to create the component to createGraph.
-	// 7. Apply all deletes in topographic order.
-	// 8. Apply all creates in topographic order.
+	// 2. Plan the action for each component. See planApply.
+	// 3. Create empty dependency graphs for deletions, deleteGraph, and for creations, createGraph.
+	// 4. Walk the plan.
+	//   4.1. If the component is deleted, add a delete task to deleteGraph.
+	//   4.2. If the component is replaced, add a delete task to deleteGraph and a create task to createGraph.
+	//   4.3. If the component is updated, add an update task to createGraph.
+	//   4.4. If the component is created, add a create task to createGraph.
+	// 5. Apply all deletes in topographic order.
+	// 6. Apply all creates in topographic order.
 
 	// 1.
 	allComponents := deps.New()
+	newComponents := make(map[string]*exohcl.Component, len(manifestComponents))
 	for i := 0; i < len(manifestComponents); i++ {
 		c := manifestComponents[i]
 		allComponents.AddNode(&componentNode{
@@ -193,122 +196,188 @@ func (ws *Workspace) Apply(ctx context.Context, input *api.ApplyInput) (*api.App
 		for _, dependency := range c.DependsOn {
 			allComponents.AddEdge(c.Name, dependency)
 		}
+		newComponents[c.Name] = c
 	}
 
 	// 2.
-	createGraph := deps.New()
-	deleteGraph := deps.New()
-
-	// 3.
-	for name, oldComponent := range oldComponents {
-		if allComponents.HasNode(name) {
-			continue
-		}
-
-		deleteGraph.AddNode(&runTaskNode{
-			name: name,
-			task: job.CreateChild("deleting " + name),
-			run: func(t *task.Task) error {
-				return ws.control(job.Context, oldComponent, &api.DestroyInput{})
-			},
-		})
-		// Invert the dependencies for deletions so that we can check whether there is anything
-		// left in the graph that still depends on something that we want to delete.
-		for _, dependency := range oldComponent.DependsOn {
-			deleteGraph.AddEdge(dependency, name)
-		}
+	// The environment is that of the manifest being applied, rather than that
+	// of the most recent apply, since the profile may have changed.
+	env, err := ws.getSimpleManifestEnvironment(ctx, m)
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting environment: %w", err)
+	}
+	plan, err := ws.planApply(ctx, oldComponents, manifestComponents, allComponents, description.Root, env)
+	if err != nil {
+		return nil, nil, err
+	}
+	output := api.ApplyOutput{
+		Warnings: make([]string, len(diags)),
+		Plan:     plan,
+	}
+	for i, diag := range diags {
+		output.Warnings[i] = diag.Error()
+	}
+	if input.Plan {
+		return &output, nil, nil
 	}
 
-	// 4.
-	unmetDeps := deleteGraph.UnmetDependencies()
-	if len(unmetDeps) > 0 {
-		return nil, fmt.Errorf("would remove components that are still depended on: %s", strings.Join(unmetDeps, ", "))
+	if err := ws.recordApplySettings(ctx, input, m.Filename, m.Files.Read()); err != nil {
+		return nil, nil, err
 	}
 
-	// 5.
-	updateSet := make(map[string]struct{})
+	// TODO: Handle partial failures.
+	job := ws.TaskTracker.StartTask(ctx, "applying")
+	ws.logEventf(ctx, "applying manifest... %s", job.JobID())
+	output.JobID = job.ID()
 
-	recreateComponentOnce := func(name string, oldComponent api.ComponentDescription, newComponent *exohcl.Component) {
-		// 6.1.1.
-		if _, alreadyUpdated := updateSet[name]; alreadyUpdated {
-			return
-		}
+	// 3.
+	createGraph := deps.New()
+	deleteGraph := deps.New()
 
-		deleteGraph.AddNode(&runTaskNode{
-			name: name,
-			task: job.CreateChild("deleting " + name),
-			run: func(t *task.Task) error {
-				return ws.control(job.Context, oldComponent, &api.DestroyInput{})
-			},
-		})
-		for _, dependency := range oldComponent.DependsOn {
-			deleteGraph.AddEdge(dependency, name)
+	// 4.
+	for _, p := range plan {
+		name := p.Name
+		oldComponent := oldComponents[name]
+		newComponent := newComponents[name]
+
+		if p.Action == actionDelete || p.Action == actionReplace {
+			// 4.1. and 4.2.
+			deleteGraph.AddNode(&runTaskNode{
+				name: name,
+				task: job.CreateChild("deleting " + name),
+				run: func(t *task.Task) error {
+					return ws.control(job.Context, oldComponent, &api.DestroyInput{})
+				},
+			})
+			for _, dependency := range oldComponent.DependsOn {
+				deleteGraph.AddEdge(dependency, name)
+			}
 		}
 
-		createGraph.AddNode(&runTaskNode{
-			name: name,
-			task: job.CreateChild("re-creating " + name),
-			run: func(t *task.Task) error {
-				// Should the replacement component get the old component's ID?
-				return ws.createComponent(t, manifestComponentToCreate(newComponent), gensym.RandomBase32())
-			},
-		})
-		for _, dependency := range newComponent.DependsOn {
-			createGraph.AddEdge(name, dependency)
-		}
-		updateSet[name] = struct{}{}
-	}
+		switch p.Action {
+		case actionReplace:
+			// 4.2.
+			createGraph.AddNode(&runTaskNode{
+				name: name,
+				task: job.CreateChild("re-creating " + name),
+				run: func(t *task.Task) error {
+					// Should the replacement component get the old component's ID?
+					err := ws.createComponent(t, manifestComponentToCreate(newComponent), gensym.RandomBase32(), env)
+					return withManifestLocation(newComponent, err)
+				},
+			})
 
-	// 6.
-	for i := 0; i < len(manifestComponents); i++ {
-		newComponent := manifestComponents[i]
-		name := newComponent.Name
-
-		if oldComponent, exists := oldComponents[name]; exists {
-			name := name
-			// 6.1.
-			recreateComponentOnce(name, oldComponent, newComponent)
-
-			componentsForUpdate := allComponents.Dependents(name)
-			for updatedComponentName, componentForUpdate := range componentsForUpdate {
-				forDelete := oldComponents[updatedComponentName]
-				forCreate := componentForUpdate.(*componentNode).component
-				recreateComponentOnce(updatedComponentName, forDelete, forCreate)
-			}
-		} else {
-			// 6.2.
+		case actionUpdate:
+			// 4.3.
+			createGraph.AddNode(&runTaskNode{
+				name: name,
+				task: job.CreateChild("updating " + name),
+				run: func(t *task.Task) error {
+					err := ws.updateComponent(t, oldComponent, manifestComponentToCreate(newComponent), env)
+					return withManifestLocation(newComponent, err)
+				},
+			})
+
+		case actionCreate:
+			// 4.4.
 			createGraph.AddNode(&runTaskNode{
 				name: name,
 				task: job.CreateChild("adding " + name),
 				run: func(t *task.Task) error {
-					return ws.createComponent(t, manifestComponentToCreate(newComponent), gensym.RandomBase32())
+					create := manifestComponentToCreate(newComponent)
+					create.Adopt = input.Adopt
+					err := ws.createComponent(t, create, gensym.RandomBase32(), env)
+					return withManifestLocation(newComponent, err)
 				},
 			})
-			for _, dependency := range newComponent.DependsOn {
-				createGraph.AddEdge(name, dependency)
-			}
+
+		default:
+			continue
+		}
+		for _, dependency := range newComponent.DependsOn {
+			createGraph.AddEdge(name, dependency)
 		}
 	}
 
-	// Run tasks.
+	// 5. and 6.
+	done := make(chan []error, 1)
 	go func() {
 		defer job.Finish()
 
-		executeRunTasks(deleteGraph)
-		executeRunTasks(createGraph)
+		failures := executeRunTasks(deleteGraph)
+		failures = append(failures, executeRunTasks(createGraph)...)
+		ws.removeUnusedImages(job)
+		if len(failures) > 0 {
+			job.Fail(fmt.Errorf("%d of %d components failed to apply", len(failures), len(plan)))
+		}
+		done <- failures
 	}()
 
-	output := api.ApplyOutput{
-		Warnings: make([]string, len(diags)),
-		JobID:    job.ID(),
-	}
-	for i, diag := range diags {
-		output.Warnings[i] = diag.Error()
+	return &output, done, nil
+}
+
+// recordApplySettings remembers the manifest and options of an apply, so
+// that the environment of the workspace matches the applied manifest and so
+// that a watched manifest is re-applied exactly as it was last applied.
+// manifestPath is the resolved path of the manifest, or empty if the manifest
+// was given only by its contents. manifestFiles are the other files that the
+// manifest read, such as module sources.
+func (ws *Workspace) recordApplySettings(ctx context.Context, input *api.ApplyInput, manifestPath string, manifestFiles []string) error {
+	patch := &state.PatchWorkspaceInput{
+		ID:             ws.ID,
+		Profile:        &input.Profile,
+		WatchManifest:  input.WatchManifest,
+		ManifestPath:   &manifestPath,
+		ManifestFormat: &input.Format,
+		Formation:      &input.Formation,
+		EnvFiles:       &input.EnvFiles,
+		ManifestFiles:  &manifestFiles,
+	}
+	if _, err := ws.Store.PatchWorkspace(ctx, patch); err != nil {
+		return fmt.Errorf("recording workspace settings: %w", err)
+	}
+	return nil
+}
+
+// Adds dependencies that components infer from their own specs, such as a
+// container that shares the network namespace of another container. Inferred
+// dependencies on components not in the manifest are ignored.
+func (ws *Workspace) addImplicitDependencies(ctx context.Context, components []*exohcl.Component) {
+	names := make(map[string]bool, len(components))
+	for _, c := range components {
+		names[c.Name] = true
+	}
+	for _, c := range components {
+		var output *api.DependenciesOutput
+		if err := ws.query(ctx, api.ComponentDescription{
+			Name: c.Name,
+			Type: c.Type,
+			Spec: c.Spec,
+		}, &output, &api.DependenciesInput{
+			Spec: c.Spec,
+		}); err != nil {
+			ws.Logger.Infof("inferring dependencies of %q: %v", c.Name, err)
+			continue
+		}
+		seen := make(map[string]bool, len(c.DependsOn))
+		for _, dep := range c.DependsOn {
+			seen[dep] = true
+		}
+		for _, dep := range output.Components {
+			if dep == c.Name || seen[dep] || !names[dep] {
+				continue
+			}
+			seen[dep] = true
+			c.DependsOn = append(c.DependsOn, dep)
+		}
 	}
-	return &output, nil
 }
 
-func executeRunTasks(g *deps.Graph) {
+// executeRunTasks runs the tasks of g in topological order, returning the
+// errors of those that failed.
+func executeRunTasks(g *deps.Graph) []error {
+	var mu sync.Mutex
+	var failures []error
 	layers := g.TopoSortedLayers()
 	for _, layer := range layers {
 		var wg sync.WaitGroup
@@ -321,11 +390,15 @@ func executeRunTasks(g *deps.Graph) {
 				defer runTask.task.Finish()
 				if err := runTask.run(runTask.task); err != nil {
 					runTask.task.Fail(err)
+					mu.Lock()
+					failures = append(failures, fmt.Errorf("%s: %w", runTask.name, err))
+					mu.Unlock()
 				}
 			}()
 		}
 		wg.Wait()
 	}
+	return failures
 }
 
 func (ws *Workspace) Resolve(ctx context.Context, input *api.ResolveInput) (*api.ResolveOutput, error) {
@@ -361,18 +434,34 @@ func (ws *Workspace) DescribeComponents(ctx context.Context, input *api.Describe
 	}
 	for i, component := range stateOutput.Components {
 		output.Components[i] = api.ComponentDescription{
-			ID:        component.ID,
-			Name:      component.Name,
-			Type:      component.Type,
-			Spec:      component.Spec,
-			State:     component.State,
-			Created:   component.Created,
-			DependsOn: component.DependsOn,
+			ID:                component.ID,
+			Name:              component.Name,
+			Type:              component.Type,
+			Spec:              component.Spec,
+			State:             component.State,
+			Created:           component.Created,
+			DependsOn:         component.DependsOn,
+			EnvironmentDigest: component.EnvironmentDigest,
+			Labels:            component.Labels,
+			StartPolicy:       component.StartPolicy,
 		}
 	}
 	return output, nil
 }
 
+func (ws *Workspace) lookupComponent(ctx context.Context, ref string) (*api.ComponentDescription, error) {
+	output, err := ws.DescribeComponents(ctx, &api.DescribeComponentsInput{
+		Refs: []string{ref},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(output.Components) == 0 {
+		return nil, nil
+	}
+	return &output.Components[0], nil
+}
+
 // TODO: Argument should be type+id, no spec or state or anything like that.
 func (ws *Workspace) newController(ctx context.Context, desc api.ComponentDescription) Controller {
 	description, err := ws.describe(ctx)
@@ -381,16 +470,12 @@ func (ws *Workspace) newController(ctx context.Context, desc api.ComponentDescri
 			Err: fmt.Errorf("workspace error: %w", err),
 		}
 	}
-	env, err := ws.getEnvironment(ctx)
+	simpleEnv, err := ws.getSimpleEnvironment(ctx)
 	if err != nil {
 		return &invalid.Invalid{
 			Err: fmt.Errorf("environment error: %w", err),
 		}
 	}
-	simpleEnv := map[string]string{}
-	for k, v := range env {
-		simpleEnv[k] = v.Value
-	}
 	base := core.ComponentBase{
 		ComponentID:          desc.ID,
 		ComponentName:        desc.Name,
@@ -399,6 +484,7 @@ func (ws *Workspace) newController(ctx context.Context, desc api.ComponentDescri
 		WorkspaceRoot:        description.Root,
 		WorkspaceEnvironment: simpleEnv,
 		Logger:               ws.Logger,
+		LookupComponent:      ws.lookupComponent,
 	}
 	switch desc.Type {
 	case "process":
@@ -414,6 +500,7 @@ func (ws *Workspace) newController(ctx context.Context, desc api.ComponentDescri
 				Docker:        ws.Docker,
 			},
 			SyslogPort: ws.SyslogPort,
+			LogMode:    ws.ContainerLogMode,
 		}
 
 	case "network":
@@ -456,7 +543,12 @@ func (ws *Workspace) CreateComponent(ctx context.Context, input *api.CreateCompo
 	go func() {
 		defer job.Finish()
 
-		err := ws.createComponent(ctx, input, id)
+		env, err := ws.getSimpleEnvironment(ctx)
+		if err != nil {
+			err = fmt.Errorf("getting environment: %w", err)
+		} else {
+			err = ws.createComponent(ctx, input, id, env)
+		}
 		if err != nil {
 			ws.logEventf(ctx, "error creating %s: %v", input.Name, err)
 			job.Fail(err)
@@ -471,19 +563,34 @@ func (ws *Workspace) CreateComponent(ctx context.Context, input *api.CreateCompo
 	}, nil
 }
 
-func (ws *Workspace) createComponent(ctx context.Context, input *api.CreateComponentInput, id string) error {
+// createComponent adds and initializes a component. env is the workspace
+// environment that the environment digest of the component is computed from.
+func (ws *Workspace) createComponent(ctx context.Context, input *api.CreateComponentInput, id string, env map[string]string) error {
 	if err := exohcl.ValidateName(input.Name); err != nil {
 		return errutil.HTTPErrorf(http.StatusBadRequest, "component name %q invalid: %w", input.Name, err)
 	}
+	switch input.StartPolicy {
+	case "", exohcl.StartPolicyAuto, exohcl.StartPolicyManual:
+	default:
+		return errutil.HTTPErrorf(http.StatusBadRequest, "invalid start policy: %q", input.StartPolicy)
+	}
+
+	description, err := ws.describe(ctx)
+	if err != nil {
+		return fmt.Errorf("describing workspace: %w", err)
+	}
 
 	if _, err := ws.Store.AddComponent(ctx, &state.AddComponentInput{
-		WorkspaceID: ws.ID,
-		ID:          id,
-		Name:        input.Name,
-		Type:        input.Type,
-		Spec:        input.Spec,
-		Created:     chrono.NowString(ctx),
-		DependsOn:   input.DependsOn,
+		WorkspaceID:       ws.ID,
+		ID:                id,
+		Name:              input.Name,
+		Type:              input.Type,
+		Spec:              input.Spec,
+		Created:           chrono.NowString(ctx),
+		DependsOn:         input.DependsOn,
+		EnvironmentDigest: environmentDigest(input.Type, input.Spec, description.Root, env),
+		Labels:            input.Labels,
+		StartPolicy:       input.StartPolicy,
 	}); err != nil {
 		return fmt.Errorf("adding component: %w", err)
 	}
@@ -492,23 +599,42 @@ func (ws *Workspace) createComponent(ctx context.Context, input *api.CreateCompo
 	// the add. Only the fields needed by control are included.
 	// TODO: Store.AddComponent could return a component description?
 	desc := api.ComponentDescription{
-		ID:        id,
-		Name:      input.Name,
-		Type:      input.Type,
-		Spec:      input.Spec,
-		DependsOn: input.DependsOn,
+		ID:          id,
+		Name:        input.Name,
+		Type:        input.Type,
+		Spec:        input.Spec,
+		DependsOn:   input.DependsOn,
+		Labels:      input.Labels,
+		StartPolicy: input.StartPolicy,
 	}
 	return ws.control(ctx, desc, &api.InitializeInput{
-		Spec: input.Spec,
+		Spec:    input.Spec,
+		Adopt:   input.Adopt,
+		NoStart: input.StartPolicy == exohcl.StartPolicyManual,
 	})
 }
 
+// Prefixes an error with the location of the manifest block that declared the
+// component, if known.
+func withManifestLocation(c *exohcl.Component, err error) error {
+	if err == nil || c.Source == nil {
+		return err
+	}
+	rng := c.Source.DefRange()
+	if rng.Filename == "" {
+		return err
+	}
+	return fmt.Errorf("%s: %w", rng, err)
+}
+
 func manifestComponentToCreate(c *exohcl.Component) *api.CreateComponentInput {
 	return &api.CreateComponentInput{
-		Type:      c.Type,
-		Name:      c.Name,
-		Spec:      c.Spec,
-		DependsOn: c.DependsOn,
+		Type:        c.Type,
+		Name:        c.Name,
+		Spec:        c.Spec,
+		DependsOn:   c.DependsOn,
+		Labels:      c.Labels,
+		StartPolicy: c.StartPolicy,
 	}
 }
 
@@ -543,6 +669,34 @@ func (ws *Workspace) UpdateComponent(ctx context.Context, input *api.UpdateCompo
 		patch.DependsOn = &input.DependsOn
 	}
 
+	// Renamed components are replaced, since names may be baked in to the
+	// underlying resources.
+	replace := newComponent.Name != oldComponent.Name ||
+		(newComponent.Spec != oldComponent.Spec && ws.planUpdate(ctx, oldComponent, newComponent.Spec))
+
+	if !replace {
+		ws.logEventf(ctx, "updating %s", oldComponent.Name)
+		job := ws.TaskTracker.StartTask(ctx, "updating")
+		go func() {
+			defer job.Finish()
+			job.Go("update in place", func(t *task.Task) error {
+				env, err := ws.getSimpleEnvironment(t)
+				if err != nil {
+					return fmt.Errorf("getting environment: %w", err)
+				}
+				return ws.updateComponent(t, oldComponent, &api.CreateComponentInput{
+					Spec:        newComponent.Spec,
+					DependsOn:   newComponent.DependsOn,
+					Labels:      newComponent.Labels,
+					StartPolicy: newComponent.StartPolicy,
+				}, env)
+			})
+		}()
+		return &api.UpdateComponentOutput{
+			JobID: job.ID(),
+		}, nil
+	}
+
 	_, err = ws.Store.PatchComponent(ctx, &patch)
 	if err != nil {
 		return nil, fmt.Errorf("patching component: %w", err)
@@ -552,7 +706,6 @@ func (ws *Workspace) UpdateComponent(ctx context.Context, input *api.UpdateCompo
 	job := ws.TaskTracker.StartTask(ctx, "updating")
 	go func() {
 		defer job.Finish()
-		// TODO: Most updates should be accomplished without a full replacement; especially when there are no spec changes!
 		job.Go("dispose old", func(disposeTask *task.Task) error {
 			// TODO: Fix this really awkward way of encoding a waterfall of async steps.
 			job.Go("initialize new", func(initializeTask *task.Task) error {
@@ -560,7 +713,8 @@ func (ws *Workspace) UpdateComponent(ctx context.Context, input *api.UpdateCompo
 					return context.Canceled
 				}
 				return ws.control(ctx, newComponent, &api.InitializeInput{
-					Spec: newComponent.Spec,
+					Spec:    newComponent.Spec,
+					NoStart: newComponent.StartPolicy == exohcl.StartPolicyManual,
 				})
 			})
 			return ws.control(ctx, oldComponent, &api.DisposeInput{})
@@ -645,11 +799,16 @@ func (ws *Workspace) resolveRefs(ctx context.Context, refs []string) ([]string,
 func (ws *Workspace) DeleteComponents(ctx context.Context, input *api.DeleteComponentsInput) (*api.DeleteComponentsOutput, error) {
 	ws.logEventf(ctx, "deleting components: %s", input.Refs)
 	query := makeComponentQuery(withRefs(input.Refs...), withReversedDependencies)
-	jobID := ws.controlEachComponent(ctx, "deleting", query, func(*api.ComponentDescription) interface{} {
-		return &api.DestroyInput{}
-	})
+	job := ws.TaskTracker.StartTask(ctx, "deleting")
+	go func() {
+		defer job.Finish()
+		ws.goControlComponents(job, query, func(*api.ComponentDescription) interface{} {
+			return &api.DestroyInput{}
+		})
+		ws.removeUnusedImages(job)
+	}()
 	return &api.DeleteComponentsOutput{
-		JobID: jobID,
+		JobID: job.ID(),
 	}, nil
 }
 
@@ -736,7 +895,10 @@ func (ws *Workspace) GetEvents(ctx context.Context, input *api.GetEventsInput) (
 
 func (ws *Workspace) Start(ctx context.Context, input *api.StartInput) (*api.StartOutput, error) {
 	ws.logEventf(ctx, "starting...")
-	jobID := ws.controlEachComponent(ctx, "starting", allProcessQuery(withDependencies), func(*api.ComponentDescription) interface{} {
+	jobID := ws.controlEachComponent(ctx, "starting", allProcessQuery(withDependencies), func(desc *api.ComponentDescription) interface{} {
+		if desc.StartPolicy == exohcl.StartPolicyManual {
+			return nil
+		}
 		return input
 	}, func(desc *api.ComponentDescription, err error) {
 		ws.logEventf(ctx, "error starting %s: %v", desc.Name, err)
@@ -824,7 +986,10 @@ func (ws *Workspace) SignalComponents(ctx context.Context, input *api.SignalComp
 func (ws *Workspace) Restart(ctx context.Context, input *api.RestartInput) (*api.RestartOutput, error) {
 	ws.logEventf(ctx, "restarting...")
 	query := makeComponentQuery(withDependencies)
-	jobID := ws.controlEachComponent(ctx, "restarting", query, func(*api.ComponentDescription) interface{} {
+	jobID := ws.controlEachComponent(ctx, "restarting", query, func(desc *api.ComponentDescription) interface{} {
+		if desc.StartPolicy == exohcl.StartPolicyManual {
+			return nil
+		}
 		return input
 	}, func(desc *api.ComponentDescription, err error) {
 		ws.logEventf(ctx, "error restarting %s: %v", desc.Name, err)
@@ -969,6 +1134,41 @@ func (ws *Workspace) ExportProcfile(ctx context.Context, input *api.ExportProcfi
 	}, nil
 }
 
+func (ws *Workspace) ExportCompose(ctx context.Context, input *api.ExportComposeInput) (*api.ExportComposeOutput, error) {
+	description, err := ws.describe(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("describing workspace: %w", err)
+	}
+	describeOutput, err := ws.DescribeComponents(ctx, &api.DescribeComponentsInput{})
+	if err != nil {
+		return nil, fmt.Errorf("describing components: %w", err)
+	}
+
+	components := make([]composemanifest.Component, len(describeOutput.Components))
+	for i, component := range describeOutput.Components {
+		components[i] = composemanifest.Component{
+			Name:      component.Name,
+			Type:      component.Type,
+			Spec:      component.Spec,
+			DependsOn: component.DependsOn,
+		}
+	}
+
+	exporter := &composemanifest.Exporter{
+		ProjectName: manifestProjectName(description.Root),
+	}
+	var export bytes.Buffer
+	warnings, err := exporter.Export(&export, components)
+	if err != nil {
+		return nil, fmt.Errorf("exporting compose: %w", err)
+	}
+
+	return &api.ExportComposeOutput{
+		Compose:  export.String(),
+		Warnings: warnings,
+	}, nil
+}
+
 func (ws *Workspace) ReadFile(ctx context.Context, input *api.ReadFileInput) (*api.ReadFileOutput, error) {
 	resolvedPath, err := ws.resolveWorkspacePath(ctx, input.Path)
 	if err != nil {
@@ -1094,8 +1294,11 @@ func (ws *Workspace) query(ctx context.Context, desc api.ComponentDescription, o
 		return err
 	}
 	out, err := josh.Send(ctx, ctrl, input)
+	if err != nil {
+		return err
+	}
 	reflect.ValueOf(output).Elem().Set(reflect.ValueOf(out))
-	return err
+	return nil
 }
 
 func (ws *Workspace) control(ctx context.Context, desc api.ComponentDescription, input interface{}) error {
@@ -1147,7 +1350,10 @@ func (ws *Workspace) BuildComponents(ctx context.Context, input *api.BuildCompon
 	ws.logEventf(ctx, "building: %s", input.Refs)
 	query := allBuildableQuery(withRefs(input.Refs...))
 	jobID := ws.controlEachComponent(ctx, "building", query, func(*api.ComponentDescription) interface{} {
-		return &api.BuildInput{}
+		return &api.BuildInput{
+			NoCache: input.NoCache,
+			Pull:    input.Pull,
+		}
 	})
 	return &api.BuildComponentsOutput{
 		JobID: jobID,
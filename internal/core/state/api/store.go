@@ -14,6 +14,7 @@ type Store interface {
 	DescribeWorkspaces(context.Context, *DescribeWorkspacesInput) (*DescribeWorkspacesOutput, error)
 	AddWorkspace(context.Context, *AddWorkspaceInput) (*AddWorkspaceOutput, error)
 	RemoveWorkspace(context.Context, *RemoveWorkspaceInput) (*RemoveWorkspaceOutput, error)
+	PatchWorkspace(context.Context, *PatchWorkspaceInput) (*PatchWorkspaceOutput, error)
 	ResolveWorkspace(context.Context, *ResolveWorkspaceInput) (*ResolveWorkspaceOutput, error)
 	Resolve(context.Context, *ResolveInput) (*ResolveOutput, error)
 	DescribeComponents(context.Context, *DescribeComponentsInput) (*DescribeComponentsOutput, error)
@@ -45,6 +46,27 @@ type RemoveWorkspaceInput struct {
 type RemoveWorkspaceOutput struct {
 }
 
+type PatchWorkspaceInput struct {
+	ID string `json:"id"`
+	// If provided, replaces the profile of the workspace.
+	Profile *string `json:"profile"`
+	// If provided, enables or disables watching the manifest of the workspace.
+	WatchManifest *bool `json:"watchManifest"`
+	// If provided, replaces the path of the most recently applied manifest.
+	ManifestPath *string `json:"manifestPath"`
+	// If provided, replaces the format of the most recently applied manifest.
+	ManifestFormat *string `json:"manifestFormat"`
+	// If provided, replaces the Procfile formation of the most recent apply.
+	Formation *string `json:"formation"`
+	// If provided, replaces the Procfile env files of the most recent apply.
+	EnvFiles *[]string `json:"envFiles"`
+	// If provided, replaces the files read by the most recently applied manifest, such as module sources.
+	ManifestFiles *[]string `json:"manifestFiles"`
+}
+
+type PatchWorkspaceOutput struct {
+}
+
 type ResolveWorkspaceInput struct {
 	Ref string `json:"ref"`
 }
@@ -75,13 +97,16 @@ type DescribeComponentsOutput struct {
 }
 
 type AddComponentInput struct {
-	WorkspaceID string   `json:"workspaceId"`
-	ID          string   `json:"id"`
-	Name        string   `json:"name"`
-	Type        string   `json:"type"`
-	Spec        string   `json:"spec"`
-	Created     string   `json:"created"`
-	DependsOn   []string `json:"dependsOn"`
+	WorkspaceID       string            `json:"workspaceId"`
+	ID                string            `json:"id"`
+	Name              string            `json:"name"`
+	Type              string            `json:"type"`
+	Spec              string            `json:"spec"`
+	Created           string            `json:"created"`
+	DependsOn         []string          `json:"dependsOn"`
+	EnvironmentDigest string            `json:"environmentDigest"`
+	Labels            map[string]string `json:"labels"`
+	StartPolicy       string            `json:"startPolicy"`
 }
 
 type AddComponentOutput struct {
@@ -97,6 +122,12 @@ type PatchComponentInput struct {
 	Spec      string    `json:"spec"`
 	State     string    `json:"state"`
 	DependsOn *[]string `json:"dependsOn"`
+	// If provided, replaces the digest of the environment that the component uses.
+	EnvironmentDigest *string `json:"environmentDigest"`
+	// If provided, replaces component labels.
+	Labels *map[string]string `json:"labels"`
+	// If provided, replaces component start policy.
+	StartPolicy *string `json:"startPolicy"`
 }
 
 type PatchComponentOutput struct {
@@ -119,6 +150,9 @@ func BuildStoreMux(b *josh.MuxBuilder, factory func(req *http.Request) Store) {
 	b.AddMethod("remove-workspace", func(req *http.Request) interface{} {
 		return factory(req).RemoveWorkspace
 	})
+	b.AddMethod("patch-workspace", func(req *http.Request) interface{} {
+		return factory(req).PatchWorkspace
+	})
 	b.AddMethod("resolve-workspace", func(req *http.Request) interface{} {
 		return factory(req).ResolveWorkspace
 	})
@@ -140,18 +174,28 @@ func BuildStoreMux(b *josh.MuxBuilder, factory func(req *http.Request) Store) {
 }
 
 type WorkspaceDescription struct {
-	ID          string `json:"id"`
-	Root        string `json:"root"`
-	DisplayName string `json:"displayName"`
+	ID             string   `json:"id"`
+	Root           string   `json:"root"`
+	DisplayName    string   `json:"displayName"`
+	Profile        string   `json:"profile"`
+	WatchManifest  bool     `json:"watchManifest"`
+	ManifestPath   string   `json:"manifestPath"`
+	ManifestFormat string   `json:"manifestFormat"`
+	Formation      string   `json:"formation"`
+	EnvFiles       []string `json:"envFiles"`
+	ManifestFiles  []string `json:"manifestFiles"`
 }
 
 type ComponentDescription struct {
-	ID          string   `json:"id"`
-	WorkspaceID string   `json:"workspaceId"`
-	Name        string   `json:"name"`
-	Type        string   `json:"type"`
-	Spec        string   `json:"spec"`
-	State       string   `json:"state"`
-	Created     string   `json:"created"`
-	DependsOn   []string `json:"dependsOn"`
+	ID                string            `json:"id"`
+	WorkspaceID       string            `json:"workspaceId"`
+	Name              string            `json:"name"`
+	Type              string            `json:"type"`
+	Spec              string            `json:"spec"`
+	State             string            `json:"state"`
+	Created           string            `json:"created"`
+	DependsOn         []string          `json:"dependsOn"`
+	EnvironmentDigest string            `json:"environmentDigest"`
+	Labels            map[string]string `json:"labels"`
+	StartPolicy       string            `json:"startPolicy"`
 }
@@ -17,6 +17,31 @@ interface "store" {
     input "id" "string" {}
   }
 
+  method "patch-workspace" {
+    input "id" "string" {}
+    input "profile" "*string" {
+      doc = "If provided, replaces the profile of the workspace."
+    }
+    input "watch-manifest" "*bool" {
+      doc = "If provided, enables or disables watching the manifest of the workspace."
+    }
+    input "manifest-path" "*string" {
+      doc = "If provided, replaces the path of the most recently applied manifest."
+    }
+    input "manifest-format" "*string" {
+      doc = "If provided, replaces the format of the most recently applied manifest."
+    }
+    input "formation" "*string" {
+      doc = "If provided, replaces the Procfile formation of the most recent apply."
+    }
+    input "env-files" "*[]string" {
+      doc = "If provided, replaces the Procfile env files of the most recent apply."
+    }
+    input "manifest-files" "*[]string" {
+      doc = "If provided, replaces the files read by the most recently applied manifest, such as module sources."
+    }
+  }
+
   method "resolve-workspace" {
     input "ref" "string" {}
 
@@ -48,6 +73,9 @@ interface "store" {
     input "spec" "string" {}
     input "created" "string" {}
     input "depends-on" "[]string" {}
+    input "environment-digest" "string" {}
+    input "labels" "map[string]string" {}
+    input "start-policy" "string" {}
   }
 
   method "patch-component" {
@@ -62,6 +90,15 @@ interface "store" {
     }
     input "state" "string" {}
     input "depends-on" "*[]string" {}
+    input "environment-digest" "*string" {
+      doc = "If provided, replaces the digest of the environment that the component uses."
+    }
+    input "labels" "*map[string]string" {
+      doc = "If provided, replaces component labels."
+    }
+    input "start-policy" "*string" {
+      doc = "If provided, replaces component start policy."
+    }
   }
 
   method "remove-component" {
@@ -73,6 +110,13 @@ struct "workspace-description" {
   field "id" "string" {}
   field "root" "string" {}
   field "display-name" "string" {}
+  field "profile" "string" {}
+  field "watch-manifest" "bool" {}
+  field "manifest-path" "string" {}
+  field "manifest-format" "string" {}
+  field "formation" "string" {}
+  field "env-files" "[]string" {}
+  field "manifest-files" "[]string" {}
 }
 
 struct "component-description" {
@@ -86,4 +130,7 @@ struct "component-description" {
   # TODO: Remove dependencies from this store. Prefer lifecycle method
   # for dynamic resolution of dependencies from spec.
   field "depends-on" "[]string" {}
+  field "environment-digest" "string" {}
+  field "labels" "map[string]string" {}
+  field "start-policy" "string" {}
 }
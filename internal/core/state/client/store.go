@@ -36,6 +36,11 @@ func (c *Store) RemoveWorkspace(ctx context.Context, input *api.RemoveWorkspaceI
 	return
 }
 
+func (c *Store) PatchWorkspace(ctx context.Context, input *api.PatchWorkspaceInput) (output *api.PatchWorkspaceOutput, err error) {
+	err = c.client.Invoke(ctx, "patch-workspace", input, &output)
+	return
+}
+
 func (c *Store) ResolveWorkspace(ctx context.Context, input *api.ResolveWorkspaceInput) (output *api.ResolveWorkspaceOutput, err error) {
 	err = c.client.Invoke(ctx, "resolve-workspace", input, &output)
 	return
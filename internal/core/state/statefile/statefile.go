@@ -41,18 +41,25 @@ type Component struct {
 	State     string   `json:"state"`
 	Created   string   `json:"created"`
 	DependsOn []string `json:"dependsOn"`
+	// Digest of the workspace environment variables the component uses.
+	EnvironmentDigest string            `json:"environmentDigest,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	StartPolicy       string            `json:"startPolicy,omitempty"`
 }
 
 func (c *Component) getDescription(id, workspaceID string) state.ComponentDescription {
 	return state.ComponentDescription{
-		ID:          id,
-		WorkspaceID: workspaceID,
-		Name:        c.Name,
-		Type:        c.Type,
-		Spec:        c.Spec,
-		State:       c.State,
-		Created:     c.Created,
-		DependsOn:   c.DependsOn,
+		ID:                id,
+		WorkspaceID:       workspaceID,
+		Name:              c.Name,
+		Type:              c.Type,
+		Spec:              c.Spec,
+		State:             c.State,
+		Created:           c.Created,
+		DependsOn:         c.DependsOn,
+		EnvironmentDigest: c.EnvironmentDigest,
+		Labels:            c.Labels,
+		StartPolicy:       c.StartPolicy,
 	}
 }
 
@@ -97,8 +104,15 @@ func (sto *Store) DescribeWorkspaces(ctx context.Context, input *state.DescribeW
 		dnb.AddPath(workspace.Root)
 		if ids == nil || ids[id] {
 			output.Workspaces = append(output.Workspaces, state.WorkspaceDescription{
-				ID:   id,
-				Root: workspace.Root,
+				ID:             id,
+				Root:           workspace.Root,
+				Profile:        workspace.Profile,
+				WatchManifest:  workspace.WatchManifest,
+				ManifestPath:   workspace.ManifestPath,
+				ManifestFormat: workspace.ManifestFormat,
+				Formation:      workspace.Formation,
+				EnvFiles:       workspace.EnvFiles,
+				ManifestFiles:  workspace.ManifestFiles,
 			})
 		}
 	}
@@ -159,6 +173,41 @@ func (sto *Store) RemoveWorkspace(ctx context.Context, input *state.RemoveWorksp
 	return &state.RemoveWorkspaceOutput{}, nil
 }
 
+func (sto *Store) PatchWorkspace(ctx context.Context, input *state.PatchWorkspaceInput) (*state.PatchWorkspaceOutput, error) {
+	_, err := sto.swap(func(root *Root) error {
+		workspace := root.Workspaces[input.ID]
+		if workspace == nil {
+			return errutil.HTTPErrorf(http.StatusNotFound, "no such workspace: %q", input.ID)
+		}
+		if input.Profile != nil {
+			workspace.Profile = *input.Profile
+		}
+		if input.WatchManifest != nil {
+			workspace.WatchManifest = *input.WatchManifest
+		}
+		if input.ManifestPath != nil {
+			workspace.ManifestPath = *input.ManifestPath
+		}
+		if input.ManifestFormat != nil {
+			workspace.ManifestFormat = *input.ManifestFormat
+		}
+		if input.Formation != nil {
+			workspace.Formation = *input.Formation
+		}
+		if input.EnvFiles != nil {
+			workspace.EnvFiles = *input.EnvFiles
+		}
+		if input.ManifestFiles != nil {
+			workspace.ManifestFiles = *input.ManifestFiles
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &state.PatchWorkspaceOutput{}, nil
+}
+
 func (sto *Store) ResolveWorkspace(ctx context.Context, input *state.ResolveWorkspaceInput) (*state.ResolveWorkspaceOutput, error) {
 	var root Root
 	if err := sto.atom.Deref(&root); err != nil {
@@ -363,11 +412,14 @@ func (sto *Store) AddComponent(ctx context.Context, input *state.AddComponentInp
 			return fmt.Errorf("component id %q already exists", input.ID)
 		}
 		workspace.Components[input.ID] = &Component{
-			Name:      input.Name,
-			Type:      input.Type,
-			Spec:      input.Spec,
-			Created:   input.Created,
-			DependsOn: input.DependsOn,
+			Name:              input.Name,
+			Type:              input.Type,
+			Spec:              input.Spec,
+			Created:           input.Created,
+			DependsOn:         input.DependsOn,
+			EnvironmentDigest: input.EnvironmentDigest,
+			Labels:            input.Labels,
+			StartPolicy:       input.StartPolicy,
 		}
 		root.ComponentWorkspaces[input.ID] = input.WorkspaceID
 		return nil
@@ -412,6 +464,15 @@ func (sto *Store) PatchComponent(ctx context.Context, input *state.PatchComponen
 		if input.State != "" {
 			component.State = input.State
 		}
+		if input.EnvironmentDigest != nil {
+			component.EnvironmentDigest = *input.EnvironmentDigest
+		}
+		if input.Labels != nil {
+			component.Labels = *input.Labels
+		}
+		if input.StartPolicy != nil {
+			component.StartPolicy = *input.StartPolicy
+		}
 		return nil
 	})
 	if err != nil {
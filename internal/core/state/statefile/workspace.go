@@ -1,9 +1,16 @@
 package statefile
 
 type Workspace struct {
-	Root       string                `json:"root"`
-	Names      map[string]string     `json:"names"`      // Name -> ID.
-	Components map[string]*Component `json:"components"` // Keyed by ID.
+	Root           string                `json:"root"`
+	Profile        string                `json:"profile,omitempty"`
+	WatchManifest  bool                  `json:"watchManifest,omitempty"`
+	ManifestPath   string                `json:"manifestPath,omitempty"`
+	ManifestFormat string                `json:"manifestFormat,omitempty"`
+	Formation      string                `json:"formation,omitempty"`
+	EnvFiles       []string              `json:"envFiles,omitempty"`
+	ManifestFiles  []string              `json:"manifestFiles,omitempty"`
+	Names          map[string]string     `json:"names"`      // Name -> ID.
+	Components     map[string]*Component `json:"components"` // Keyed by ID.
 }
 
 func (ws *Workspace) resolve(refs []string) []*string {
@@ -22,6 +22,8 @@ import (
 	"github.com/deref/exo/internal/gensym"
 	"github.com/deref/exo/internal/install"
 	"github.com/deref/exo/internal/providers/core/components/log"
+	"github.com/deref/exo/internal/providers/docker"
+	"github.com/deref/exo/internal/providers/docker/logs"
 	"github.com/deref/exo/internal/syslogd"
 	"github.com/deref/exo/internal/task"
 	"github.com/deref/exo/internal/task/api"
@@ -32,7 +34,6 @@ import (
 	"github.com/deref/exo/internal/util/httputil"
 	"github.com/deref/exo/internal/util/logging"
 	"github.com/deref/exo/internal/util/sysutil"
-	docker "github.com/docker/docker/client"
 	"github.com/jmoiron/sqlx"
 	"github.com/mattn/go-isatty"
 	"gopkg.in/natefinch/lumberjack.v2"
@@ -135,9 +136,9 @@ func RunServer(ctx context.Context, flags map[string]string) {
 		}
 	}()
 
-	dockerClient, err := docker.NewClientWithOpts()
+	dockerClient, err := docker.NewRuntime(cfg.Container)
 	if err != nil {
-		cmdutil.Fatalf("failed to create docker client: %v", err)
+		cmdutil.Fatalf("failed to create container runtime client: %v", err)
 	}
 
 	taskTracker := &task.TaskTracker{
@@ -146,16 +147,17 @@ func RunServer(ctx context.Context, flags map[string]string) {
 	}
 
 	kernelCfg := &kernel.Config{
-		Install:     inst,
-		VarDir:      cfg.VarDir,
-		Store:       store,
-		SyslogPort:  cfg.Log.SyslogPort,
-		Docker:      dockerClient,
-		Logger:      logger,
-		TaskTracker: taskTracker,
-		TokenClient: cfg.GetTokenClient(),
-		EsvClient:   esv.NewEsvClient(cfg.EsvTokenPath),
-		ExoVersion:  about.Version,
+		Install:          inst,
+		VarDir:           cfg.VarDir,
+		Store:            store,
+		SyslogPort:       cfg.Log.SyslogPort,
+		ContainerLogMode: cfg.Log.ContainerMode,
+		Docker:           dockerClient,
+		Logger:           logger,
+		TaskTracker:      taskTracker,
+		TokenClient:      cfg.GetTokenClient(),
+		EsvClient:        esv.NewEsvClient(cfg.EsvTokenPath),
+		ExoVersion:       about.Version,
 	}
 
 	// As a one-time migration, simply delete all logs in the old Badger format.
@@ -209,6 +211,30 @@ func RunServer(ctx context.Context, flags map[string]string) {
 			}
 		}()
 
+		if cfg.Log.ContainerMode == "follow" {
+			logFollower := &logs.Follower{
+				Logger: logger,
+				Docker: dockerClient,
+				Store:  eventStore,
+			}
+			go func() {
+				if err := logFollower.Run(ctx); err != nil {
+					cmdutil.Fatalf("container log follower error: %v", err)
+				}
+			}()
+		}
+
+		manifestWatcher := &kernel.ManifestWatcher{
+			Config:       kernelCfg,
+			Debounce:     250 * time.Millisecond,
+			PollInterval: 2 * time.Second,
+		}
+		go func() {
+			if err := manifestWatcher.Run(ctx); err != nil {
+				logger.Infof("manifest watcher error: %v", err)
+			}
+		}()
+
 		go func() {
 			for {
 				select {
@@ -0,0 +1,184 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"unicode/utf8"
+
+	"github.com/deref/exo/internal/manifest/exohcl"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// document is an open exo.hcl file, along with the results of analyzing it.
+type document struct {
+	URI      string
+	Filename string
+	Text     string
+
+	lineStarts []int
+
+	File         *hcl.File
+	SyntaxErrors bool
+	Diagnostics  hcl.Diagnostics
+}
+
+func newDocument(ctx context.Context, uri string, text string) *document {
+	doc := &document{
+		URI:      uri,
+		Filename: uriToFilename(uri),
+		Text:     text,
+	}
+	doc.lineStarts = []int{0}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			doc.lineStarts = append(doc.lineStarts, i+1)
+		}
+	}
+	doc.analyze(ctx)
+	return doc
+}
+
+func (doc *document) analyze(ctx context.Context) {
+	var diags hcl.Diagnostics
+	doc.File, diags = hclsyntax.ParseConfig([]byte(doc.Text), doc.Filename, hcl.InitialPos)
+	doc.SyntaxErrors = diags.HasErrors()
+	analysisContext := &exohcl.AnalysisContext{
+		Context:     ctx,
+		Diagnostics: diags,
+	}
+	if doc.File != nil && doc.File.Body != nil {
+		doc.validate(analysisContext)
+	}
+	doc.Diagnostics = analysisContext.Diagnostics
+}
+
+// validateManifest is exohcl.Validate, stubbed by tests.
+var validateManifest = exohcl.Validate
+
+// validate validates the manifest of the document. A panic in the analyzer is
+// reported as a diagnostic, rather than crashing the editor's language server.
+func (doc *document) validate(ctx *exohcl.AnalysisContext) {
+	defer func() {
+		if r := recover(); r != nil {
+			ctx.AppendDiags(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Internal error",
+				Detail:   fmt.Sprintf("analyzing manifest: %v", r),
+			})
+		}
+	}()
+	validateManifest(ctx, exohcl.NewManifest(doc.Filename, doc.File))
+}
+
+// body returns the top-level body of the document, which may be partial if
+// the document has syntax errors.
+func (doc *document) body() *hclsyntax.Body {
+	if doc.File == nil {
+		return nil
+	}
+	body, _ := doc.File.Body.(*hclsyntax.Body)
+	return body
+}
+
+// lspDiagnostics converts the diagnostics of this document. Diagnostics of
+// other files, such as included modules, are reported at the start of the
+// document.
+func (doc *document) lspDiagnostics() []Diagnostic {
+	res := make([]Diagnostic, 0, len(doc.Diagnostics))
+	for _, diag := range doc.Diagnostics {
+		var rng Range
+		message := diag.Summary
+		if diag.Detail != "" {
+			message += ": " + diag.Detail
+		}
+		if diag.Subject != nil {
+			if diag.Subject.Filename == doc.Filename {
+				rng = doc.lspRange(*diag.Subject)
+			} else {
+				message = diag.Subject.String() + ": " + message
+			}
+		}
+		severity := diagnosticSeverityError
+		if diag.Severity == hcl.DiagWarning {
+			severity = diagnosticSeverityWarning
+		}
+		res = append(res, Diagnostic{
+			Range:    rng,
+			Severity: severity,
+			Source:   "exo",
+			Message:  message,
+		})
+	}
+	return res
+}
+
+// offset converts an LSP position to a byte offset in the document's text.
+func (doc *document) offset(pos Position) int {
+	if pos.Line < 0 {
+		return 0
+	}
+	if pos.Line >= len(doc.lineStarts) {
+		return len(doc.Text)
+	}
+	offset := doc.lineStarts[pos.Line]
+	for units := 0; units < pos.Character && offset < len(doc.Text); {
+		r, size := utf8.DecodeRuneInString(doc.Text[offset:])
+		if r == '\n' {
+			break
+		}
+		units += utf16Len(r)
+		offset += size
+	}
+	return offset
+}
+
+// lspPosition converts a byte offset in the document's text to an LSP
+// position.
+func (doc *document) lspPosition(offset int) Position {
+	if offset > len(doc.Text) {
+		offset = len(doc.Text)
+	}
+	line := 0
+	for line+1 < len(doc.lineStarts) && doc.lineStarts[line+1] <= offset {
+		line++
+	}
+	character := 0
+	for _, r := range doc.Text[doc.lineStarts[line]:offset] {
+		character += utf16Len(r)
+	}
+	return Position{
+		Line:      line,
+		Character: character,
+	}
+}
+
+func (doc *document) lspRange(rng hcl.Range) Range {
+	return Range{
+		Start: doc.lspPosition(rng.Start.Byte),
+		End:   doc.lspPosition(rng.End.Byte),
+	}
+}
+
+// fullRange is the range of the entire document.
+func (doc *document) fullRange() Range {
+	return Range{
+		End: doc.lspPosition(len(doc.Text)),
+	}
+}
+
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+func uriToFilename(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return u.Path
+}
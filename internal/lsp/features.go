@@ -0,0 +1,326 @@
+package lsp
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/deref/exo/internal/manifest/exohcl"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Documentation of the keywords of the manifest language.
+
+var topLevelDocs = map[string]string{
+	"exo":         "The manifest format version, such as `\"0.1\"`.",
+	"environment": "Environment variables available to all components, and the secrets vaults they are loaded from.",
+	"components":  "The components of the workspace.",
+	"variable":    "A named input value, referenced as `var.<name>`.",
+	"locals":      "Named values, referenced as `local.<name>`.",
+	"module":      "Includes the components of another manifest, namespaced by the module name.",
+	"profile":     "Overlays that remove, add or patch components and environment variables when applied with `--profile`.",
+}
+
+var componentTypeDocs = map[string]string{
+	"process":   "A local process supervised by exo.",
+	"container": "A Docker container, specified like a docker-compose service.",
+	"volume":    "A Docker volume, specified like a docker-compose volume.",
+	"network":   "A Docker network, specified like a docker-compose network.",
+	"component": "A component of any type, with an explicit `type` and encoded `spec`.",
+}
+
+var metaAttributeDocs = map[string]string{
+	"depends_on":   "Names of components that must be created before this one.",
+	"labels":       "Arbitrary string metadata about the component.",
+	"start_policy": "Either `\"auto\"`, to start along with the workspace, or `\"manual\"`.",
+}
+
+var blockAttributeDocs = map[string]map[string]string{
+	"variable": {
+		"default":     "The value of the variable, unless set by a module input.",
+		"description": "Documentation of the variable.",
+	},
+	"module": {
+		"source": "Path of the included manifest, or of a directory containing an exo.hcl file.",
+		"inputs": "Values for the variables of the included manifest.",
+	},
+	"profile": {
+		"remove":      "Names of components and environment variables to remove.",
+		"environment": "Environment variables to set, replacing those of the same name.",
+		"components":  "Components to add, or to patch if one of the same name and type exists.",
+	},
+	"remove": {
+		"components":  "Names of components to remove.",
+		"environment": "Names of environment variables to remove.",
+	},
+	"component": {
+		"type": "The type of the component, such as `\"process\"`.",
+		"spec": "The encoded spec of the component, such as `jsonencode({ ... })`.",
+	},
+}
+
+func init() {
+	for name, doc := range metaAttributeDocs {
+		blockAttributeDocs["component"][name] = doc
+	}
+	blockAttributeDocs["_"] = metaAttributeDocs
+}
+
+// cursor describes the syntactic context of a position in a document.
+type cursor struct {
+	// Blocks enclosing the position, from outermost to innermost.
+	Blocks []*hclsyntax.Block
+	// Attribute containing the position, if any, within the innermost block.
+	Attribute *hclsyntax.Attribute
+}
+
+func (doc *document) cursorAt(offset int) cursor {
+	var cur cursor
+	body := doc.body()
+	for body != nil {
+		var next *hclsyntax.Body
+		for _, block := range body.Blocks {
+			if containsOffset(block.Body.SrcRange, offset) && offset > block.OpenBraceRange.Start.Byte {
+				cur.Blocks = append(cur.Blocks, block)
+				next = block.Body
+				break
+			}
+		}
+		if next == nil {
+			for _, attr := range body.Attributes {
+				if containsOffset(attr.SrcRange, offset) {
+					cur.Attribute = attr
+				}
+			}
+		}
+		body = next
+	}
+	return cur
+}
+
+// path returns the types of the enclosing blocks.
+func (cur cursor) path() []string {
+	res := make([]string, len(cur.Blocks))
+	for i, block := range cur.Blocks {
+		res[i] = block.Type
+	}
+	return res
+}
+
+func (cur cursor) innermost() *hclsyntax.Block {
+	if len(cur.Blocks) == 0 {
+		return nil
+	}
+	return cur.Blocks[len(cur.Blocks)-1]
+}
+
+func containsOffset(rng hcl.Range, offset int) bool {
+	return rng.Start.Byte <= offset && offset <= rng.End.Byte
+}
+
+func (doc *document) completions(pos Position) []CompletionItem {
+	offset := doc.offset(pos)
+	cur := doc.cursorAt(offset)
+	if cur.Attribute != nil && offset > cur.Attribute.EqualsRange.Start.Byte {
+		// Values are not completed.
+		return []CompletionItem{}
+	}
+
+	path := cur.path()
+	switch {
+	case len(path) == 0:
+		return keywordCompletions(topLevelDocs, completionItemKindKeyword)
+	case len(path) == 1 && path[0] == "components":
+		return keywordCompletions(componentTypeDocs, completionItemKindModule)
+	case len(path) == 1:
+		return keywordCompletions(blockAttributeDocs[path[0]], completionItemKindProperty)
+	case len(path) == 2 && path[0] == "components":
+		if path[1] == "component" {
+			return keywordCompletions(blockAttributeDocs["component"], completionItemKindProperty)
+		}
+		schema := exohcl.SpecSchemaFor(path[1])
+		if schema == nil {
+			return []CompletionItem{}
+		}
+		items := make([]CompletionItem, 0, len(schema.Attributes)+1)
+		for _, name := range schema.AttributeNames() {
+			attr := schema.Attributes[name]
+			items = append(items, CompletionItem{
+				Label:  name,
+				Kind:   completionItemKindProperty,
+				Detail: attr.Type.String(),
+			})
+		}
+		items = append(items, CompletionItem{
+			Label:         "_",
+			Kind:          completionItemKindKeyword,
+			Documentation: docPtr("Metadata about the component, such as `depends_on`."),
+		})
+		return items
+	case len(path) == 2 && path[0] == "profile" && path[1] == "components":
+		return keywordCompletions(componentTypeDocs, completionItemKindModule)
+	case len(path) == 2 && path[0] == "profile" && path[1] == "remove":
+		return keywordCompletions(blockAttributeDocs["remove"], completionItemKindProperty)
+	case len(path) == 3 && path[0] == "components" && path[2] == "_":
+		return keywordCompletions(metaAttributeDocs, completionItemKindProperty)
+	default:
+		return []CompletionItem{}
+	}
+}
+
+func keywordCompletions(docs map[string]string, kind int) []CompletionItem {
+	names := make([]string, 0, len(docs))
+	for name := range docs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	items := make([]CompletionItem, len(names))
+	for i, name := range names {
+		items[i] = CompletionItem{
+			Label:         name,
+			Kind:          kind,
+			Documentation: docPtr(docs[name]),
+		}
+	}
+	return items
+}
+
+func docPtr(s string) *MarkupContent {
+	doc := markdown(s)
+	return &doc
+}
+
+func (doc *document) hover(pos Position) *Hover {
+	offset := doc.offset(pos)
+	cur := doc.cursorAt(offset)
+	path := cur.path()
+
+	// Hovering over the type of a block.
+	var siblings []*hclsyntax.Block
+	if block := cur.innermost(); block != nil {
+		siblings = block.Body.Blocks
+	} else if body := doc.body(); body != nil {
+		siblings = body.Blocks
+	}
+	for _, block := range siblings {
+		if !containsOffset(block.TypeRange, offset) {
+			continue
+		}
+		var text string
+		switch {
+		case len(path) == 0:
+			text = topLevelDocs[block.Type]
+		case len(path) == 1 && path[0] == "components":
+			text = componentTypeDocs[block.Type]
+		case block.Type == "_":
+			text = "Metadata about the component, such as `depends_on`."
+		}
+		if text == "" {
+			return nil
+		}
+		return doc.newHover(fmt.Sprintf("**%s**\n\n%s", block.Type, text), block.TypeRange)
+	}
+
+	// Hovering over the name of an attribute.
+	attr := cur.Attribute
+	if attr == nil || !containsOffset(attr.NameRange, offset) {
+		return nil
+	}
+	if len(path) == 0 {
+		if text := topLevelDocs[attr.Name]; text != "" {
+			return doc.newHover(fmt.Sprintf("**%s**\n\n%s", attr.Name, text), attr.NameRange)
+		}
+		return nil
+	}
+	if len(path) == 2 && path[0] == "components" {
+		if schema := exohcl.SpecSchemaFor(path[1]); schema != nil {
+			specAttr := schema.Attributes[attr.Name]
+			if specAttr == nil {
+				return nil
+			}
+			text := fmt.Sprintf("**%s** `%s`\n\nAttribute of %s specs.", attr.Name, specAttr.Type, path[1])
+			return doc.newHover(text, attr.NameRange)
+		}
+	}
+	if docs := blockAttributeDocs[path[len(path)-1]]; docs[attr.Name] != "" {
+		return doc.newHover(fmt.Sprintf("**%s**\n\n%s", attr.Name, docs[attr.Name]), attr.NameRange)
+	}
+	return nil
+}
+
+func (doc *document) newHover(text string, rng hcl.Range) *Hover {
+	lspRange := doc.lspRange(rng)
+	return &Hover{
+		Contents: markdown(text),
+		Range:    &lspRange,
+	}
+}
+
+// definition resolves a component name in a depends_on attribute to the
+// block that declares the component.
+func (doc *document) definition(pos Position) *Location {
+	offset := doc.offset(pos)
+	cur := doc.cursorAt(offset)
+	attr := cur.Attribute
+	if attr == nil || attr.Name != "depends_on" {
+		return nil
+	}
+	tup, ok := attr.Expr.(*hclsyntax.TupleConsExpr)
+	if !ok {
+		return nil
+	}
+	var name string
+	for _, elem := range tup.Exprs {
+		if !containsOffset(elem.Range(), offset) {
+			continue
+		}
+		v, diags := elem.Value(nil)
+		if diags.HasErrors() || !v.IsKnown() || v.IsNull() || v.Type() != cty.String {
+			return nil
+		}
+		name = v.AsString()
+	}
+	if name == "" {
+		return nil
+	}
+
+	body := doc.body()
+	if body == nil {
+		return nil
+	}
+	for _, block := range body.Blocks {
+		if block.Type != "components" {
+			continue
+		}
+		for _, component := range block.Body.Blocks {
+			if len(component.Labels) == 1 && component.Labels[0] == name {
+				return &Location{
+					URI:   doc.URI,
+					Range: doc.lspRange(component.LabelRanges[0]),
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// formatting returns an edit that reformats the entire document, or no edits
+// if the document cannot be parsed. Only whitespace is changed, so that
+// comments and the author's choice of syntax are preserved.
+func (doc *document) formatting() []TextEdit {
+	if doc.File == nil || doc.SyntaxErrors {
+		return []TextEdit{}
+	}
+	formatted := string(hclwrite.Format([]byte(doc.Text)))
+	if formatted == doc.Text {
+		return []TextEdit{}
+	}
+	return []TextEdit{
+		{
+			Range:   doc.fullRange(),
+			NewText: formatted,
+		},
+	}
+}
@@ -0,0 +1,245 @@
+// Package lsp implements a language server for exo.hcl manifests, speaking
+// the Language Server Protocol over a pair of streams, typically stdio.
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/deref/exo/internal/about"
+)
+
+type Server struct {
+	conn      *conn
+	documents map[string]*document
+	shutdown  bool
+}
+
+func NewServer(r io.Reader, w io.Writer) *Server {
+	return &Server{
+		conn:      newConn(r, w),
+		documents: make(map[string]*document),
+	}
+}
+
+// Serve handles messages until the client sends the exit notification or
+// closes the input stream.
+func (srv *Server) Serve(ctx context.Context) error {
+	for {
+		msg, err := srv.conn.read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		var rpcErr *responseError
+		if errors.As(err, &rpcErr) {
+			// The ID of a malformed request is unknown, so is reported as null.
+			nullID := json.RawMessage("null")
+			if err := srv.conn.write(&message{
+				ID:    &nullID,
+				Error: rpcErr,
+			}); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if msg.Method == "exit" {
+			if !srv.shutdown {
+				return errors.New("exit before shutdown")
+			}
+			return nil
+		}
+
+		result, err := srv.handleRecover(ctx, msg)
+		if msg.isNotification() {
+			continue
+		}
+		res := &message{
+			ID:     msg.ID,
+			Result: result,
+		}
+		if err != nil {
+			if !errors.As(err, &rpcErr) {
+				rpcErr = &responseError{
+					Code:    codeInternalError,
+					Message: err.Error(),
+				}
+			}
+			res.Result = nil
+			res.Error = rpcErr
+		} else if result == nil {
+			res.Result = json.RawMessage("null")
+		}
+		if err := srv.conn.write(res); err != nil {
+			return err
+		}
+	}
+}
+
+// handleRecover is handle, but converts a panic into an internal error, so
+// that one bad message does not stop the server.
+func (srv *Server) handleRecover(ctx context.Context, msg *message) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = nil
+			err = &responseError{
+				Code:    codeInternalError,
+				Message: fmt.Sprintf("internal error handling %s: %v", msg.Method, r),
+			}
+		}
+	}()
+	return srv.handle(ctx, msg)
+}
+
+func (srv *Server) handle(ctx context.Context, msg *message) (interface{}, error) {
+	switch msg.Method {
+	case "initialize":
+		return &InitializeResult{
+			Capabilities: ServerCapabilities{
+				TextDocumentSync: TextDocumentSyncOptions{
+					OpenClose:         true,
+					Change:            textDocumentSyncKindFull,
+					WillSaveWaitUntil: true,
+				},
+				CompletionProvider:         &CompletionOptions{},
+				HoverProvider:              true,
+				DefinitionProvider:         true,
+				DocumentFormattingProvider: true,
+			},
+			ServerInfo: ServerInfo{
+				Name:    "exo",
+				Version: about.Version,
+			},
+		}, nil
+
+	case "initialized":
+		return nil, nil
+
+	case "shutdown":
+		srv.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		return nil, srv.update(ctx, params.TextDocument.URI, params.TextDocument.Text)
+
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		if len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+		text := params.ContentChanges[len(params.ContentChanges)-1].Text
+		return nil, srv.update(ctx, params.TextDocument.URI, text)
+
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		delete(srv.documents, params.TextDocument.URI)
+		// Clear diagnostics, since the client no longer has anywhere to show them.
+		return nil, srv.conn.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{
+			URI:         params.TextDocument.URI,
+			Diagnostics: []Diagnostic{},
+		})
+
+	case "textDocument/completion":
+		var params TextDocumentPositionParams
+		doc, err := srv.documentParams(msg, &params, &params.TextDocument)
+		if err != nil {
+			return nil, err
+		}
+		return doc.completions(params.Position), nil
+
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		doc, err := srv.documentParams(msg, &params, &params.TextDocument)
+		if err != nil {
+			return nil, err
+		}
+		if hover := doc.hover(params.Position); hover != nil {
+			return hover, nil
+		}
+		return nil, nil
+
+	case "textDocument/definition":
+		var params TextDocumentPositionParams
+		doc, err := srv.documentParams(msg, &params, &params.TextDocument)
+		if err != nil {
+			return nil, err
+		}
+		if loc := doc.definition(params.Position); loc != nil {
+			return loc, nil
+		}
+		return nil, nil
+
+	case "textDocument/formatting":
+		var params DocumentFormattingParams
+		doc, err := srv.documentParams(msg, &params, &params.TextDocument)
+		if err != nil {
+			return nil, err
+		}
+		return doc.formatting(), nil
+
+	case "textDocument/willSaveWaitUntil":
+		var params WillSaveTextDocumentParams
+		doc, err := srv.documentParams(msg, &params, &params.TextDocument)
+		if err != nil {
+			return nil, err
+		}
+		return doc.formatting(), nil
+
+	default:
+		return nil, &responseError{
+			Code:    codeMethodNotFound,
+			Message: fmt.Sprintf("method not found: %s", msg.Method),
+		}
+	}
+}
+
+// update re-analyzes a document and publishes its diagnostics.
+func (srv *Server) update(ctx context.Context, uri string, text string) error {
+	doc := newDocument(ctx, uri, text)
+	srv.documents[uri] = doc
+	return srv.conn.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: doc.lspDiagnostics(),
+	})
+}
+
+func unmarshalParams(msg *message, params interface{}) error {
+	if err := json.Unmarshal(msg.Params, params); err != nil {
+		return &responseError{
+			Code:    codeInvalidParams,
+			Message: err.Error(),
+		}
+	}
+	return nil
+}
+
+// documentParams unmarshals the parameters of a request about an open
+// document, and returns that document.
+func (srv *Server) documentParams(msg *message, params interface{}, id *TextDocumentIdentifier) (*document, error) {
+	if err := unmarshalParams(msg, params); err != nil {
+		return nil, err
+	}
+	doc := srv.documents[id.URI]
+	if doc == nil {
+		return nil, &responseError{
+			Code:    codeInvalidParams,
+			Message: fmt.Sprintf("document not open: %s", id.URI),
+		}
+	}
+	return doc, nil
+}
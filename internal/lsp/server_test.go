@@ -0,0 +1,259 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/deref/exo/internal/manifest/exohcl"
+	"github.com/stretchr/testify/assert"
+)
+
+const testURI = "file:///project/exo.hcl"
+
+const testManifest = `exo = "0.1"
+components {
+  process "web" {
+    progam = "web"
+    _ {
+      depends_on = ["db"]
+    }
+  }
+  container "db" {
+    image = "postgres"
+  }
+}
+`
+
+// session runs a server over a scripted sequence of client messages, and
+// returns every message that the server sent.
+func session(t *testing.T, messages ...interface{}) []map[string]interface{} {
+	var in bytes.Buffer
+	for _, msg := range messages {
+		bs, err := json.Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(bs), bs)
+	}
+	var out bytes.Buffer
+	if err := NewServer(&in, &out).Serve(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Messages are decoded generically, so that null results are preserved.
+	var res []map[string]interface{}
+	r := bufio.NewReader(&out)
+	for {
+		header, err := r.ReadString('\n')
+		if err == io.EOF {
+			return res
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		var length int
+		if _, err := fmt.Sscanf(header, "Content-Length: %d", &length); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.ReadString('\n'); err != nil {
+			t.Fatal(err)
+		}
+		bs := make([]byte, length)
+		if _, err := io.ReadFull(r, bs); err != nil {
+			t.Fatal(err)
+		}
+		var decoded map[string]interface{}
+		if err := json.Unmarshal(bs, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		res = append(res, decoded)
+	}
+}
+
+func request(id int, method string, params interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"method":  method,
+		"params":  params,
+	}
+}
+
+func notification(method string, params interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	}
+}
+
+func didOpen(text string) map[string]interface{} {
+	return notification("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{
+			"uri":        testURI,
+			"languageId": "hcl",
+			"version":    1,
+			"text":       text,
+		},
+	})
+}
+
+func at(line, character int) map[string]interface{} {
+	return map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": testURI},
+		"position":     map[string]interface{}{"line": line, "character": character},
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	out := session(t, didOpen(testManifest))
+	if assert.Len(t, out, 1) {
+		assert.Equal(t, "textDocument/publishDiagnostics", out[0]["method"])
+		diags := out[0]["params"].(map[string]interface{})["diagnostics"].([]interface{})
+		if assert.Len(t, diags, 1) {
+			diag := diags[0].(map[string]interface{})
+			assert.Equal(t, map[string]interface{}{
+				"start": map[string]interface{}{"line": 3.0, "character": 4.0},
+				"end":   map[string]interface{}{"line": 3.0, "character": 10.0},
+			}, diag["range"])
+			assert.Contains(t, diag["message"], `Did you mean "program"?`)
+		}
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	labels := func(line, character int) []string {
+		out := session(t, didOpen(testManifest), request(1, "textDocument/completion", at(line, character)))
+		var res []string
+		for _, item := range out[1]["result"].([]interface{}) {
+			res = append(res, item.(map[string]interface{})["label"].(string))
+		}
+		return res
+	}
+	assert.Contains(t, labels(1, 0), "components")
+	assert.Contains(t, labels(2, 0), "container")
+	assert.Contains(t, labels(4, 0), "program")
+	assert.Contains(t, labels(4, 0), "_")
+	assert.Contains(t, labels(10, 0), "mem_limit")
+	assert.Equal(t, []string{"depends_on", "labels", "start_policy"}, labels(5, 0))
+}
+
+func TestHover(t *testing.T) {
+	hover := func(line, character int) interface{} {
+		out := session(t, didOpen(testManifest), request(1, "textDocument/hover", at(line, character)))
+		result, _ := out[1]["result"].(map[string]interface{})
+		if result == nil {
+			return nil
+		}
+		return result["contents"].(map[string]interface{})["value"]
+	}
+	assert.Contains(t, hover(2, 4), "local process")
+	assert.Contains(t, hover(9, 5), "image")
+	assert.Nil(t, hover(3, 5))
+}
+
+func TestDefinition(t *testing.T) {
+	out := session(t, didOpen(testManifest), request(1, "textDocument/definition", at(5, 21)))
+	assert.Equal(t, map[string]interface{}{
+		"uri": testURI,
+		"range": map[string]interface{}{
+			"start": map[string]interface{}{"line": 8.0, "character": 12.0},
+			"end":   map[string]interface{}{"line": 8.0, "character": 16.0},
+		},
+	}, out[1]["result"])
+}
+
+func TestFormatting(t *testing.T) {
+	out := session(t,
+		didOpen("exo = \"0.1\"\ncomponents {\nprocess \"web\" {\nprogram = \"web\"\n}\n}\n"),
+		request(1, "textDocument/formatting", map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": testURI},
+		}),
+	)
+	edits := out[1]["result"].([]interface{})
+	if assert.Len(t, edits, 1) {
+		edit := edits[0].(map[string]interface{})
+		assert.Equal(t, "exo = \"0.1\"\ncomponents {\n  process \"web\" {\n    program = \"web\"\n  }\n}\n", edit["newText"])
+	}
+}
+
+func TestFormattingPreservesComments(t *testing.T) {
+	out := session(t,
+		didOpen("exo = \"0.1\"\n# Services.\ncomponents {\nprocess \"web\" {\nprogram = \"web\" // The server.\n}\n}\n"),
+		request(1, "textDocument/formatting", map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": testURI},
+		}),
+	)
+	edits := out[1]["result"].([]interface{})
+	if assert.Len(t, edits, 1) {
+		edit := edits[0].(map[string]interface{})
+		assert.Equal(t, "exo = \"0.1\"\n# Services.\ncomponents {\n  process \"web\" {\n    program = \"web\" // The server.\n  }\n}\n", edit["newText"])
+	}
+}
+
+func TestFormattingSyntaxError(t *testing.T) {
+	out := session(t,
+		didOpen("exo = \"0.1\"\ncomponents {\nprocess \"web\" {\n"),
+		request(1, "textDocument/formatting", map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": testURI},
+		}),
+	)
+	assert.Equal(t, []interface{}{}, out[1]["result"])
+}
+
+func TestLifecycle(t *testing.T) {
+	out := session(t,
+		request(1, "initialize", map[string]interface{}{}),
+		notification("initialized", map[string]interface{}{}),
+		request(2, "unknown/method", nil),
+		request(3, "shutdown", nil),
+		notification("exit", nil),
+		// Ignored, since the server has exited.
+		request(4, "shutdown", nil),
+	)
+	if assert.Len(t, out, 3) {
+		capabilities := out[0]["result"].(map[string]interface{})["capabilities"].(map[string]interface{})
+		assert.Equal(t, true, capabilities["hoverProvider"])
+		assert.Equal(t, -32601.0, out[1]["error"].(map[string]interface{})["code"])
+		assert.Equal(t, 3.0, out[2]["id"])
+		assert.Contains(t, out[2], "result")
+	}
+}
+
+func TestAnalysisPanic(t *testing.T) {
+	defer func(orig func(*exohcl.AnalysisContext, *exohcl.Manifest)) {
+		validateManifest = orig
+	}(validateManifest)
+	validateManifest = func(*exohcl.AnalysisContext, *exohcl.Manifest) {
+		panic("boom")
+	}
+
+	out := session(t, didOpen(testManifest), request(1, "textDocument/hover", at(2, 4)))
+	if assert.Len(t, out, 2) {
+		diags := out[0]["params"].(map[string]interface{})["diagnostics"].([]interface{})
+		if assert.Len(t, diags, 1) {
+			assert.Contains(t, diags[0].(map[string]interface{})["message"], "boom")
+		}
+		// The server keeps serving the document.
+		assert.Contains(t, out[1], "result")
+	}
+}
+
+func TestHandlePanic(t *testing.T) {
+	// Without a connection, publishing diagnostics panics.
+	srv := &Server{documents: make(map[string]*document)}
+	_, err := srv.handleRecover(context.Background(), &message{
+		Method: "textDocument/didClose",
+		Params: json.RawMessage(`{"textDocument": {"uri": "file:///project/exo.hcl"}}`),
+	})
+	var rpcErr *responseError
+	if assert.True(t, errors.As(err, &rpcErr)) {
+		assert.Equal(t, codeInternalError, rpcErr.Code)
+	}
+}
@@ -0,0 +1,196 @@
+package compose
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/deref/exo/internal/providers/docker/compose"
+	"github.com/deref/exo/internal/util/yamlutil"
+	"gopkg.in/yaml.v3"
+)
+
+// Exporter converts container, network, and volume components back in to a
+// compose project. Where it can, it reverses the renaming performed by
+// Importer, so that the exported project creates resources with the same
+// names when run by docker-compose under the same project name.
+type Exporter struct {
+	// ProjectName is the prefix that was used when the components were imported.
+	ProjectName string
+}
+
+type Component struct {
+	Name      string
+	Type      string
+	Spec      string
+	DependsOn []string
+}
+
+// Export writes a compose file describing components. Components of other
+// types cannot be represented in compose, and are reported as warnings.
+func (exp *Exporter) Export(w io.Writer, components []Component) (warnings []string, err error) {
+	sorted := append([]Component{}, components...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	var project compose.Project
+	componentTypes := make(map[string]string, len(sorted))
+	volumeKeys := make(map[string]string)
+	networkKeys := make(map[string]string)
+	for _, component := range sorted {
+		componentTypes[component.Name] = component.Type
+		switch component.Type {
+		case "volume":
+			var volume compose.Volume
+			if err := yamlutil.UnmarshalString(component.Spec, &volume); err != nil {
+				return nil, fmt.Errorf("parsing volume %q: %w", component.Name, err)
+			}
+			volume.Key = component.Name
+			volumeKeys[volume.Name.Value] = volume.Key
+			if !volume.External.Value && volume.Name.Value == exp.prefixedName(volume.Key, "") {
+				volume.Name = compose.String{}
+			}
+			// Compose rejects a name that is given in both forms.
+			if volume.External.Name.Value == volume.Name.Value {
+				volume.Name = compose.String{}
+			}
+			project.Volumes = append(project.Volumes, volume)
+
+		case "network":
+			var network compose.Network
+			if err := yamlutil.UnmarshalString(component.Spec, &network); err != nil {
+				return nil, fmt.Errorf("parsing network %q: %w", component.Name, err)
+			}
+			network.Key = component.Name
+			networkKeys[network.Name.Value] = network.Key
+			if !network.External.Value {
+				if network.Name.Value == exp.prefixedName(network.Key, "") {
+					network.Name = compose.String{}
+				}
+				if network.Driver.Value == "bridge" {
+					network.Driver = compose.String{}
+				}
+			}
+			if network.External.Name.Value == network.Name.Value {
+				network.Name = compose.String{}
+			}
+			project.Networks = append(project.Networks, network)
+
+		case "container":
+		default:
+			warnings = append(warnings, fmt.Sprintf("%s component %q cannot be exported to compose", component.Type, component.Name))
+		}
+	}
+
+	containerKeys := make(map[string]string)
+	for _, component := range sorted {
+		if component.Type == "container" {
+			containerKeys[exp.prefixedName(component.Name, "1")] = component.Name
+		}
+	}
+
+	for _, component := range sorted {
+		if component.Type != "container" {
+			continue
+		}
+		var service compose.Service
+		if err := yamlutil.UnmarshalString(component.Spec, &service); err != nil {
+			return nil, fmt.Errorf("parsing container %q: %w", component.Name, err)
+		}
+		service.Key = component.Name
+
+		// Compose reserves its own labels.
+		labels := service.Labels.Items[:0]
+		for _, item := range service.Labels.Items {
+			if !strings.HasPrefix(item.Key, "com.docker.compose.") {
+				labels = append(labels, item)
+			}
+		}
+		service.Labels.Items = labels
+		if len(labels) == 0 {
+			service.Labels = compose.Dictionary{}
+		}
+
+		if service.ContainerName.Value == exp.prefixedName(service.Key, "1") {
+			service.ContainerName = compose.String{}
+		}
+
+		for i, network := range service.Networks.Items {
+			key, ok := networkKeys[network.Key]
+			if !ok {
+				continue
+			}
+			service.Networks.Items[i].Key = key
+			if network.ShortForm.Expression != "" {
+				service.Networks.Items[i].ShortForm = compose.MakeString(key)
+			}
+		}
+
+		for i, mount := range service.Volumes {
+			if mount.Type.Value != "volume" {
+				continue
+			}
+			if key, ok := volumeKeys[mount.Source.Value]; ok {
+				service.Volumes[i].Source = compose.MakeString(key)
+			}
+		}
+
+		for i, link := range service.Links {
+			key, ok := containerKeys[link.Service]
+			if !ok {
+				continue
+			}
+			value := key
+			if link.Alias != key {
+				value += ":" + link.Alias
+			}
+			service.Links[i] = compose.Link{
+				String:  compose.MakeString(value),
+				Service: key,
+				Alias:   link.Alias,
+			}
+		}
+
+		// Dependencies declared outside of the spec become depends_on entries.
+		declared := make(map[string]bool, len(service.DependsOn.Items))
+		for _, dep := range service.DependsOn.Items {
+			declared[dep.Service.Value] = true
+		}
+		for _, dep := range component.DependsOn {
+			if componentTypes[dep] != "container" || declared[dep] {
+				continue
+			}
+			declared[dep] = true
+			if service.DependsOn.Style == compose.MapStyle {
+				service.DependsOn.Items = append(service.DependsOn.Items, compose.ServiceDependency{
+					Service: compose.MakeString(dep),
+					ServiceDependencyLongForm: compose.ServiceDependencyLongForm{
+						Condition: compose.MakeString("service_started"),
+					},
+				})
+			} else {
+				service.DependsOn.Style = compose.SeqStyle
+				service.DependsOn.Items = append(service.DependsOn.Items, compose.ServiceDependency{
+					IsShortSyntax: true,
+					Service:       compose.MakeString(dep),
+				})
+			}
+		}
+
+		project.Services = append(project.Services, service)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(project); err != nil {
+		return nil, err
+	}
+	return warnings, enc.Close()
+}
+
+func (exp *Exporter) prefixedName(name string, suffix string) string {
+	imp := &Importer{ProjectName: exp.ProjectName}
+	return imp.prefixedName(name, suffix)
+}
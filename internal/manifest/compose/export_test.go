@@ -0,0 +1,115 @@
+package compose_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/deref/exo/internal/manifest/compose"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExport(t *testing.T) {
+	exp := &compose.Exporter{ProjectName: "testproj"}
+	components := []compose.Component{
+		{
+			Name: "web",
+			Type: "container",
+			Spec: `
+image: nodejs:14
+container_name: testproj_web_1
+labels:
+  com.docker.compose.project: testproj
+  com.docker.compose.service: web
+  team: frontend
+networks:
+  - testproj_default
+volumes:
+  - type: volume
+    source: testproj_data
+    target: /data
+links:
+  - testproj_db_1:database
+`,
+			DependsOn: []string{"default", "data", "db", "worker"},
+		},
+		{
+			Name: "db",
+			Type: "container",
+			Spec: `
+image: postgres:13
+container_name: custom_db
+`,
+		},
+		{
+			Name: "worker",
+			Type: "container",
+			Spec: `
+image: worker
+`,
+		},
+		{
+			Name: "default",
+			Type: "network",
+			Spec: `
+name: testproj_default
+driver: bridge
+`,
+		},
+		{
+			Name: "data",
+			Type: "volume",
+			Spec: `
+name: testproj_data
+`,
+		},
+		{
+			Name: "shared",
+			Type: "volume",
+			Spec: `
+name: shared
+external: true
+`,
+		},
+		{
+			Name: "tool",
+			Type: "process",
+			Spec: `{"program": "tool"}`,
+		},
+	}
+
+	var out strings.Builder
+	warnings, err := exp.Export(&out, components)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{`process component "tool" cannot be exported to compose`}, warnings)
+	assert.Equal(t, `services:
+  db:
+    container_name: custom_db
+    image: postgres:13
+  web:
+    depends_on:
+      - db
+      - worker
+    image: nodejs:14
+    labels:
+      team: frontend
+    links:
+      - db:database
+    networks:
+      - default
+    volumes:
+      - type: volume
+        source: data
+        target: /data
+  worker:
+    image: worker
+networks:
+  default: {}
+volumes:
+  data: {}
+  shared:
+    external: true
+    name: shared
+`, out.String())
+}
@@ -41,6 +41,10 @@ func (imp *Importer) Import(ctx *exohcl.AnalysisContext, bs []byte) *hcl.File {
 	// volumes/components a service references.
 	networkKeyToName := map[string]string{}
 	volumeKeyToName := map[string]string{}
+	secretsByKey := map[string]compose.Secret{}
+	for _, secret := range project.Secrets {
+		secretsByKey[secret.Key] = secret
+	}
 
 	for _, volume := range project.Volumes {
 		name := exohcl.MangleName(volume.Key)
@@ -49,8 +53,16 @@ func (imp *Importer) Import(ctx *exohcl.AnalysisContext, bs []byte) *hcl.File {
 			ctx.AppendDiags(exohcl.NewRenameWarning(volume.Key, name, subject))
 		}
 
+		// External volumes are not namespaced by the project name, since they
+		// are not created by Compose.
 		if volume.Name.Value == "" {
-			volume.Name = compose.MakeString(imp.prefixedName(volume.Key, ""))
+			if volume.External.Name.Value != "" {
+				volume.Name = volume.External.Name
+			} else if volume.External.Value {
+				volume.Name = compose.MakeString(volume.Key)
+			} else {
+				volume.Name = compose.MakeString(imp.prefixedName(volume.Key, ""))
+			}
 		}
 		volumeKeyToName[volume.Key] = volume.Name.Value
 
@@ -73,7 +85,13 @@ func (imp *Importer) Import(ctx *exohcl.AnalysisContext, bs []byte) *hcl.File {
 		// then we should honor that as the docker network name. Otherwise, we should set the name as
 		// `<project_name>_<network_key>`.
 		if network.Name.Value == "" {
-			network.Name = compose.MakeString(imp.prefixedName(network.Key, ""))
+			if network.External.Name.Value != "" {
+				network.Name = network.External.Name
+			} else if network.External.Value {
+				network.Name = compose.MakeString(network.Key)
+			} else {
+				network.Name = compose.MakeString(imp.prefixedName(network.Key, ""))
+			}
 		}
 		networkKeyToName[network.Key] = network.Name.Value
 
@@ -111,6 +129,40 @@ func (imp *Importer) Import(ctx *exohcl.AnalysisContext, bs []byte) *hcl.File {
 			service.ContainerName = compose.MakeString(imp.prefixedName(service.Key, "1"))
 		}
 
+		// Build secrets refer to top-level secret definitions, which do not
+		// otherwise become components. Copy their sources in to the build spec.
+		for i, secret := range service.Build.Secrets {
+			if secret.File.Value != "" || secret.Environment.Value != "" {
+				continue
+			}
+			def, ok := secretsByKey[secret.Source.Value]
+			if !ok {
+				ctx.AppendDiags(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  fmt.Sprintf("unknown secret %q in build of service %q", secret.Source.Value, service.Key),
+				})
+				continue
+			}
+			if def.File.Value == "" && def.Environment.Value == "" {
+				ctx.AppendDiags(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  fmt.Sprintf("secret %q must specify file or environment to be used in a build", secret.Source.Value),
+				})
+				continue
+			}
+			service.Build.Secrets[i].File = def.File
+			service.Build.Secrets[i].Environment = def.Environment
+		}
+
+		if service.Deploy != nil && service.Deploy.Replicas != nil && service.Deploy.Replicas.Value != 1 {
+			var subject *hcl.Range
+			ctx.AppendDiags(exohcl.NewUnsupportedFeatureWarning(
+				"deploy.replicas",
+				fmt.Sprintf("service %q will run as a single container", service.Key),
+				subject,
+			))
+		}
+
 		for _, item := range service.Labels.Items {
 			if strings.HasPrefix(item.Key, "com.docker.compose") {
 				ctx.AppendDiags(&hcl.Diagnostic{
@@ -147,7 +199,7 @@ func (imp *Importer) Import(ctx *exohcl.AnalysisContext, bs []byte) *hcl.File {
 				dependsOn = append(dependsOn, network.Key)
 			}
 			service.Networks.Items = mappedNetworks
-		} else {
+		} else if service.NetworkMode.Value == "" {
 			service.Networks.Items = []compose.ServiceNetwork{
 				{
 					Key:       defaultNetworkName,
@@ -157,6 +209,16 @@ func (imp *Importer) Import(ctx *exohcl.AnalysisContext, bs []byte) *hcl.File {
 			dependsOn = append(dependsOn, "default")
 		}
 
+		// Services that share the namespaces of another service depend on it.
+		for _, mode := range []*compose.String{&service.NetworkMode, &service.PidMode, &service.IPC} {
+			if !strings.HasPrefix(mode.Value, "service:") {
+				continue
+			}
+			target := exohcl.MangleName(strings.TrimPrefix(mode.Value, "service:"))
+			*mode = compose.MakeString("service:" + target)
+			dependsOn = append(dependsOn, target)
+		}
+
 		if len(service.Volumes) > 0 {
 			for i, volumeMount := range service.Volumes {
 				if volumeMount.Type.Value != "volume" {
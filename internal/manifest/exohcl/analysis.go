@@ -9,10 +9,7 @@ import (
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
-	ctyyaml "github.com/zclconf/go-cty-yaml"
 	"github.com/zclconf/go-cty/cty"
-	"github.com/zclconf/go-cty/cty/function"
-	"github.com/zclconf/go-cty/cty/function/stdlib"
 )
 
 type AnalysisContext struct {
@@ -75,16 +72,15 @@ func NewUnsupportedFeatureWarning(featureName, explanation string, subject *hcl.
 	}
 }
 
-var evalCtx = &hcl.EvalContext{
-	Functions: map[string]function.Function{
-		"jsonencode": stdlib.JSONEncodeFunc,
-		"yamlencode": ctyyaml.YAMLEncodeFunc,
-	},
-}
-
-func AnalyzeString(ctx *AnalysisContext, x hcl.Expression) (s string, ok bool) {
+func AnalyzeString(ctx *AnalysisContext, evalCtx *hcl.EvalContext, x hcl.Expression) (s string, ok bool) {
 	v, diags := x.Value(evalCtx)
 	ctx.AppendDiags(diags...)
+	if !v.IsKnown() {
+		// Unknown values follow an error that was already reported, or come
+		// from analysis without an environment, as by the language server.
+		// See makeEnvFunc.
+		return "", false
+	}
 	if v.Type() != cty.String {
 		ctx.AppendDiags(&hcl.Diagnostic{
 			Severity: hcl.DiagError,
@@ -2,23 +2,30 @@ package exohcl
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/deref/exo/internal/manifest/exohcl/hclgen"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
 )
 
 type ComponentSet struct {
 	// Analysis inputs.
-	Blocks hcl.Blocks
+	Blocks      hcl.Blocks
+	EvalContext *hcl.EvalContext
+	Modules     []*Module
 
 	// Analysis outputs.
+	// Components includes the namespaced components of all modules.
 	Components []*Component
 }
 
 func NewComponentSet(m *Manifest) *ComponentSet {
 	return &ComponentSet{
-		Blocks: m.Components,
+		Blocks:      m.Components,
+		EvalContext: m.EvalContext(),
+		Modules:     m.Modules,
 	}
 }
 
@@ -60,26 +67,75 @@ func (cs *ComponentSet) Analyze(ctx *AnalysisContext) {
 			})
 		}
 		for _, componentBlock := range body.Blocks {
-			component := NewComponent(componentBlock)
+			component := NewComponent(componentBlock, cs.EvalContext)
 			component.Analyze(ctx)
 			cs.Components = append(cs.Components, component)
 		}
 	}
+
+	for _, module := range cs.Modules {
+		cs.Components = append(cs.Components, module.Components...)
+	}
+
+	declared := make(map[string]*Component, len(cs.Components))
+	for _, component := range cs.Components {
+		if component.Name == "" {
+			continue
+		}
+		if prev, exists := declared[component.Name]; exists {
+			ctx.AppendDiags(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate component",
+				Detail:   fmt.Sprintf("A component named %q was already declared at %s.", component.Name, prev.Source.DefRange()),
+				Subject:  component.Source.DefRange().Ptr(),
+			})
+			continue
+		}
+		declared[component.Name] = component
+	}
 }
 
 type Component struct {
-	Source *hclsyntax.Block
+	Source      *hclsyntax.Block
+	EvalContext *hcl.EvalContext
+	// Module is the name of the module that included the component, or empty
+	// for components declared directly in the manifest.
+	Module string
 
-	Expansion *hclsyntax.Block
-	Type      string
-	Name      string
-	Spec      string
-	DependsOn []string
+	Expansion   *hclsyntax.Block
+	Type        string
+	Name        string
+	Spec        string
+	DependsOn   []string
+	Labels      map[string]string
+	StartPolicy string
 }
 
-func NewComponent(block *hclsyntax.Block) *Component {
+// Start policies. A component with the manual start policy is expected to be
+// started explicitly, rather than along with the rest of the workspace.
+const (
+	StartPolicyAuto   = "auto"
+	StartPolicyManual = "manual"
+)
+
+// Attributes that may appear in the `_` meta block of a sugared component,
+// such as `process "web" { _ { depends_on = ["db"] } }`. These are copied
+// into the expanded component block, where they may also appear directly.
+var metaAttributes = []string{"depends_on", "labels", "start_policy"}
+
+func isMetaAttribute(name string) bool {
+	for _, meta := range metaAttributes {
+		if name == meta {
+			return true
+		}
+	}
+	return false
+}
+
+func NewComponent(block *hclsyntax.Block, evalCtx *hcl.EvalContext) *Component {
 	return &Component{
-		Source: block,
+		Source:      block,
+		EvalContext: evalCtx,
 	}
 }
 
@@ -95,6 +151,8 @@ func (c *Component) Analyze(ctx *AnalysisContext) {
 			{Name: "type", Required: true},
 			{Name: "spec"},
 			{Name: "depends_on"},
+			{Name: "labels"},
+			{Name: "start_policy"},
 		},
 		Blocks: []hcl.BlockHeaderSchema{
 			{Type: "spec"},
@@ -152,7 +210,8 @@ func (c *Component) Analyze(ctx *AnalysisContext) {
 			})
 		}
 	} else {
-		c.Spec, _ = AnalyzeString(ctx, specAttr.Expr)
+		c.Spec, _ = AnalyzeString(ctx, c.EvalContext, specAttr.Expr)
+		validateSpec(ctx, c.EvalContext, c.Type, specAttr.Expr)
 	}
 
 	depsAttr := content.Attributes["depends_on"]
@@ -166,17 +225,73 @@ func (c *Component) Analyze(ctx *AnalysisContext) {
 				Detail:   fmt.Sprintf("Expected literal array of strings, got %T", depsExpr),
 				Subject:  depsExpr.Range().Ptr(),
 			})
-		}
-		c.DependsOn = make([]string, 0, len(tup.Exprs))
-		for _, elem := range tup.Exprs {
-			dep, diag := parseLiteralString(elem)
-			if diag != nil {
-				ctx.AppendDiags(diag)
-				continue
+		} else {
+			c.DependsOn = make([]string, 0, len(tup.Exprs))
+			for _, elem := range tup.Exprs {
+				dep, diag := parseLiteralString(elem)
+				if diag != nil {
+					ctx.AppendDiags(diag)
+					continue
+				}
+				c.DependsOn = append(c.DependsOn, dep)
 			}
-			c.DependsOn = append(c.DependsOn, dep)
 		}
 	}
+
+	labelsAttr := content.Attributes["labels"]
+	if labelsAttr != nil {
+		c.Labels = analyzeLabels(ctx, c.EvalContext, labelsAttr.Expr)
+	}
+
+	startPolicyAttr := content.Attributes["start_policy"]
+	if startPolicyAttr != nil {
+		policy, ok := AnalyzeString(ctx, c.EvalContext, startPolicyAttr.Expr)
+		switch {
+		case !ok:
+		case policy == StartPolicyAuto || policy == StartPolicyManual:
+			c.StartPolicy = policy
+		default:
+			ctx.AppendDiags(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid start policy",
+				Detail:   fmt.Sprintf("Expected start_policy to be %q or %q, but got %q", StartPolicyAuto, StartPolicyManual, policy),
+				Subject:  startPolicyAttr.Expr.Range().Ptr(),
+			})
+		}
+	}
+}
+
+func analyzeLabels(ctx *AnalysisContext, evalCtx *hcl.EvalContext, x hcl.Expression) map[string]string {
+	v, diags := x.Value(evalCtx)
+	ctx.AppendDiags(diags...)
+	if diags.HasErrors() {
+		return nil
+	}
+	ty := v.Type()
+	if !(ty.IsObjectType() || ty.IsMapType()) || v.IsNull() {
+		ctx.AppendDiags(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Expected object",
+			Detail:   fmt.Sprintf("Expected labels to be an object of strings, but got %s", ty.FriendlyName()),
+			Subject:  x.Range().Ptr(),
+		})
+		return nil
+	}
+	labels := make(map[string]string)
+	for it := v.ElementIterator(); it.Next(); {
+		k, elem := it.Element()
+		if elem.Type() != cty.String || elem.IsNull() {
+			ctx.AppendDiags(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Expected string",
+				Detail:   fmt.Sprintf("Expected label %q to be a string, but got %s", k.AsString(), elem.Type().FriendlyName()),
+				Subject:  x.Range().Ptr(),
+			})
+			continue
+		}
+		labels[k.AsString()] = elem.AsString()
+	}
+	return labels
 }
 
 func expandComponent(ctx *AnalysisContext, block *hclsyntax.Block) *hclsyntax.Block {
@@ -198,58 +313,61 @@ func expandComponent(ctx *AnalysisContext, block *hclsyntax.Block) *hclsyntax.Bl
 		})
 		return nil
 	}
+	meta := make(hclsyntax.Attributes)
 	for _, subblock := range body.Blocks {
 		switch subblock.Type {
 		case "_":
-			// TODO: copy content of "meta" blocks in to the expanded output.
+			expandMetaBlock(ctx, subblock, meta)
 		default:
 			ctx.AppendDiags(&hcl.Diagnostic{
 				Severity: hcl.DiagError,
 				Summary:  "Unexpected block",
 				Detail:   fmt.Sprintf(`Unexpected %q block in %q component.`, subblock.Type, block.Type),
-				Subject:  body.Blocks[0].DefRange().Ptr(),
+				Subject:  subblock.DefRange().Ptr(),
 			})
 		}
 	}
-	attrs := body.Attributes
+	attrs := hclgen.AttributesFromSytnax(body.Attributes)
 	specItems := make([]hclsyntax.ObjectConsItem, 0, len(attrs))
 	for _, attr := range attrs {
 		specItems = append(specItems, hclsyntax.ObjectConsItem{
-			KeyExpr:   hclgen.NewObjStringKey(attr.Name, attr.Range()),
+			KeyExpr:   hclgen.NewObjStringKey(attr.Name, attr.NameRange),
 			ValueExpr: attr.Expr,
 		})
 	}
-	// sort.Sort(specItemsSorter{specItems}) // XXX sort specItems by attr range?
-	// XXX search for "_" blocks with depends_on, etc. and other meta properties.
+	attributes := hclsyntax.Attributes{
+		"type": &hclsyntax.Attribute{
+			Name:        "type",
+			Expr:        hclgen.NewStringLiteral(block.Type, block.TypeRange),
+			SrcRange:    block.TypeRange,
+			NameRange:   block.TypeRange,
+			EqualsRange: block.TypeRange,
+		},
+		"spec": &hclsyntax.Attribute{
+			Name: "spec",
+			Expr: &hclsyntax.FunctionCallExpr{
+				Name: encodefunc,
+				Args: []hclsyntax.Expression{
+					&hclsyntax.ObjectConsExpr{
+						Items:     specItems,
+						SrcRange:  body.SrcRange,
+						OpenRange: block.OpenBraceRange,
+					},
+				},
+			},
+			SrcRange:    body.SrcRange,
+			NameRange:   block.TypeRange,
+			EqualsRange: block.TypeRange,
+		},
+	}
+	for name, attr := range meta {
+		attributes[name] = attr
+	}
 	return &hclsyntax.Block{
 		Type:   "component",
 		Labels: block.Labels,
 		Body: &hclsyntax.Body{
-			Attributes: hclsyntax.Attributes{
-				"type": &hclsyntax.Attribute{
-					Name:        "type",
-					Expr:        hclgen.NewStringLiteral(block.Type, block.TypeRange),
-					SrcRange:    block.TypeRange,
-					NameRange:   block.TypeRange,
-					EqualsRange: block.TypeRange,
-				},
-				"spec": &hclsyntax.Attribute{
-					Name: "spec",
-					Expr: &hclsyntax.FunctionCallExpr{
-						Name: encodefunc,
-						Args: []hclsyntax.Expression{
-							&hclsyntax.ObjectConsExpr{
-								Items:     specItems,
-								SrcRange:  body.SrcRange,
-								OpenRange: block.OpenBraceRange,
-							},
-						},
-					},
-					SrcRange:    body.SrcRange,
-					NameRange:   block.TypeRange,
-					EqualsRange: block.TypeRange,
-				},
-			},
+			Attributes: attributes,
 		},
 		TypeRange:       block.TypeRange,
 		LabelRanges:     block.LabelRanges,
@@ -257,3 +375,44 @@ func expandComponent(ctx *AnalysisContext, block *hclsyntax.Block) *hclsyntax.Bl
 		CloseBraceRange: block.CloseBraceRange,
 	}
 }
+
+// expandMetaBlock collects the attributes of a `_` meta block in to meta.
+func expandMetaBlock(ctx *AnalysisContext, block *hclsyntax.Block, meta hclsyntax.Attributes) {
+	if len(block.Labels) > 0 {
+		ctx.AppendDiags(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Unexpected label on meta block",
+			Detail:   fmt.Sprintf("A meta block expects no labels, but has %d", len(block.Labels)),
+			Subject:  &block.LabelRanges[0],
+		})
+	}
+	for _, child := range block.Body.Blocks {
+		ctx.AppendDiags(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Unexpected block",
+			Detail:   fmt.Sprintf("Unexpected %q block in meta block.", child.Type),
+			Subject:  child.DefRange().Ptr(),
+		})
+	}
+	for _, attr := range hclgen.AttributesFromSytnax(block.Body.Attributes) {
+		if !isMetaAttribute(attr.Name) {
+			ctx.AppendDiags(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unsupported meta attribute",
+				Detail:   fmt.Sprintf("Meta blocks support only the following attributes: %s.", strings.Join(metaAttributes, ", ")),
+				Subject:  &attr.NameRange,
+			})
+			continue
+		}
+		if prev, exists := meta[attr.Name]; exists {
+			ctx.AppendDiags(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate meta attribute",
+				Detail:   fmt.Sprintf("The %q attribute was already defined at %s.", attr.Name, prev.NameRange),
+				Subject:  &attr.NameRange,
+			})
+			continue
+		}
+		meta[attr.Name] = attr
+	}
+}
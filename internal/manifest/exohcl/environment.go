@@ -10,9 +10,15 @@ import (
 	"github.com/zclconf/go-cty/cty"
 )
 
+// Environment is the environment block of a manifest, merged with those of
+// the manifests it includes as modules. Variables of the including manifest
+// take precedence over those of its modules. Modules that set a variable to
+// different values conflict.
 type Environment struct {
 	// Analysis inputs.
-	Blocks hcl.Blocks
+	Blocks      hcl.Blocks
+	EvalContext *hcl.EvalContext
+	Modules     []*Environment
 
 	// Analysis outputs.
 	Attributes []*hclgen.Attribute
@@ -21,13 +27,21 @@ type Environment struct {
 }
 
 func NewEnvironment(m *Manifest) *Environment {
-	return &Environment{
-		Blocks: m.Environment,
+	env := &Environment{
+		Blocks:      m.Environment,
+		EvalContext: m.EvalContext(),
 	}
+	for _, mod := range m.Modules {
+		if mod.Manifest != nil && mod.Manifest.Content != nil {
+			env.Modules = append(env.Modules, NewEnvironment(mod.Manifest))
+		}
+	}
+	return env
 }
 
 func (env *Environment) Analyze(ctx *AnalysisContext) {
 	env.Variables = make(map[string]string)
+	env.analyzeModules(ctx)
 
 	if len(env.Blocks) > 1 {
 		ctx.AppendDiags(&hcl.Diagnostic{
@@ -52,12 +66,18 @@ func (env *Environment) Analyze(ctx *AnalysisContext) {
 
 		for _, attr := range body.Attributes {
 			// TODO: Validate attribute name.
-			v, diags := attr.Expr.Value(evalCtx)
+			v, diags := attr.Expr.Value(env.EvalContext)
 			ctx.AppendDiags(diags...)
 			if diags.HasErrors() {
 				continue
 			}
-			if v.Type() != cty.String {
+			if !v.IsKnown() {
+				// Analysis without an environment, as by the language server, cannot
+				// know the values of env calls. See makeEnvFunc.
+				env.Attributes = append(env.Attributes, attr)
+				continue
+			}
+			if v.Type() != cty.String || v.IsNull() {
 				ctx.AppendDiags(&hcl.Diagnostic{
 					Severity: hcl.DiagError,
 					Summary:  "expected environment variable to be a string",
@@ -65,6 +85,7 @@ func (env *Environment) Analyze(ctx *AnalysisContext) {
 					Subject:  attr.Expr.Range().Ptr(),
 					Context:  &attr.SrcRange,
 				})
+				continue
 			}
 			env.Attributes = append(env.Attributes, attr)
 			env.Variables[attr.Name] = v.AsString()
@@ -73,7 +94,7 @@ func (env *Environment) Analyze(ctx *AnalysisContext) {
 		for _, child := range body.Blocks {
 			switch child.Type {
 			case "secrets":
-				secrets := NewSecrets(child)
+				secrets := NewSecrets(child, env.EvalContext)
 				secrets.Analyze(ctx)
 				env.Secrets = append(env.Secrets, secrets)
 			default:
@@ -89,6 +110,51 @@ func (env *Environment) Analyze(ctx *AnalysisContext) {
 	}
 }
 
+func (env *Environment) analyzeModules(ctx *AnalysisContext) {
+	ranges := make(map[string]hcl.Range)
+	for _, mod := range env.Modules {
+		mod.Analyze(ctx)
+		for _, attr := range mod.allAttributes() {
+			name := attr.Name
+			value, known := mod.Variables[name]
+			if !known {
+				continue
+			}
+			if prev, exists := env.Variables[name]; exists && prev != value {
+				prevRange := ranges[name]
+				ctx.AppendDiags(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Conflicting environment variable",
+					Detail:   fmt.Sprintf("Environment variable %q was already set to a different value by a module at %s.", name, prevRange),
+					Subject:  attr.SrcRange.Ptr(),
+				})
+				continue
+			}
+			env.Variables[name] = value
+			ranges[name] = attr.SrcRange
+		}
+	}
+}
+
+// allAttributes returns the attributes that set the variables of this
+// environment, including those of modules.
+func (env *Environment) allAttributes() []*hclgen.Attribute {
+	var attrs []*hclgen.Attribute
+	for _, mod := range env.Modules {
+		attrs = append(attrs, mod.allAttributes()...)
+	}
+	return append(attrs, env.Attributes...)
+}
+
+// AllSecrets returns the secrets of this environment and of its modules.
+func (env *Environment) AllSecrets() []*Secrets {
+	var secrets []*Secrets
+	for _, mod := range env.Modules {
+		secrets = append(secrets, mod.AllSecrets()...)
+	}
+	return append(secrets, env.Secrets...)
+}
+
 func (env *Environment) EnvironmentSource() string {
 	return "manifest"
 }
@@ -0,0 +1,82 @@
+package exohcl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandMetaBlock(t *testing.T) {
+	ctx := context.Background()
+	assertRewrite(t, Expand{Context: ctx}, `
+exo = "0.1"
+components {
+  process "web" {
+    program = "web"
+    _ {
+      depends_on = ["db"]
+      labels = { team = "api" }
+      start_policy = "manual"
+    }
+  }
+}
+`, `
+exo = "0.1"
+components {
+  component "web" {
+    type         = "process"
+    spec         = jsonencode({ program = "web" })
+    depends_on   = ["db"]
+    labels       = { team = "api" }
+    start_policy = "manual"
+  }
+}
+`)
+
+	components, diags := analyzeComponents(t, "exo.hcl", `
+exo = "0.1"
+components {
+  container "web" {
+    image = "nginx"
+    _ {
+      depends_on = ["db"]
+    }
+    _ {
+      labels = { team = "api" }
+    }
+  }
+}
+`)
+	assert.Empty(t, diags)
+	if assert.Len(t, components, 1) {
+		c := components[0]
+		assert.Equal(t, []string{"db"}, c.DependsOn)
+		assert.Equal(t, map[string]string{"team": "api"}, c.Labels)
+		assert.Equal(t, "", c.StartPolicy)
+	}
+}
+
+func TestExpandMetaBlockErrors(t *testing.T) {
+	check := func(meta string, summary string) {
+		_, diags := analyzeComponents(t, "exo.hcl", `
+exo = "0.1"
+components {
+  process "web" {
+    program = "web"
+`+meta+`
+  }
+}
+`)
+		if assert.True(t, diags.HasErrors(), "%s", meta) {
+			assert.Equal(t, summary, diags[0].Summary)
+		}
+	}
+	check(`_ { restart = "always" }`, "Unsupported meta attribute")
+	check(`
+_ { depends_on = ["a"] }
+_ { depends_on = ["b"] }
+`, "Duplicate meta attribute")
+	check(`_ { start_policy = "sometimes" }`, "Invalid start policy")
+	check(`_ { labels = { n = 1 } }`, "Expected string")
+}
@@ -0,0 +1,97 @@
+package exohcl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/deref/exo/internal/util/pathutil"
+)
+
+// Files controls access to the files that analysis reads on behalf of a
+// manifest, such as those read by the file function. A nil *Files permits
+// reading any file and does not record reads.
+type Files struct {
+	// Root, if not empty, is the directory that files must be within, such as
+	// the workspace root when manifests are analyzed by the daemon. Symbolic
+	// links are followed before checking.
+	Root string
+
+	mx   sync.Mutex
+	read map[string]bool
+}
+
+// Resolve returns the absolute path of path, which may be relative to
+// baseDir, or an error if the path is outside of the root.
+func (files *Files) Resolve(baseDir string, path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, path)
+	}
+	path = absPath(path)
+	if files == nil || files.Root == "" {
+		return path, nil
+	}
+	root := absPath(files.Root)
+	within := pathutil.HasFilePathPrefix(path, root)
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		// Also check the link target, so that links cannot escape the root.
+		if resolvedRoot, err := filepath.EvalSymlinks(root); err == nil {
+			root = resolvedRoot
+		}
+		within = pathutil.HasFilePathPrefix(resolved, root)
+	}
+	if !within {
+		return "", fmt.Errorf("cannot read %s outside of workspace root", path)
+	}
+	return path, nil
+}
+
+// ReadFile reads a file whose path may be relative to baseDir. The path is
+// recorded, even if reading fails, so that files that do not exist yet may be
+// watched for changes too.
+func (files *Files) ReadFile(baseDir string, path string) ([]byte, error) {
+	path, err := files.Resolve(baseDir, path)
+	if err != nil {
+		return nil, err
+	}
+	if files != nil {
+		files.mx.Lock()
+		if files.read == nil {
+			files.read = make(map[string]bool)
+		}
+		files.read[path] = true
+		files.mx.Unlock()
+	}
+	return ioutil.ReadFile(path)
+}
+
+// Read returns the sorted absolute paths of the files that have been read,
+// such as module sources and the files read by the file function.
+func (files *Files) Read() []string {
+	if files == nil {
+		return nil
+	}
+	files.mx.Lock()
+	defer files.mx.Unlock()
+	res := make([]string, 0, len(files.read))
+	for path := range files.read {
+		res = append(res, path)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// baseDir returns the directory against which the relative paths of a
+// manifest with the given filename are resolved. Manifests without a file,
+// such as those sent as contents to the daemon, use the root.
+func (files *Files) baseDir(filename string) string {
+	if filename == "" || filename == "/dev/stdin" {
+		if files != nil && files.Root != "" {
+			return files.Root
+		}
+		return "."
+	}
+	return filepath.Dir(filename)
+}
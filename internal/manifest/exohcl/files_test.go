@@ -0,0 +1,134 @@
+package exohcl
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilesResolve(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exohcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	root := filepath.Join(dir, "ws")
+	assert.NoError(t, os.Mkdir(root, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "secret"), []byte("hunter2"), 0644))
+	assert.NoError(t, os.Symlink(filepath.Join(dir, "secret"), filepath.Join(root, "link")))
+
+	files := &Files{Root: root}
+	path, err := files.Resolve(root, "config/app.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "config", "app.yaml"), path)
+
+	for _, path := range []string{"../secret", filepath.Join(dir, "secret"), "link"} {
+		_, err := files.Resolve(root, path)
+		assert.Error(t, err, path)
+	}
+
+	// Without a root, any file may be read.
+	var unrestricted *Files
+	_, err = unrestricted.Resolve(root, "../secret")
+	assert.NoError(t, err)
+}
+
+func TestFileFunctionOutsideRoot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exohcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "motd.txt"), []byte("hello"), 0644))
+
+	analyze := func(src string) ([]*Component, hcl.Diagnostics) {
+		file, diags := hclsyntax.ParseConfig([]byte(src), "", hcl.InitialPos)
+		if diags.HasErrors() {
+			t.Fatal(diags)
+		}
+		// Manifests sent without a filename resolve paths against the root.
+		m := NewManifest("", file)
+		m.Env = map[string]string{}
+		m.Files = &Files{Root: dir}
+		ctx := context.Background()
+		diags = Analyze(ctx, m)
+		cs := NewComponentSet(m)
+		diags = append(diags, Analyze(ctx, cs)...)
+		return cs.Components, diags
+	}
+
+	components, diags := analyze(`
+exo = "0.1"
+components {
+  process "web" {
+    program = "web"
+    environment = { MOTD = file("motd.txt") }
+  }
+}
+`)
+	assert.Empty(t, diags)
+	if assert.Len(t, components, 1) {
+		assert.Equal(t, `{"environment":{"MOTD":"hello"},"program":"web"}`, components[0].Spec)
+	}
+
+	_, diags = analyze(`
+exo = "0.1"
+components {
+  process "web" {
+    program = "web"
+    environment = { PASSWD = file("/etc/passwd") }
+  }
+}
+`)
+	if assert.True(t, diags.HasErrors()) {
+		assert.Contains(t, diags.Error(), "outside of workspace root")
+	}
+}
+
+func TestFilesRead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exohcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "api"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "api", "exo.hcl"), []byte(`
+exo = "0.1"
+components {
+  process "api" {
+    program = "api"
+    environment = { MOTD = file("motd.txt") }
+  }
+}
+`), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "api", "motd.txt"), []byte("hello"), 0644))
+
+	file, diags := hclsyntax.ParseConfig([]byte(`
+exo = "0.1"
+module "api" {
+  source = "api"
+}
+`), filepath.Join(dir, "exo.hcl"), hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	m := NewManifest(filepath.Join(dir, "exo.hcl"), file)
+	m.Env = map[string]string{}
+	m.Files = &Files{Root: dir}
+	ctx := context.Background()
+	diags = Analyze(ctx, m)
+	diags = append(diags, Analyze(ctx, NewComponentSet(m))...)
+	assert.Empty(t, diags)
+
+	// Both the module source and the file read by the module are recorded.
+	assert.Equal(t, []string{
+		filepath.Join(dir, "api", "exo.hcl"),
+		filepath.Join(dir, "api", "motd.txt"),
+	}, m.Files.Read())
+}
@@ -0,0 +1,208 @@
+package exohcl
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	ctyyaml "github.com/zclconf/go-cty-yaml"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// newEvalContext returns the context for evaluating manifest expressions.
+// Relative paths given to file functions are resolved against baseDir and
+// read through files, and the env function reads from env.
+func newEvalContext(baseDir string, files *Files, env map[string]string, variables map[string]cty.Value) *hcl.EvalContext {
+	funcs := makeFunctions(baseDir, files, env)
+	funcs["templatefile"] = makeTemplateFileFunc(baseDir, files, makeFunctions(baseDir, files, env))
+	return &hcl.EvalContext{
+		Variables: variables,
+		Functions: funcs,
+	}
+}
+
+// makeFunctions returns all functions available to manifest expressions,
+// except for templatefile, which is excluded to prevent templates from
+// recursively rendering other templates.
+func makeFunctions(baseDir string, files *Files, env map[string]string) map[string]function.Function {
+	return map[string]function.Function{
+		// Encoding.
+		"jsondecode": stdlib.JSONDecodeFunc,
+		"jsonencode": stdlib.JSONEncodeFunc,
+		"yamldecode": ctyyaml.YAMLDecodeFunc,
+		"yamlencode": ctyyaml.YAMLEncodeFunc,
+		"csvdecode":  stdlib.CSVDecodeFunc,
+
+		// Strings.
+		"chomp":        stdlib.ChompFunc,
+		"format":       stdlib.FormatFunc,
+		"formatlist":   stdlib.FormatListFunc,
+		"indent":       stdlib.IndentFunc,
+		"join":         stdlib.JoinFunc,
+		"lower":        stdlib.LowerFunc,
+		"regex":        stdlib.RegexFunc,
+		"regexall":     stdlib.RegexAllFunc,
+		"regexreplace": stdlib.RegexReplaceFunc,
+		"replace":      stdlib.ReplaceFunc,
+		"split":        stdlib.SplitFunc,
+		"strlen":       stdlib.StrlenFunc,
+		"strrev":       stdlib.ReverseFunc,
+		"substr":       stdlib.SubstrFunc,
+		"title":        stdlib.TitleFunc,
+		"trim":         stdlib.TrimFunc,
+		"trimprefix":   stdlib.TrimPrefixFunc,
+		"trimspace":    stdlib.TrimSpaceFunc,
+		"trimsuffix":   stdlib.TrimSuffixFunc,
+		"upper":        stdlib.UpperFunc,
+
+		// Collections.
+		"chunklist":       stdlib.ChunklistFunc,
+		"coalesce":        stdlib.CoalesceFunc,
+		"coalescelist":    stdlib.CoalesceListFunc,
+		"compact":         stdlib.CompactFunc,
+		"concat":          stdlib.ConcatFunc,
+		"contains":        stdlib.ContainsFunc,
+		"distinct":        stdlib.DistinctFunc,
+		"element":         stdlib.ElementFunc,
+		"flatten":         stdlib.FlattenFunc,
+		"index":           stdlib.IndexFunc,
+		"keys":            stdlib.KeysFunc,
+		"length":          stdlib.LengthFunc,
+		"lookup":          stdlib.LookupFunc,
+		"merge":           stdlib.MergeFunc,
+		"range":           stdlib.RangeFunc,
+		"reverse":         stdlib.ReverseListFunc,
+		"setintersection": stdlib.SetIntersectionFunc,
+		"setproduct":      stdlib.SetProductFunc,
+		"setsubtract":     stdlib.SetSubtractFunc,
+		"setunion":        stdlib.SetUnionFunc,
+		"slice":           stdlib.SliceFunc,
+		"sort":            stdlib.SortFunc,
+		"values":          stdlib.ValuesFunc,
+		"zipmap":          stdlib.ZipmapFunc,
+
+		// Numbers.
+		"abs":      stdlib.AbsoluteFunc,
+		"ceil":     stdlib.CeilFunc,
+		"floor":    stdlib.FloorFunc,
+		"max":      stdlib.MaxFunc,
+		"min":      stdlib.MinFunc,
+		"parseint": stdlib.ParseIntFunc,
+
+		// Environment and filesystem.
+		"env":  makeEnvFunc(env),
+		"file": makeFileFunc(baseDir, files),
+	}
+}
+
+// makeEnvFunc returns a function that looks up a variable in env. If the
+// variable is not set, the optional second argument is returned instead, or
+// an error if there is no second argument. If env is nil, as when a manifest
+// is analyzed without a workspace, all variables are unknown.
+func makeEnvFunc(env map[string]string) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "name",
+				Type: cty.String,
+			},
+		},
+		VarParam: &function.Parameter{
+			Name: "default",
+			Type: cty.String,
+		},
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			if len(args) > 2 {
+				return cty.UnknownVal(cty.String), function.NewArgErrorf(2, "expected at most one default")
+			}
+			if env == nil {
+				return cty.UnknownVal(cty.String), nil
+			}
+			name := args[0].AsString()
+			if value, ok := env[name]; ok {
+				return cty.StringVal(value), nil
+			}
+			if len(args) == 1 {
+				return cty.UnknownVal(cty.String), function.NewArgErrorf(0, "environment variable %q is not set", name)
+			}
+			return args[1], nil
+		},
+	})
+}
+
+func makeFileFunc(baseDir string, files *Files) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "path",
+				Type: cty.String,
+			},
+		},
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			src, err := files.ReadFile(baseDir, args[0].AsString())
+			if err != nil {
+				return cty.UnknownVal(cty.String), function.NewArgError(0, err)
+			}
+			return cty.StringVal(string(src)), nil
+		},
+	})
+}
+
+// makeTemplateFileFunc returns a function that renders a template file, as
+// if it were a string template in the manifest, with the given variables in
+// scope.
+func makeTemplateFileFunc(baseDir string, files *Files, funcs map[string]function.Function) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "path",
+				Type: cty.String,
+			},
+			{
+				Name: "vars",
+				Type: cty.DynamicPseudoType,
+			},
+		},
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			path := args[0].AsString()
+			src, err := files.ReadFile(baseDir, path)
+			if err != nil {
+				return cty.UnknownVal(cty.String), function.NewArgError(0, err)
+			}
+
+			varsVal := args[1]
+			varsType := varsVal.Type()
+			if !(varsType.IsObjectType() || varsType.IsMapType()) {
+				return cty.UnknownVal(cty.String), function.NewArgErrorf(1, "expected object or map, got %s", varsType.FriendlyName())
+			}
+			vars := make(map[string]cty.Value)
+			if !varsVal.IsNull() {
+				for it := varsVal.ElementIterator(); it.Next(); {
+					k, v := it.Element()
+					vars[k.AsString()] = v
+				}
+			}
+
+			tmpl, diags := hclsyntax.ParseTemplate(src, path, hcl.Pos{Line: 1, Column: 1})
+			if diags.HasErrors() {
+				return cty.UnknownVal(cty.String), function.NewArgError(0, diags)
+			}
+			result, diags := tmpl.Value(&hcl.EvalContext{
+				Variables: vars,
+				Functions: funcs,
+			})
+			if diags.HasErrors() {
+				return cty.UnknownVal(cty.String), diags
+			}
+			if result.Type() != cty.String {
+				return cty.UnknownVal(cty.String), fmt.Errorf("template %s rendered %s, expected string", path, result.Type().FriendlyName())
+			}
+			return result, nil
+		},
+	})
+}
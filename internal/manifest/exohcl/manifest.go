@@ -2,18 +2,37 @@ package exohcl
 
 import (
 	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
 )
 
 type Manifest struct {
 	// Analysis inputs.
 	Filename string
 	File     *hcl.File
+	// Inputs are the values of variables given by an including module block.
+	Inputs map[string]cty.Value
+	// Ancestors are the filenames of the manifests that include this one as a
+	// module, used to detect include cycles.
+	Ancestors []string
+	// Profile is the name of a profile whose overlays are applied to the
+	// manifest, if any. See Profile.
+	Profile string
+	// Env holds the variables read by the env function. Included manifests
+	// share the env of the including manifest.
+	Env map[string]string
+	// RootDir is the directory of the outermost manifest when this manifest
+	// is included as a module.
+	RootDir string
+	// Files controls the files that may be read by the manifest's functions.
+	Files *Files
 
 	// Analysis outputs.
 	Content       *hcl.BodyContent
 	FormatVersion *FormatVersion
 	Environment   hcl.Blocks
 	Components    hcl.Blocks
+	Scope         *Scope
+	Modules       []*Module
 }
 
 func NewManifest(filename string, file *hcl.File) *Manifest {
@@ -24,14 +43,27 @@ func NewManifest(filename string, file *hcl.File) *Manifest {
 }
 
 func (m *Manifest) Analyze(ctx *AnalysisContext) {
+	body := m.File.Body
+	if m.Profile != "" {
+		profile := NewProfile(m, m.Profile)
+		profile.Analyze(ctx)
+		if profile.Body != nil {
+			body = profile.Body
+		}
+	}
+
 	var diags hcl.Diagnostics
-	m.Content, diags = m.File.Body.Content(&hcl.BodySchema{
+	m.Content, diags = body.Content(&hcl.BodySchema{
 		Attributes: []hcl.AttributeSchema{
 			{Name: "exo", Required: true},
 		},
 		Blocks: []hcl.BlockHeaderSchema{
 			{Type: "environment"},
 			{Type: "components"},
+			{Type: "variable", LabelNames: []string{"name"}},
+			{Type: "locals"},
+			{Type: "module", LabelNames: []string{"name"}},
+			{Type: "profile", LabelNames: []string{"name"}},
 		},
 	})
 	ctx.AppendDiags(diags...)
@@ -41,5 +73,27 @@ func (m *Manifest) Analyze(ctx *AnalysisContext) {
 		m.FormatVersion.Analyze(ctx)
 		m.Environment = m.Content.Blocks.OfType("environment")
 		m.Components = m.Content.Blocks.OfType("components")
+		m.Scope = NewScope(m)
+		m.Scope.Analyze(ctx)
+		for _, block := range m.Content.Blocks.OfType("module") {
+			module := NewModule(m, block)
+			module.Analyze(ctx)
+			m.Modules = append(m.Modules, module)
+		}
 	}
 }
+
+// EvalContext returns the context in which the manifest's expressions are
+// evaluated. It is only fully populated after analysis.
+func (m *Manifest) EvalContext() *hcl.EvalContext {
+	if m.Scope == nil || m.Scope.EvalContext == nil {
+		return newEvalContext(m.baseDir(), m.Files, m.Env, nil)
+	}
+	return m.Scope.EvalContext
+}
+
+// baseDir returns the directory against which relative paths in the manifest
+// are resolved.
+func (m *Manifest) baseDir() string {
+	return m.Files.baseDir(m.Filename)
+}
@@ -0,0 +1,269 @@
+package exohcl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Module includes the components of another manifest, such as one owned by a
+// particular service of a monorepo:
+//
+//	module "api" {
+//	  source = "./services/api"
+//	  inputs = {
+//	    port = 4000
+//	  }
+//	}
+//
+// The source is a path to a manifest file, or to a directory containing an
+// exo.hcl file, relative to the including manifest. Inputs set the values of
+// the included manifest's variables.
+//
+// Component names are namespaced by the module name, so the included "web"
+// component is named "api-web". Dependencies on components of the same module
+// are renamed accordingly, while any other dependencies are left as is, so
+// that a module may depend on components of the including manifest.
+//
+// Relative paths in the included components are relative to the included
+// manifest, and processes run in its directory by default. Variables of the
+// included manifest's environment block are added to the workspace
+// environment. See Environment.
+type Module struct {
+	// Analysis inputs.
+	Block       *hcl.Block
+	BaseDir     string
+	EvalContext *hcl.EvalContext
+	Ancestors   []string
+	Env         map[string]string
+	// RootDir is the directory of the outermost including manifest.
+	RootDir string
+	// Files controls the files that may be included. Included manifests share
+	// the files of the including manifest.
+	Files *Files
+
+	// Analysis outputs.
+	Name       string
+	Manifest   *Manifest
+	Components []*Component
+}
+
+func NewModule(m *Manifest, block *hcl.Block) *Module {
+	ancestors := append([]string{}, m.Ancestors...)
+	if m.Filename != "" {
+		ancestors = append(ancestors, absPath(m.Filename))
+	}
+	rootDir := m.RootDir
+	if rootDir == "" {
+		rootDir = m.baseDir()
+	}
+	return &Module{
+		Block:       block,
+		BaseDir:     m.baseDir(),
+		EvalContext: m.EvalContext(),
+		Ancestors:   ancestors,
+		Env:         m.Env,
+		RootDir:     rootDir,
+		Files:       m.Files,
+	}
+}
+
+func (mod *Module) Analyze(ctx *AnalysisContext) {
+	mod.Name = mod.Block.Labels[0]
+	if err := ValidateName(mod.Name); err != nil {
+		ctx.AppendDiags(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid module name",
+			Detail:   fmt.Sprintf("Module name %q %v.", mod.Name, err),
+			Subject:  mod.Block.LabelRanges[0].Ptr(),
+		})
+		return
+	}
+
+	content, diags := mod.Block.Body.Content(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "source", Required: true},
+			{Name: "inputs"},
+		},
+	})
+	ctx.AppendDiags(diags...)
+	if diags.HasErrors() {
+		return
+	}
+
+	sourceAttr := content.Attributes["source"]
+	source, ok := AnalyzeString(ctx, mod.EvalContext, sourceAttr.Expr)
+	if !ok {
+		return
+	}
+	filename, err := resolveModuleSource(mod.Files, mod.BaseDir, source)
+	if err != nil {
+		ctx.AppendDiags(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid module source",
+			Detail:   err.Error(),
+			Subject:  sourceAttr.Expr.Range().Ptr(),
+		})
+		return
+	}
+	for _, ancestor := range mod.Ancestors {
+		if ancestor == absPath(filename) {
+			ctx.AppendDiags(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Module cycle",
+				Detail:   fmt.Sprintf("Module %q includes %s, which includes itself.", mod.Name, filename),
+				Subject:  sourceAttr.Expr.Range().Ptr(),
+			})
+			return
+		}
+	}
+
+	inputs := mod.analyzeInputs(ctx, content.Attributes["inputs"])
+
+	bs, err := mod.Files.ReadFile(mod.BaseDir, filename)
+	if err != nil {
+		ctx.AppendDiags(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Cannot read module source",
+			Detail:   err.Error(),
+			Subject:  sourceAttr.Expr.Range().Ptr(),
+		})
+		return
+	}
+	file, diags := hclsyntax.ParseConfig(bs, filename, hcl.InitialPos)
+	ctx.AppendDiags(diags...)
+	if diags.HasErrors() {
+		return
+	}
+	mod.Manifest = NewManifest(filename, file)
+	mod.Manifest.Inputs = inputs
+	mod.Manifest.Ancestors = mod.Ancestors
+	mod.Manifest.Env = mod.Env
+	mod.Manifest.RootDir = mod.RootDir
+	mod.Manifest.Files = mod.Files
+	mod.Manifest.Analyze(ctx)
+	if mod.Manifest.Content == nil {
+		return
+	}
+	mod.checkInputs(ctx, content.Attributes["inputs"], inputs)
+
+	cs := NewComponentSet(mod.Manifest)
+	cs.Analyze(ctx)
+	local := make(map[string]bool, len(cs.Components))
+	for _, c := range cs.Components {
+		local[c.Name] = true
+	}
+	dir := mod.relativeDir(filename)
+	mod.Components = make([]*Component, len(cs.Components))
+	for i, c := range cs.Components {
+		namespaced := *c
+		namespaced.Name = mod.namespaced(c.Name)
+		namespaced.Spec = rebaseSpec(c.Type, c.Spec, dir)
+		namespaced.Module = mod.Name
+		namespaced.DependsOn = make([]string, len(c.DependsOn))
+		for j, dep := range c.DependsOn {
+			if local[dep] {
+				dep = mod.namespaced(dep)
+			}
+			namespaced.DependsOn[j] = dep
+		}
+		mod.Components[i] = &namespaced
+	}
+}
+
+// relativeDir returns the directory of the included manifest relative to the
+// root directory, or as an absolute path if it is not within the root.
+func (mod *Module) relativeDir(filename string) string {
+	dir := absPath(filepath.Dir(filename))
+	rel, err := filepath.Rel(absPath(mod.RootDir), dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return dir
+	}
+	return rel
+}
+
+func (mod *Module) namespaced(name string) string {
+	return mod.Name + "-" + name
+}
+
+func (mod *Module) analyzeInputs(ctx *AnalysisContext, attr *hcl.Attribute) map[string]cty.Value {
+	if attr == nil {
+		return nil
+	}
+	v, diags := attr.Expr.Value(mod.EvalContext)
+	ctx.AppendDiags(diags...)
+	if diags.HasErrors() {
+		return nil
+	}
+	if !(v.Type().IsObjectType() || v.Type().IsMapType()) || v.IsNull() {
+		ctx.AppendDiags(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Expected object",
+			Detail:   fmt.Sprintf("Module inputs must be an object, but got %s", v.Type().FriendlyName()),
+			Subject:  attr.Expr.Range().Ptr(),
+		})
+		return nil
+	}
+	inputs := make(map[string]cty.Value)
+	for it := v.ElementIterator(); it.Next(); {
+		k, v := it.Element()
+		inputs[k.AsString()] = v
+	}
+	return inputs
+}
+
+// checkInputs reports inputs that do not correspond to any variable of the
+// included manifest.
+func (mod *Module) checkInputs(ctx *AnalysisContext, attr *hcl.Attribute, inputs map[string]cty.Value) {
+	declared := make(map[string]bool)
+	for _, block := range mod.Manifest.Scope.Variables {
+		declared[block.Labels[0]] = true
+	}
+	var unknown []string
+	for name := range inputs {
+		if !declared[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		ctx.AppendDiags(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Unknown module input",
+			Detail:   fmt.Sprintf("Module %q has no variable named %q.", mod.Name, name),
+			Subject:  attr.Expr.Range().Ptr(),
+		})
+	}
+}
+
+func resolveModuleSource(files *Files, baseDir string, source string) (string, error) {
+	if source == "" {
+		return "", fmt.Errorf("module source must not be empty")
+	}
+	path, err := files.Resolve(baseDir, source)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		// Check again, since the manifest may be a link out of the root.
+		return files.Resolve(path, "exo.hcl")
+	}
+	return path, nil
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
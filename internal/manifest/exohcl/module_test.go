@@ -0,0 +1,280 @@
+package exohcl
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModule(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exohcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	apiDir := filepath.Join(dir, "services", "api")
+	assert.NoError(t, os.MkdirAll(apiDir, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(apiDir, "exo.hcl"), []byte(`
+exo = "0.1"
+
+variable "port" {
+  default = 3000
+}
+
+components {
+  process "server" {
+    program = "api"
+    environment = {
+      PORT = format("%d", var.port)
+    }
+  }
+  component "worker" {
+    type = "process"
+    spec = jsonencode({ program = "worker", directory = "worker" })
+    depends_on = ["server", "db"]
+  }
+  container "cache" {
+    build = "./cache"
+    volumes = ["./data:/data", "cache:/tmp", "/var/run:/var/run"]
+  }
+}
+`), 0644))
+
+	components, diags := analyzeComponents(t, filepath.Join(dir, "exo.hcl"), `
+exo = "0.1"
+
+module "api" {
+  source = "./services/api"
+  inputs = {
+    port = 4000
+  }
+}
+
+components {
+  process "db" {
+    program = "postgres"
+  }
+}
+`)
+	assert.Empty(t, diags)
+	type summary struct {
+		Name      string
+		Module    string
+		Spec      string
+		DependsOn []string
+	}
+	var actual []summary
+	for _, c := range components {
+		actual = append(actual, summary{
+			Name:      c.Name,
+			Module:    c.Module,
+			Spec:      c.Spec,
+			DependsOn: c.DependsOn,
+		})
+	}
+	assert.Equal(t, []summary{
+		{Name: "db", Spec: `{"program":"postgres"}`},
+		{Name: "api-server", Module: "api", Spec: `{"directory":"services/api","environment":{"PORT":"4000"},"program":"api"}`, DependsOn: []string{}},
+		{Name: "api-worker", Module: "api", Spec: `{"directory":"services/api/worker","program":"worker"}`, DependsOn: []string{"api-server", "db"}},
+		{Name: "api-cache", Module: "api", Spec: `{"build":"services/api/cache","volumes":["./services/api/data:/data","cache:/tmp","/var/run:/var/run"]}`, DependsOn: []string{}},
+	}, actual)
+}
+
+func TestModuleEnvironment(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exohcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeModule := func(name string, env string) {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name+".hcl"), []byte(`
+exo = "0.1"
+
+environment {
+`+env+`
+}
+`), 0644))
+	}
+	writeModule("a", `
+  SHARED = "same"
+  A = "a"
+  OVERRIDDEN = "a"
+`)
+	writeModule("b", `
+  SHARED = "same"
+  B = "b"
+`)
+	writeModule("c", `
+  A = "c"
+`)
+
+	analyzeEnvironment := func(src string) (*Environment, hcl.Diagnostics) {
+		ctx := context.Background()
+		filename := filepath.Join(dir, "exo.hcl")
+		file, diags := hclsyntax.ParseConfig([]byte(src), filename, hcl.InitialPos)
+		if diags.HasErrors() {
+			t.Fatal(diags)
+		}
+		m := NewManifest(filename, file)
+		m.Env = map[string]string{}
+		diags = Analyze(ctx, m)
+		env := NewEnvironment(m)
+		diags = append(diags, Analyze(ctx, env)...)
+		return env, diags
+	}
+
+	env, diags := analyzeEnvironment(`
+exo = "0.1"
+
+module "a" {
+  source = "./a.hcl"
+}
+
+module "b" {
+  source = "./b.hcl"
+}
+
+environment {
+  OVERRIDDEN = "root"
+}
+`)
+	assert.Empty(t, diags)
+	assert.Equal(t, map[string]string{
+		"SHARED":     "same",
+		"A":          "a",
+		"B":          "b",
+		"OVERRIDDEN": "root",
+	}, env.Variables)
+
+	_, diags = analyzeEnvironment(`
+exo = "0.1"
+
+module "a" {
+  source = "./a.hcl"
+}
+
+module "c" {
+  source = "./c.hcl"
+}
+`)
+	if assert.True(t, diags.HasErrors()) {
+		assert.Equal(t, "Conflicting environment variable", diags[0].Summary)
+	}
+}
+
+func TestModuleErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exohcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "loop.hcl"), []byte(`
+exo = "0.1"
+
+module "again" {
+  source = "./loop.hcl"
+}
+`), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "web.hcl"), []byte(`
+exo = "0.1"
+
+components {
+  process "server" {
+    program = "web"
+  }
+}
+`), 0644))
+
+	check := func(src string, summary string) {
+		_, diags := analyzeComponents(t, filepath.Join(dir, "exo.hcl"), `
+exo = "0.1"
+`+src)
+		if assert.True(t, diags.HasErrors(), "%s", src) {
+			assert.Equal(t, summary, diags[0].Summary)
+		}
+	}
+	check(`
+module "loop" {
+  source = "./loop.hcl"
+}
+`, "Module cycle")
+	check(`
+module "web" {
+  source = "./missing.hcl"
+}
+`, "Invalid module source")
+	check(`
+module "web" {
+  source = "./web.hcl"
+  inputs = {
+    port = 1
+  }
+}
+`, "Unknown module input")
+	check(`
+module "web" {
+  source = "./web.hcl"
+}
+components {
+  process "web-server" {
+    program = "web"
+  }
+}
+`, "Duplicate component")
+}
+
+func TestModuleSourceOutsideRoot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exohcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	root := filepath.Join(dir, "ws")
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "api"), 0755))
+	manifest := []byte(`
+exo = "0.1"
+
+components {
+  process "server" {
+    program = "web"
+  }
+}
+`)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "exo.hcl"), manifest, 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "api", "exo.hcl"), manifest, 0644))
+
+	analyze := func(source string) hcl.Diagnostics {
+		src := `
+exo = "0.1"
+
+module "web" {
+  source = "` + source + `"
+}
+`
+		file, diags := hclsyntax.ParseConfig([]byte(src), filepath.Join(root, "exo.hcl"), hcl.InitialPos)
+		if diags.HasErrors() {
+			t.Fatal(diags)
+		}
+		m := NewManifest(filepath.Join(root, "exo.hcl"), file)
+		m.Env = map[string]string{}
+		m.Files = &Files{Root: root}
+		ctx := context.Background()
+		diags = Analyze(ctx, m)
+		return append(diags, Analyze(ctx, NewComponentSet(m))...)
+	}
+
+	assert.Empty(t, analyze("./api"))
+	for _, source := range []string{"..", dir} {
+		diags := analyze(source)
+		if assert.True(t, diags.HasErrors(), source) {
+			assert.Equal(t, "Invalid module source", diags[0].Summary)
+			assert.Contains(t, diags[0].Detail, "outside of workspace root")
+		}
+	}
+}
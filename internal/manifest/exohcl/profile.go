@@ -0,0 +1,370 @@
+package exohcl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// Profile layers named overlays on to a manifest, such as for running in CI:
+//
+//	profile "ci" {
+//	  remove {
+//	    components  = ["gui"]
+//	    environment = ["DEBUG"]
+//	  }
+//	  environment {
+//	    LOG_LEVEL = "warn"
+//	  }
+//	  components {
+//	    process "web" {
+//	      program = "web --headless"
+//	    }
+//	  }
+//	}
+//
+// An overlay may also be written in a file beside the manifest, named for the
+// profile, such as exo.ci.hcl beside exo.hcl. The file has the same contents
+// as a profile block. Profile blocks are applied before the overlay file.
+//
+// Removals are applied first. Then environment variables are set, replacing
+// those of the same name. Components are added, or patched when a component
+// of the same name and type already exists. Patching replaces attributes and
+// merges nested blocks, such as `_`. A component with the same name but a
+// different type is replaced entirely.
+//
+// Overlays only apply to the components of the manifest itself, not to those
+// of included modules.
+type Profile struct {
+	// Analysis inputs.
+	Name     string
+	Filename string
+	Base     *hclsyntax.Body
+
+	// Analysis outputs.
+	Overlays []*hclsyntax.Body
+	// Body is the base manifest body with all overlays applied and without any
+	// profile blocks.
+	Body *hclsyntax.Body
+}
+
+func NewProfile(m *Manifest, name string) *Profile {
+	base, _ := m.File.Body.(*hclsyntax.Body)
+	return &Profile{
+		Name:     name,
+		Filename: m.Filename,
+		Base:     base,
+	}
+}
+
+var overlaySchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "remove"},
+		{Type: "environment"},
+		{Type: "components"},
+	},
+}
+
+func (p *Profile) Analyze(ctx *AnalysisContext) {
+	if p.Base == nil {
+		ctx.AppendDiags(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Unsupported profile",
+			Detail:   fmt.Sprintf("Profile %q cannot be applied to a manifest that was imported from another format.", p.Name),
+		})
+		return
+	}
+
+	p.Body = copyBody(p.Base)
+	p.Body.Blocks = p.Body.Blocks[:0]
+	for _, block := range p.Base.Blocks {
+		if block.Type != "profile" {
+			p.Body.Blocks = append(p.Body.Blocks, block)
+			continue
+		}
+		if len(block.Labels) == 1 && block.Labels[0] == p.Name {
+			p.Overlays = append(p.Overlays, block.Body)
+		}
+	}
+
+	overlayFilename := ProfileFilename(p.Filename, p.Name)
+	if overlayFilename != "" {
+		bs, err := ioutil.ReadFile(overlayFilename)
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			ctx.AppendDiags(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Cannot read overlay file",
+				Detail:   err.Error(),
+			})
+		default:
+			file, diags := hclsyntax.ParseConfig(bs, overlayFilename, hcl.InitialPos)
+			ctx.AppendDiags(diags...)
+			if !diags.HasErrors() {
+				p.Overlays = append(p.Overlays, file.Body.(*hclsyntax.Body))
+			}
+		}
+	}
+
+	if len(p.Overlays) == 0 {
+		detail := fmt.Sprintf("There is no profile block named %q", p.Name)
+		if overlayFilename != "" {
+			detail += fmt.Sprintf(" and no overlay file %s", overlayFilename)
+		}
+		ctx.AppendDiags(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Unknown profile",
+			Detail:   detail + ".",
+		})
+		return
+	}
+
+	for _, overlay := range p.Overlays {
+		p.applyOverlay(ctx, overlay)
+	}
+}
+
+// ProfileFilename returns the name of the overlay file of a profile for the
+// given manifest file, or an empty string if the manifest is not a file.
+func ProfileFilename(manifestFilename string, profile string) string {
+	if manifestFilename == "" || manifestFilename == "/dev/stdin" {
+		return ""
+	}
+	ext := filepath.Ext(manifestFilename)
+	return strings.TrimSuffix(manifestFilename, ext) + "." + profile + ".hcl"
+}
+
+func (p *Profile) applyOverlay(ctx *AnalysisContext, overlay *hclsyntax.Body) {
+	_, diags := overlay.Content(overlaySchema)
+	ctx.AppendDiags(diags...)
+	for _, block := range overlay.Blocks {
+		if len(block.Labels) > 0 {
+			ctx.AppendDiags(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("Unexpected label on %s block", block.Type),
+				Detail:   fmt.Sprintf("A %s block expects no labels, but has %d", block.Type, len(block.Labels)),
+				Subject:  &block.LabelRanges[0],
+			})
+			continue
+		}
+		switch block.Type {
+		case "remove":
+			p.applyRemovals(ctx, block.Body)
+		case "environment":
+			p.applyEnvironment(block)
+		case "components":
+			p.applyComponents(ctx, block)
+		}
+	}
+}
+
+func (p *Profile) applyRemovals(ctx *AnalysisContext, body *hclsyntax.Body) {
+	content, diags := body.Content(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "components"},
+			{Name: "environment"},
+		},
+	})
+	ctx.AppendDiags(diags...)
+
+	if attr := content.Attributes["components"]; attr != nil {
+		for _, name := range p.analyzeNames(ctx, attr.Expr) {
+			removed := false
+			p.updateBlocks("components", func(components *hclsyntax.Body) {
+				blocks := components.Blocks[:0:0]
+				for _, block := range components.Blocks {
+					if componentName(block) == name {
+						removed = true
+						continue
+					}
+					blocks = append(blocks, block)
+				}
+				components.Blocks = blocks
+			})
+			if !removed {
+				ctx.AppendDiags(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Unknown component",
+					Detail:   fmt.Sprintf("Profile %q removes component %q, which is not in the manifest.", p.Name, name),
+					Subject:  attr.Expr.Range().Ptr(),
+				})
+			}
+		}
+	}
+
+	if attr := content.Attributes["environment"]; attr != nil {
+		for _, name := range p.analyzeNames(ctx, attr.Expr) {
+			removed := false
+			p.updateBlocks("environment", func(env *hclsyntax.Body) {
+				if _, ok := env.Attributes[name]; ok {
+					delete(env.Attributes, name)
+					removed = true
+				}
+			})
+			if !removed {
+				ctx.AppendDiags(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Unknown environment variable",
+					Detail:   fmt.Sprintf("Profile %q removes environment variable %q, which is not in the manifest.", p.Name, name),
+					Subject:  attr.Expr.Range().Ptr(),
+				})
+			}
+		}
+	}
+}
+
+func (p *Profile) analyzeNames(ctx *AnalysisContext, x hcl.Expression) []string {
+	exprs, diags := hcl.ExprList(x)
+	ctx.AppendDiags(diags...)
+	names := make([]string, 0, len(exprs))
+	for _, elem := range exprs {
+		name, diag := parseLiteralString(elem)
+		if diag != nil {
+			ctx.AppendDiags(diag)
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// updateBlocks replaces each top-level block of the given type with a copy
+// that has been modified by f.
+func (p *Profile) updateBlocks(typ string, f func(body *hclsyntax.Body)) {
+	for i, block := range p.Body.Blocks {
+		if block.Type == typ {
+			block = copyBlock(block)
+			f(block.Body)
+			p.Body.Blocks[i] = block
+		}
+	}
+}
+
+// firstBlock returns the index of the first top-level block of the given
+// type.
+func (p *Profile) firstBlock(typ string) (int, bool) {
+	for i, block := range p.Body.Blocks {
+		if block.Type == typ {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+func (p *Profile) applyEnvironment(block *hclsyntax.Block) {
+	i, ok := p.firstBlock("environment")
+	if !ok {
+		p.Body.Blocks = append(p.Body.Blocks, block)
+		return
+	}
+	overlay := block.Body
+	env := copyBlock(p.Body.Blocks[i])
+	for name, attr := range overlay.Attributes {
+		env.Body.Attributes[name] = attr
+	}
+	// Secrets blocks are unlabeled, so are added rather than merged.
+	env.Body.Blocks = append(env.Body.Blocks, overlay.Blocks...)
+	p.Body.Blocks[i] = env
+}
+
+func (p *Profile) applyComponents(ctx *AnalysisContext, block *hclsyntax.Block) {
+	overlay := block.Body
+	if len(overlay.Attributes) > 0 {
+		ctx.AppendDiags(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Unexpected attributes in components block",
+			Detail:   fmt.Sprintf("A components block expects no attributes, but has %d", len(overlay.Attributes)),
+			Subject:  overlay.Attributes.Range().Ptr(),
+		})
+	}
+	i, ok := p.firstBlock("components")
+	if !ok {
+		p.Body.Blocks = append(p.Body.Blocks, block)
+		return
+	}
+	components := copyBlock(p.Body.Blocks[i])
+	for _, patch := range overlay.Blocks {
+		name := componentName(patch)
+		found := false
+		for j, existing := range components.Body.Blocks {
+			if name == "" || componentName(existing) != name {
+				continue
+			}
+			found = true
+			if existing.Type == patch.Type {
+				components.Body.Blocks[j] = mergeBlocks(existing, patch)
+			} else {
+				components.Body.Blocks[j] = patch
+			}
+			break
+		}
+		if !found {
+			components.Body.Blocks = append(components.Body.Blocks, patch)
+		}
+	}
+	p.Body.Blocks[i] = components
+}
+
+func componentName(block *hclsyntax.Block) string {
+	if len(block.Labels) != 1 {
+		return ""
+	}
+	return block.Labels[0]
+}
+
+// mergeBlocks returns a copy of base, with the attributes of patch replacing
+// those of base and nested blocks with matching types and labels merged.
+func mergeBlocks(base, patch *hclsyntax.Block) *hclsyntax.Block {
+	res := copyBlock(base)
+	for name, attr := range patch.Body.Attributes {
+		res.Body.Attributes[name] = attr
+	}
+	for _, child := range patch.Body.Blocks {
+		merged := false
+		for i, existing := range res.Body.Blocks {
+			if existing.Type == child.Type && labelsEqual(existing.Labels, child.Labels) {
+				res.Body.Blocks[i] = mergeBlocks(existing, child)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			res.Body.Blocks = append(res.Body.Blocks, child)
+		}
+	}
+	return res
+}
+
+func labelsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func copyBlock(block *hclsyntax.Block) *hclsyntax.Block {
+	res := *block
+	res.Body = copyBody(block.Body)
+	return &res
+}
+
+func copyBody(body *hclsyntax.Body) *hclsyntax.Body {
+	res := *body
+	res.Attributes = make(hclsyntax.Attributes, len(body.Attributes))
+	for name, attr := range body.Attributes {
+		res.Attributes[name] = attr
+	}
+	res.Blocks = append(hclsyntax.Blocks{}, body.Blocks...)
+	return &res
+}
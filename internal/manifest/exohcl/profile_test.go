@@ -0,0 +1,195 @@
+package exohcl
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+)
+
+const profileManifest = `
+exo = "0.1"
+
+environment {
+  DEBUG = "true"
+  LOG_LEVEL = "info"
+}
+
+components {
+  process "web" {
+    program = "web"
+    arguments = ["--port", "3000"]
+    _ {
+      depends_on = ["db"]
+      labels = { tier = "frontend" }
+    }
+  }
+  process "db" {
+    program = "postgres"
+  }
+  process "gui" {
+    program = "gui"
+  }
+}
+
+profile "ci" {
+  remove {
+    components  = ["gui"]
+    environment = ["DEBUG"]
+  }
+  environment {
+    LOG_LEVEL = "warn"
+  }
+  components {
+    process "web" {
+      arguments = ["--headless"]
+      _ {
+        labels = { tier = "test" }
+      }
+    }
+    container "db" {
+      image = "postgres"
+    }
+  }
+}
+`
+
+func analyzeProfile(t *testing.T, filename string, src string, profile string) (*Manifest, []*Component, hcl.Diagnostics) {
+	ctx := context.Background()
+	file, diags := hclsyntax.ParseConfig([]byte(src), filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	m := NewManifest(filename, file)
+	m.Profile = profile
+	diags = Analyze(ctx, m)
+	env := NewEnvironment(m)
+	diags = append(diags, Analyze(ctx, env)...)
+	cs := NewComponentSet(m)
+	diags = append(diags, Analyze(ctx, cs)...)
+	return m, cs.Components, diags
+}
+
+func TestProfileBlock(t *testing.T) {
+	m, components, diags := analyzeProfile(t, "exo.hcl", profileManifest, "ci")
+	assert.Empty(t, diags)
+
+	env := NewEnvironment(m)
+	assert.Empty(t, Analyze(context.Background(), env))
+	assert.Equal(t, map[string]string{"LOG_LEVEL": "warn"}, env.Variables)
+
+	type summary struct {
+		Name      string
+		Type      string
+		Spec      string
+		DependsOn []string
+		Labels    map[string]string
+	}
+	var actual []summary
+	for _, c := range components {
+		actual = append(actual, summary{c.Name, c.Type, c.Spec, c.DependsOn, c.Labels})
+	}
+	assert.Equal(t, []summary{
+		{
+			Name:      "web",
+			Type:      "process",
+			Spec:      `{"arguments":["--headless"],"program":"web"}`,
+			DependsOn: []string{"db"},
+			Labels:    map[string]string{"tier": "test"},
+		},
+		{
+			Name: "db",
+			Type: "container",
+			Spec: "\"image\": \"postgres\"\n",
+		},
+	}, actual)
+}
+
+func TestWithoutProfile(t *testing.T) {
+	_, components, diags := analyzeProfile(t, "exo.hcl", profileManifest, "")
+	assert.Empty(t, diags)
+	var names []string
+	for _, c := range components {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"web", "db", "gui"}, names)
+}
+
+func TestProfileFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exohcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "exo.e2e.hcl"), []byte(`
+components {
+  process "browser" {
+    program = "chromium"
+    _ {
+      depends_on = ["web"]
+    }
+  }
+}
+`), 0644))
+
+	_, components, diags := analyzeProfile(t, filepath.Join(dir, "exo.hcl"), profileManifest, "e2e")
+	assert.Empty(t, diags)
+	var names []string
+	for _, c := range components {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"web", "db", "gui", "browser"}, names)
+
+	_, _, diags = analyzeProfile(t, filepath.Join(dir, "exo.hcl"), profileManifest, "demo")
+	if assert.Len(t, diags, 1) {
+		assert.Equal(t, "Unknown profile", diags[0].Summary)
+	}
+}
+
+func TestProfileRemoveUnknown(t *testing.T) {
+	_, _, diags := analyzeProfile(t, "exo.hcl", `
+exo = "0.1"
+
+components {
+  process "web" {
+    program = "web"
+  }
+}
+
+profile "ci" {
+  remove {
+    components = ["worker"]
+  }
+}
+`, "ci")
+	if assert.Len(t, diags, 1) {
+		assert.Equal(t, "Unknown component", diags[0].Summary)
+	}
+}
+
+func TestValidateProfiles(t *testing.T) {
+	file, diags := hclsyntax.ParseConfig([]byte(`
+exo = "0.1"
+
+profile "ci" {
+  remove {
+    environment = ["DEBUG"]
+  }
+}
+`), "exo.hcl", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	ctx := &AnalysisContext{
+		Context: context.Background(),
+	}
+	Validate(ctx, NewManifest("exo.hcl", file))
+	if assert.Len(t, ctx.Diagnostics, 1) {
+		assert.Equal(t, "Unknown environment variable", ctx.Diagnostics[0].Summary)
+	}
+}
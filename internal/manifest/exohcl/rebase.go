@@ -0,0 +1,104 @@
+package exohcl
+
+import (
+	"encoding/json"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// rebaseSpec rewrites the relative paths in the spec of a component included
+// from a module, so that they are relative to the workspace root instead of
+// to the module's manifest. dir is the module's directory, relative to the
+// workspace root if possible. Processes default to running in dir.
+//
+// Specs that cannot be decoded are returned unchanged, to be reported by
+// validation.
+func rebaseSpec(typ string, spec string, dir string) string {
+	if dir == "." || dir == "" {
+		return spec
+	}
+	var obj map[string]interface{}
+	if err := yaml.Unmarshal([]byte(spec), &obj); err != nil || obj == nil {
+		return spec
+	}
+	switch typ {
+	case "process":
+		directory, _ := obj["directory"].(string)
+		obj["directory"] = rebasePath(dir, directory)
+	case "container":
+		rebaseContainer(obj, dir)
+	default:
+		return spec
+	}
+	bs, err := json.Marshal(obj)
+	if err != nil {
+		return spec
+	}
+	return string(bs)
+}
+
+func rebaseContainer(obj map[string]interface{}, dir string) {
+	switch build := obj["build"].(type) {
+	case string:
+		obj["build"] = rebasePath(dir, build)
+	case map[string]interface{}:
+		if context, ok := build["context"].(string); ok {
+			build["context"] = rebasePath(dir, context)
+		}
+	}
+
+	switch envFile := obj["env_file"].(type) {
+	case string:
+		obj["env_file"] = rebasePath(dir, envFile)
+	case []interface{}:
+		for i, item := range envFile {
+			if s, ok := item.(string); ok {
+				envFile[i] = rebasePath(dir, s)
+			}
+		}
+	}
+
+	volumes, _ := obj["volumes"].([]interface{})
+	for i, volume := range volumes {
+		switch volume := volume.(type) {
+		case string:
+			// Short syntax is "source:target[:mode]", where relative sources
+			// begin with a dot to distinguish them from volume names.
+			parts := strings.SplitN(volume, ":", 2)
+			if len(parts) == 2 && strings.HasPrefix(parts[0], ".") {
+				volumes[i] = rebaseMountSource(dir, parts[0]) + ":" + parts[1]
+			}
+		case map[string]interface{}:
+			if volume["type"] != "bind" {
+				continue
+			}
+			if source, ok := volume["source"].(string); ok && strings.HasPrefix(source, ".") {
+				volume["source"] = rebaseMountSource(dir, source)
+			}
+		}
+	}
+}
+
+// rebasePath returns p relative to the workspace root instead of to dir.
+func rebasePath(dir string, p string) string {
+	if filepath.IsAbs(p) || strings.HasPrefix(p, "~") {
+		return p
+	}
+	if filepath.IsAbs(dir) {
+		return filepath.Join(dir, p)
+	}
+	return path.Join(filepath.ToSlash(dir), filepath.ToSlash(p))
+}
+
+// rebaseMountSource is like rebasePath, but keeps the leading dot that
+// marks a mount source as a path.
+func rebaseMountSource(dir string, source string) string {
+	rebased := rebasePath(dir, source)
+	if filepath.IsAbs(rebased) || strings.HasPrefix(rebased, ".") {
+		return rebased
+	}
+	return "./" + rebased
+}
@@ -127,13 +127,16 @@ func (_ RewriteBase) RewriteSecrets(re Rewrite, s *Secrets) *hclgen.Block {
 }
 
 func (_ RewriteBase) RewriteComponents(re Rewrite, cs *ComponentSet) *hclgen.Block {
-	n := len(cs.Components)
-	if n == 0 {
-		return nil
+	// Components of modules are left to their own manifests.
+	blocks := make([]*hclgen.Block, 0, len(cs.Components))
+	for _, c := range cs.Components {
+		if c.Module != "" {
+			continue
+		}
+		blocks = append(blocks, RewriteComponent(re, c))
 	}
-	blocks := make([]*hclgen.Block, n)
-	for i, c := range cs.Components {
-		blocks[i] = RewriteComponent(re, c)
+	if len(blocks) == 0 {
+		return nil
 	}
 	return &hclgen.Block{
 		Type: "components",
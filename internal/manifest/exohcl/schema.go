@@ -0,0 +1,304 @@
+package exohcl
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/deref/exo/internal/providers/docker/compose"
+	"github.com/deref/exo/internal/providers/unix/processspec"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"gopkg.in/yaml.v3"
+)
+
+// SpecSchema describes the attributes that may appear in a component spec, or
+// in an object nested within one. Schemas are derived from the Go structs that
+// components decode their specs in to, so that mistakes are reported at
+// analysis time rather than when the component is initialized.
+type SpecSchema struct {
+	Attributes map[string]*SpecAttribute
+	// Compose files permit arbitrary extension attributes prefixed with "x-".
+	AllowExtensions bool
+	// JSON specs are decoded strictly, so the types of their values are checked
+	// too. YAML specs are decoded leniently, such as by coercing numbers to
+	// strings, so only their attribute names are checked.
+	CheckTypes bool
+}
+
+type SpecAttribute struct {
+	Name string
+	Type reflect.Type
+	// Schema of the attribute's object value, or of the objects in its array
+	// value. Nil if the structure of the value is not checked.
+	Nested *SpecSchema
+}
+
+var specSchemas = map[string]*SpecSchema{
+	"process":   newSpecSchema(reflect.TypeOf(processspec.Spec{}), "json"),
+	"container": newSpecSchema(reflect.TypeOf(compose.Service{}), "yaml"),
+	"volume":    newSpecSchema(reflect.TypeOf(compose.Volume{}), "yaml"),
+	"network":   newSpecSchema(reflect.TypeOf(compose.Network{}), "yaml"),
+}
+
+// SpecSchemaFor returns the schema of specs for the given component type, or
+// nil if the type is not known.
+func SpecSchemaFor(typ string) *SpecSchema {
+	return specSchemas[typ]
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	yamlUnmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+)
+
+func newSpecSchema(t reflect.Type, tagKey string) *SpecSchema {
+	schema := &SpecSchema{
+		Attributes:      make(map[string]*SpecAttribute),
+		AllowExtensions: tagKey == "yaml",
+		CheckTypes:      tagKey == "json",
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get(tagKey), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		attr := &SpecAttribute{
+			Name: name,
+			Type: field.Type,
+		}
+		if nested := nestedStructType(field.Type, tagKey); nested != nil {
+			attr.Nested = newSpecSchema(nested, tagKey)
+		}
+		schema.Attributes[name] = attr
+	}
+	return schema
+}
+
+// nestedStructType returns the struct type of objects within values of type
+// t, if that struct is decoded field-by-field. Structs with custom decoding
+// logic typically accept multiple syntaxes, so are not checked.
+func nestedStructType(t reflect.Type, tagKey string) reflect.Type {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	unmarshaler := yamlUnmarshalerType
+	if tagKey == "json" {
+		unmarshaler = jsonUnmarshalerType
+	}
+	if reflect.PtrTo(t).Implements(unmarshaler) {
+		return nil
+	}
+	return t
+}
+
+// AttributeNames returns the sorted names of all attributes in the schema.
+func (schema *SpecSchema) AttributeNames() []string {
+	names := make([]string, 0, len(schema.Attributes))
+	for name := range schema.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateSpec checks the spec of an expanded component against the schema
+// for its type. Only specs constructed literally with jsonencode or
+// yamlencode can be checked, since other expressions lack source ranges for
+// individual attributes.
+func validateSpec(ctx *AnalysisContext, evalCtx *hcl.EvalContext, typ string, specExpr hcl.Expression) {
+	schema := SpecSchemaFor(typ)
+	if schema == nil {
+		return
+	}
+	call, ok := specExpr.(*hclsyntax.FunctionCallExpr)
+	if !ok || len(call.Args) != 1 || !(call.Name == "jsonencode" || call.Name == "yamlencode") {
+		return
+	}
+	obj, ok := call.Args[0].(*hclsyntax.ObjectConsExpr)
+	if !ok {
+		return
+	}
+	schema.validateObject(ctx, evalCtx, typ, obj)
+}
+
+func (schema *SpecSchema) validateObject(ctx *AnalysisContext, evalCtx *hcl.EvalContext, path string, obj *hclsyntax.ObjectConsExpr) {
+	for _, item := range obj.Items {
+		key, diags := item.KeyExpr.Value(nil)
+		if diags.HasErrors() || !key.IsKnown() || key.IsNull() || key.Type() != cty.String {
+			continue
+		}
+		name := key.AsString()
+		attr := schema.Attributes[name]
+		if attr == nil {
+			if schema.AllowExtensions && strings.HasPrefix(name, "x-") {
+				continue
+			}
+			detail := fmt.Sprintf("%s does not support an attribute named %q.", path, name)
+			if suggestion := nameSuggestion(name, schema.AttributeNames()); suggestion != "" {
+				detail += fmt.Sprintf(" Did you mean %q?", suggestion)
+			}
+			ctx.AppendDiags(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unsupported attribute",
+				Detail:   detail,
+				Subject:  item.KeyExpr.Range().Ptr(),
+			})
+			continue
+		}
+
+		attrPath := path + "." + name
+		if schema.CheckTypes {
+			v, diags := item.ValueExpr.Value(evalCtx)
+			// Evaluation errors are reported when the spec itself is evaluated.
+			if !diags.HasErrors() {
+				if expected := checkValueType(v, attr.Type); expected != "" {
+					ctx.AppendDiags(&hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "Incorrect attribute value type",
+						Detail:   fmt.Sprintf("Expected %s to be %s, but got %s.", attrPath, expected, v.Type().FriendlyName()),
+						Subject:  item.ValueExpr.Range().Ptr(),
+					})
+					continue
+				}
+			}
+		}
+
+		if attr.Nested == nil {
+			continue
+		}
+		switch value := item.ValueExpr.(type) {
+		case *hclsyntax.ObjectConsExpr:
+			attr.Nested.validateObject(ctx, evalCtx, attrPath, value)
+		case *hclsyntax.TupleConsExpr:
+			for _, elem := range value.Exprs {
+				if elemObj, ok := elem.(*hclsyntax.ObjectConsExpr); ok {
+					attr.Nested.validateObject(ctx, evalCtx, attrPath, elemObj)
+				}
+			}
+		}
+	}
+}
+
+// checkValueType returns a description of the expected type if v cannot be
+// decoded as JSON in to a value of type t, or an empty string if it can.
+func checkValueType(v cty.Value, t reflect.Type) (expected string) {
+	if !v.IsKnown() {
+		return ""
+	}
+	if v.IsNull() {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+			return ""
+		}
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	ty := v.Type()
+	switch t.Kind() {
+	case reflect.Interface:
+		return ""
+	case reflect.String:
+		if ty != cty.String {
+			return "a string"
+		}
+	case reflect.Bool:
+		if ty != cty.Bool {
+			return "a bool"
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if ty != cty.Number {
+			return "a number"
+		}
+	case reflect.Slice, reflect.Array:
+		if !(ty.IsTupleType() || ty.IsListType() || ty.IsSetType()) || v.IsNull() {
+			return "an array"
+		}
+		for it := v.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			if expected := checkValueType(elem, t.Elem()); expected != "" {
+				return "an array of " + plural(expected)
+			}
+		}
+	case reflect.Map:
+		if !(ty.IsObjectType() || ty.IsMapType()) || v.IsNull() {
+			return "an object"
+		}
+		for it := v.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			if expected := checkValueType(elem, t.Elem()); expected != "" {
+				return "an object of " + plural(expected)
+			}
+		}
+	case reflect.Struct:
+		if !(ty.IsObjectType() || ty.IsMapType()) || v.IsNull() {
+			return "an object"
+		}
+	}
+	return ""
+}
+
+// plural converts a description such as "a string" to "strings".
+func plural(description string) string {
+	for _, article := range []string{"a ", "an "} {
+		if strings.HasPrefix(description, article) {
+			return strings.TrimPrefix(description, article) + "s"
+		}
+	}
+	return description
+}
+
+// nameSuggestion returns the candidate most similar to name, if any is close
+// enough to be a likely typo.
+func nameSuggestion(name string, candidates []string) string {
+	best := ""
+	bestDistance := 3
+	for _, candidate := range candidates {
+		if distance := levenshtein(name, candidate); distance < bestDistance {
+			best = candidate
+			bestDistance = distance
+		}
+	}
+	return best
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	m := a
+	if b < m {
+		m = b
+	}
+	if c < m {
+		m = c
+	}
+	return m
+}
@@ -0,0 +1,85 @@
+package exohcl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSpec(t *testing.T) {
+	check := func(components string, expected ...string) {
+		_, diags := analyzeComponents(t, "exo.hcl", `
+exo = "0.1"
+components {
+`+components+`
+}
+`)
+		var actual []string
+		for _, diag := range diags {
+			actual = append(actual, diag.Detail)
+		}
+		assert.Equal(t, expected, actual, "%s", components)
+	}
+
+	// Valid specs.
+	check(`
+process "web" {
+  program = "web"
+  arguments = ["--port", "80"]
+  environment = { DEBUG = "1" }
+  shutdownGracePeriodSeconds = 5
+}
+container "db" {
+  image = "postgres"
+  x-notes = "extension attributes are allowed in compose specs"
+  mem_limit = 512
+  deploy = {
+    resources = { limits = { cpus = "0.5" } }
+  }
+}
+component "data" {
+  type = "volume"
+  spec = yamlencode({ driver = "local" })
+}
+`)
+
+	// Unknown attributes.
+	check(`
+process "web" {
+  progam = "web"
+}
+`, `process does not support an attribute named "progam". Did you mean "program"?`)
+	check(`
+component "web" {
+  type = "process"
+  spec = jsonencode({ program = "web", x-debug = true })
+}
+`, `process does not support an attribute named "x-debug".`)
+	check(`
+container "db" {
+  image = "postgres"
+  deploy = {
+    resources = { limit = {} }
+  }
+}
+`, `container.deploy.resources does not support an attribute named "limit". Did you mean "limits"?`)
+	check(`
+network "front" {
+  drivr = "bridge"
+}
+`, `network does not support an attribute named "drivr". Did you mean "driver"?`)
+
+	// Types of JSON specs.
+	check(`
+process "web" {
+  program = "web"
+  arguments = "--verbose"
+  environment = { PORT = 80 }
+  shutdownGracePeriodSeconds = "5s"
+}
+`,
+		"Expected process.arguments to be an array, but got string.",
+		"Expected process.environment to be an object of strings, but got object.",
+		"Expected process.shutdownGracePeriodSeconds to be a number, but got string.",
+	)
+}
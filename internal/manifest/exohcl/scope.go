@@ -0,0 +1,182 @@
+package exohcl
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Scope evaluates the variable and locals blocks of a manifest to produce the
+// context in which all other manifest expressions are evaluated. Variables
+// are referenced as `var.name` and locals as `local.name`. Locals may
+// reference variables and other locals. When the manifest is included as a
+// module, its inputs take precedence over variable defaults.
+type Scope struct {
+	// Analysis inputs.
+	BaseDir   string
+	Files     *Files
+	Variables hcl.Blocks
+	Locals    hcl.Blocks
+	Inputs    map[string]cty.Value
+	Env       map[string]string
+
+	// Analysis outputs.
+	EvalContext *hcl.EvalContext
+}
+
+func NewScope(m *Manifest) *Scope {
+	return &Scope{
+		BaseDir:   m.baseDir(),
+		Files:     m.Files,
+		Variables: m.Content.Blocks.OfType("variable"),
+		Locals:    m.Content.Blocks.OfType("locals"),
+		Inputs:    m.Inputs,
+		Env:       m.Env,
+	}
+}
+
+func (scope *Scope) Analyze(ctx *AnalysisContext) {
+	// Variables and locals are evaluated with only functions in scope, then
+	// the resulting values are added to the context as they become available.
+	scope.EvalContext = newEvalContext(scope.BaseDir, scope.Files, scope.Env, nil)
+
+	vars := scope.analyzeVariables(ctx)
+	scope.EvalContext.Variables = map[string]cty.Value{
+		"var": cty.ObjectVal(vars),
+	}
+
+	locals := scope.analyzeLocals(ctx)
+	scope.EvalContext.Variables["local"] = cty.ObjectVal(locals)
+}
+
+func (scope *Scope) analyzeVariables(ctx *AnalysisContext) map[string]cty.Value {
+	vars := make(map[string]cty.Value, len(scope.Variables))
+	for _, block := range scope.Variables {
+		name := block.Labels[0]
+		content, diags := block.Body.Content(&hcl.BodySchema{
+			Attributes: []hcl.AttributeSchema{
+				{Name: "default"},
+				{Name: "description"},
+			},
+		})
+		ctx.AppendDiags(diags...)
+		if _, exists := vars[name]; exists {
+			ctx.AppendDiags(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate variable",
+				Detail:   fmt.Sprintf("A variable named %q was already declared.", name),
+				Subject:  block.LabelRanges[0].Ptr(),
+			})
+			continue
+		}
+		if input, ok := scope.Inputs[name]; ok {
+			vars[name] = input
+			continue
+		}
+		if content == nil {
+			continue
+		}
+		defaultAttr := content.Attributes["default"]
+		if defaultAttr == nil {
+			ctx.AppendDiags(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Missing variable value",
+				Detail:   fmt.Sprintf("Variable %q has no default value and was not given a module input.", name),
+				Subject:  block.DefRange.Ptr(),
+			})
+			vars[name] = cty.DynamicVal
+			continue
+		}
+		v, diags := defaultAttr.Expr.Value(scope.EvalContext)
+		ctx.AppendDiags(diags...)
+		vars[name] = v
+	}
+	return vars
+}
+
+func (scope *Scope) analyzeLocals(ctx *AnalysisContext) map[string]cty.Value {
+	pending := make(map[string]*hcl.Attribute)
+	for _, block := range scope.Locals {
+		if len(block.Labels) > 0 {
+			ctx.AppendDiags(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unexpected label on locals block",
+				Detail:   fmt.Sprintf("A locals block expects no labels, but has %d", len(block.Labels)),
+				Subject:  &block.LabelRanges[0],
+			})
+		}
+		attrs, diags := block.Body.JustAttributes()
+		ctx.AppendDiags(diags...)
+		for name, attr := range attrs {
+			if prev, exists := pending[name]; exists {
+				ctx.AppendDiags(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate local",
+					Detail:   fmt.Sprintf("A local named %q was already defined at %s.", name, prev.NameRange),
+					Subject:  attr.NameRange.Ptr(),
+				})
+				continue
+			}
+			pending[name] = attr
+		}
+	}
+
+	// Repeatedly evaluate all locals whose dependencies have been evaluated
+	// until no further progress can be made. Whatever remains is part of a
+	// reference cycle.
+	locals := make(map[string]cty.Value, len(pending))
+	for len(pending) > 0 {
+		var ready []string
+		for name, attr := range pending {
+			if !dependsOnPending(attr.Expr, pending) {
+				ready = append(ready, name)
+			}
+		}
+		if len(ready) == 0 {
+			break
+		}
+		sort.Strings(ready)
+		for _, name := range ready {
+			v, diags := pending[name].Expr.Value(scope.EvalContext)
+			ctx.AppendDiags(diags...)
+			locals[name] = v
+			delete(pending, name)
+		}
+		scope.EvalContext.Variables["local"] = cty.ObjectVal(locals)
+	}
+
+	names := make([]string, 0, len(pending))
+	for name := range pending {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		attr := pending[name]
+		ctx.AppendDiags(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Cycle in locals",
+			Detail:   fmt.Sprintf("The value of local %q depends on itself.", name),
+			Subject:  attr.Expr.Range().Ptr(),
+		})
+		locals[name] = cty.DynamicVal
+	}
+	return locals
+}
+
+func dependsOnPending(x hcl.Expression, pending map[string]*hcl.Attribute) bool {
+	for _, traversal := range x.Variables() {
+		if traversal.RootName() != "local" || len(traversal) < 2 {
+			continue
+		}
+		attr, ok := traversal[1].(hcl.TraverseAttr)
+		if !ok {
+			continue
+		}
+		if _, ok := pending[attr.Name]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package exohcl
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+)
+
+func analyzeComponents(t *testing.T, filename string, src string) ([]*Component, hcl.Diagnostics) {
+	return analyzeComponentsInEnv(t, filename, src, map[string]string{})
+}
+
+func analyzeComponentsInEnv(t *testing.T, filename string, src string, env map[string]string) ([]*Component, hcl.Diagnostics) {
+	ctx := context.Background()
+	file, diags := hclsyntax.ParseConfig([]byte(src), filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	m := NewManifest(filename, file)
+	m.Env = env
+	diags = Analyze(ctx, m)
+	cs := NewComponentSet(m)
+	diags = append(diags, Analyze(ctx, cs)...)
+	return cs.Components, diags
+}
+
+func TestScope(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exohcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "motd.txt"), []byte("hello\n"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "config.tmpl"), []byte("port=${port}"), 0644))
+
+	env := map[string]string{
+		"EXOHCL_TEST_HOST": "db.internal",
+	}
+	components, diags := analyzeComponentsInEnv(t, filepath.Join(dir, "exo.hcl"), `
+exo = "0.1"
+
+variable "db_name" {
+  default = "app"
+}
+
+locals {
+  db_url = "postgres://${local.db_host}/${var.db_name}"
+}
+
+locals {
+  db_host = env("EXOHCL_TEST_HOST")
+  db_user = env("EXOHCL_TEST_UNSET", "postgres")
+}
+
+components {
+  process "web" {
+    program = "web"
+    environment = {
+      DATABASE_URL = local.db_url
+      DATABASE_USER = upper(local.db_user)
+      MOTD = trimspace(file("motd.txt"))
+      CONFIG = templatefile("config.tmpl", { port = 8080 })
+    }
+  }
+}
+`, env)
+	assert.Empty(t, diags)
+	if assert.Len(t, components, 1) {
+		assert.Equal(t, `{"environment":{"CONFIG":"port=8080","DATABASE_URL":"postgres://db.internal/app","DATABASE_USER":"POSTGRES","MOTD":"hello"},"program":"web"}`, components[0].Spec)
+	}
+}
+
+func TestScopeWithoutEnvironment(t *testing.T) {
+	// Without an environment, env() is unknown rather than an error, so that
+	// manifests can be checked outside of a workspace.
+	components, diags := analyzeComponentsInEnv(t, "exo.hcl", `
+exo = "0.1"
+
+components {
+  process "web" {
+    program = "web"
+    environment = {
+      HOST = env("EXOHCL_TEST_HOST")
+    }
+  }
+}
+`, nil)
+	assert.Empty(t, diags)
+	if assert.Len(t, components, 1) {
+		assert.Equal(t, "", components[0].Spec)
+	}
+}
+
+func TestScopeErrors(t *testing.T) {
+	check := func(src string, summary string) {
+		_, diags := analyzeComponents(t, "exo.hcl", `
+exo = "0.1"
+`+src)
+		if assert.True(t, diags.HasErrors(), "%s", src) {
+			assert.Equal(t, summary, diags[0].Summary)
+		}
+	}
+	check(`
+locals {
+  a = local.b
+  b = local.a
+}
+`, "Cycle in locals")
+	check(`
+variable "x" {}
+`, "Missing variable value")
+	check(`
+variable "x" {
+  default = 1
+}
+variable "x" {
+  default = 2
+}
+`, "Duplicate variable")
+	check(`
+locals {
+  a = env("EXOHCL_TEST_UNSET")
+}
+`, "Invalid function argument")
+}
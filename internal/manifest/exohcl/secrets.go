@@ -11,15 +11,17 @@ import (
 
 type Secrets struct {
 	// Analysis inputs.
-	Block *hclsyntax.Block
+	Block       *hclsyntax.Block
+	EvalContext *hcl.EvalContext
 
 	// Analysis outputs.
 	Source string
 }
 
-func NewSecrets(block *hclsyntax.Block) *Secrets {
+func NewSecrets(block *hclsyntax.Block, evalCtx *hcl.EvalContext) *Secrets {
 	return &Secrets{
-		Block: block,
+		Block:       block,
+		EvalContext: evalCtx,
 	}
 }
 
@@ -41,7 +43,7 @@ func (s *Secrets) Analyze(ctx *AnalysisContext) {
 	ctx.AppendDiags(diags...)
 
 	sourceAttr := content.Attributes["source"]
-	s.Source, _ = AnalyzeString(ctx, sourceAttr.Expr)
+	s.Source, _ = AnalyzeString(ctx, s.EvalContext, sourceAttr.Expr)
 }
 
 type AppendSecrets struct {
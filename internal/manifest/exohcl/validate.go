@@ -1,8 +1,28 @@
 package exohcl
 
+import "github.com/hashicorp/hcl/v2/hclsyntax"
+
 // Validate deeply analyzes the manifest, collecting all diagnostics eagerly.
 func Validate(ctx *AnalysisContext, m *Manifest) {
 	m.Analyze(ctx)
 	NewEnvironment(m).Analyze(ctx)
 	NewComponentSet(m).Analyze(ctx)
+	validateProfiles(ctx, m)
+}
+
+// validateProfiles checks the overlays of the profile blocks that were not
+// applied during analysis.
+func validateProfiles(ctx *AnalysisContext, m *Manifest) {
+	body, ok := m.File.Body.(*hclsyntax.Body)
+	if !ok {
+		return
+	}
+	seen := map[string]bool{m.Profile: true}
+	for _, block := range body.Blocks {
+		if block.Type != "profile" || len(block.Labels) != 1 || seen[block.Labels[0]] {
+			continue
+		}
+		seen[block.Labels[0]] = true
+		NewProfile(m, block.Labels[0]).Analyze(ctx)
+	}
 }
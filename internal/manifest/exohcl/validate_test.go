@@ -0,0 +1,42 @@
+package exohcl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateWithoutEnvironment(t *testing.T) {
+	// As by the language server, which has no environment for env() calls.
+	src := `
+exo = "0.1"
+
+environment {
+  HOME_DIR = env("HOME")
+  DEBUG = true
+}
+
+components {
+  process "web" {
+    program = "web"
+  }
+}
+`
+	file, diags := hclsyntax.ParseConfig([]byte(src), "exo.hcl", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	m := NewManifest("exo.hcl", file)
+	ctx := &AnalysisContext{Context: context.Background()}
+	assert.NotPanics(t, func() {
+		Validate(ctx, m)
+	})
+	var summaries []string
+	for _, diag := range ctx.Diagnostics {
+		summaries = append(summaries, diag.Summary)
+	}
+	assert.Equal(t, []string{"expected environment variable to be a string"}, summaries)
+}
@@ -35,11 +35,22 @@ type Loader struct {
 	Format        string
 	Filename      string
 	Bytes         []byte
+	// Profile optionally names the profile to apply to the loaded manifest.
+	Profile string
+	// Procfile options take precedence over those of a .foreman file.
+	Procfile procfile.Options
+	// Env holds the variables read by the env function of exo manifests.
+	Env map[string]string
+	// Files controls the files that exo manifests may read. See exohcl.Files.
+	Files *exohcl.Files
 }
 
 func (l *Loader) Load(ctx *exohcl.AnalysisContext) (*exohcl.Manifest, error) {
 	m := &exohcl.Manifest{
 		Filename: l.Filename,
+		Profile:  l.Profile,
+		Env:      l.Env,
+		Files:    l.Files,
 	}
 
 	format := l.Format
@@ -59,7 +70,10 @@ func (l *Loader) Load(ctx *exohcl.AnalysisContext) (*exohcl.Manifest, error) {
 	}
 	switch format {
 	case "procfile":
-		importer = &procfile.Importer{}
+		importer = &procfile.Importer{
+			Dir:     l.dir(),
+			Options: l.Procfile,
+		}
 	case "compose":
 		importer = &compose.Importer{
 			ProjectName: l.WorkspaceName,
@@ -75,3 +89,11 @@ func (l *Loader) Load(ctx *exohcl.AnalysisContext) (*exohcl.Manifest, error) {
 	m.Analyze(ctx)
 	return m, nil
 }
+
+// dir returns the directory containing the manifest file, if known.
+func (l *Loader) dir() string {
+	if l.Filename == "" || l.Filename == "/dev/stdin" {
+		return ""
+	}
+	return filepath.Dir(l.Filename)
+}
@@ -0,0 +1,116 @@
+package procfile
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ForemanFile is the name of foreman's file of default options, which is read
+// from the directory containing the Procfile.
+const ForemanFile = ".foreman"
+
+// Options control how processes are imported from a Procfile, mirroring the
+// options of foreman. Zero values are unset.
+type Options struct {
+	// Port is the base port. Processes are assigned ports in steps of PortStep,
+	// and each instance of a process is assigned the next port after the
+	// previous instance. Defaults to BasePort.
+	Port int
+	// Formation is the number of instances of each process to run, such as
+	// "web=2,worker=1". The name "all" sets the number of instances of processes
+	// that are not listed. Defaults to "all=1".
+	Formation string
+	// EnvFiles are paths of env files, relative to the directory containing the
+	// Procfile, whose variables are given to every process. Later files take
+	// precedence over earlier ones.
+	EnvFiles []string
+}
+
+// ParseForemanFile parses the YAML contents of a .foreman file. Formation may
+// also be given as "concurrency", and env files as a comma separated string.
+func ParseForemanFile(bs []byte) (*Options, error) {
+	var raw struct {
+		Port        int    `yaml:"port"`
+		Formation   string `yaml:"formation"`
+		Concurrency string `yaml:"concurrency"`
+		Env         string `yaml:"env"`
+	}
+	if err := yaml.Unmarshal(bs, &raw); err != nil {
+		return nil, err
+	}
+	opts := &Options{
+		Port:      raw.Port,
+		Formation: raw.Formation,
+	}
+	if opts.Formation == "" {
+		opts.Formation = raw.Concurrency
+	}
+	if raw.Env != "" {
+		for _, path := range strings.Split(raw.Env, ",") {
+			if path = strings.TrimSpace(path); path != "" {
+				opts.EnvFiles = append(opts.EnvFiles, path)
+			}
+		}
+	}
+	return opts, nil
+}
+
+// Merge returns options with the set values of override taking precedence
+// over those of opts.
+func (opts Options) Merge(override Options) Options {
+	if override.Port != 0 {
+		opts.Port = override.Port
+	}
+	if override.Formation != "" {
+		opts.Formation = override.Formation
+	}
+	if override.EnvFiles != nil {
+		opts.EnvFiles = override.EnvFiles
+	}
+	return opts
+}
+
+// Formation is the number of instances of each process.
+type Formation struct {
+	Counts map[string]int
+	// Default is the number of instances of unlisted processes.
+	Default int
+}
+
+func ParseFormation(s string) (*Formation, error) {
+	formation := &Formation{
+		Counts:  make(map[string]int),
+		Default: 1,
+	}
+	if strings.TrimSpace(s) == "" {
+		return formation, nil
+	}
+	formation.Default = 0
+	for _, entry := range strings.Split(s, ",") {
+		parts := strings.SplitN(entry, "=", 2)
+		name := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || name == "" {
+			return nil, fmt.Errorf("invalid formation entry %q, expected <process>=<count>", strings.TrimSpace(entry))
+		}
+		count, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("invalid count for process %q in formation", name)
+		}
+		if name == "all" {
+			formation.Default = count
+		} else {
+			formation.Counts[name] = count
+		}
+	}
+	return formation, nil
+}
+
+func (f *Formation) Count(process string) int {
+	if count, ok := f.Counts[process]; ok {
+		return count
+	}
+	return f.Default
+}
@@ -0,0 +1,64 @@
+package procfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseForemanFile(t *testing.T) {
+	opts, err := ParseForemanFile([]byte(`
+port: 3000
+concurrency: web=2,worker=1
+env: .env, .env.local
+`))
+	if assert.NoError(t, err) {
+		assert.Equal(t, &Options{
+			Port:      3000,
+			Formation: "web=2,worker=1",
+			EnvFiles:  []string{".env", ".env.local"},
+		}, opts)
+	}
+
+	opts, err = ParseForemanFile([]byte("formation: all=2\n"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, &Options{Formation: "all=2"}, opts)
+	}
+
+	assert.Equal(t, Options{
+		Port:      3000,
+		Formation: "web=1",
+		EnvFiles:  []string{".env"},
+	}, Options{
+		Port:      3000,
+		Formation: "all=2",
+		EnvFiles:  []string{".env"},
+	}.Merge(Options{
+		Formation: "web=1",
+	}))
+}
+
+func TestParseFormation(t *testing.T) {
+	formation, err := ParseFormation("")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, formation.Count("web"))
+	}
+
+	formation, err = ParseFormation("web=2, worker=0")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, formation.Count("web"))
+		assert.Equal(t, 0, formation.Count("worker"))
+		assert.Equal(t, 0, formation.Count("clock"))
+	}
+
+	formation, err = ParseFormation("all=3,web=1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, formation.Count("web"))
+		assert.Equal(t, 3, formation.Count("clock"))
+	}
+
+	_, err = ParseFormation("web")
+	assert.Error(t, err)
+	_, err = ParseFormation("web=-1")
+	assert.Error(t, err)
+}
@@ -2,6 +2,10 @@ package procfile
 
 import (
 	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
 
@@ -9,12 +13,20 @@ import (
 	"github.com/deref/exo/internal/manifest/exohcl/hclgen"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/joho/godotenv"
 )
 
 const BasePort = 5000
 const PortStep = 100
 
-type Importer struct{}
+type Importer struct {
+	// Dir is the directory containing the Procfile, from which the .foreman
+	// file and env files are read. If empty, there is no .foreman file and env
+	// files are relative to the working directory.
+	Dir string
+	// Options take precedence over those of the .foreman file.
+	Options Options
+}
 
 func (imp *Importer) Import(ctx *exohcl.AnalysisContext, bs []byte) *hcl.File {
 	b := exohcl.NewBuilder(bs)
@@ -25,72 +37,183 @@ func (imp *Importer) Import(ctx *exohcl.AnalysisContext, bs []byte) *hcl.File {
 		return b.Build()
 	}
 
-	port := BasePort
-	for _, p := range procfile.Processes {
-		// Assign default PORT, merge in specified environment.
-		environment := map[string]string{
-			"PORT": strconv.Itoa(port),
-		}
-		for name, value := range p.Environment {
-			environment[name] = value
-		}
-		port += PortStep
+	opts := imp.loadOptions(ctx)
+	basePort := BasePort
+	if opts.Port != 0 {
+		basePort = opts.Port
+	}
+	formation, err := ParseFormation(opts.Formation)
+	if err != nil {
+		ctx.AppendDiags(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "invalid formation",
+			Detail:   err.Error(),
+		})
+		return b.Build()
+	}
+	imp.checkFormation(ctx, procfile, formation)
+	envFiles := imp.resolveEnvFiles(ctx, opts.EnvFiles)
 
+	for index, p := range procfile.Processes {
 		// Get component name.
-		name := exohcl.MangleName(p.Name)
-		if name != p.Name {
+		baseName := exohcl.MangleName(p.Name)
+		if baseName != p.Name {
 			var subject *hcl.Range
-			ctx.AppendDiags(exohcl.NewRenameWarning(p.Name, name, subject))
+			ctx.AppendDiags(exohcl.NewRenameWarning(p.Name, baseName, subject))
 		}
 
-		// Build HCL attributes.
-		args := make([]hclsyntax.Expression, len(p.Arguments))
-		for i, arg := range p.Arguments {
-			args[i] = hclgen.NewStringLiteral(arg, p.Range)
+		// Like foreman, each process has a block of ports, and each instance of
+		// the process uses the next port in the block.
+		count := formation.Count(p.Name)
+		for instance := 1; instance <= count; instance++ {
+			name := baseName
+			if count > 1 {
+				name = fmt.Sprintf("%s-%d", baseName, instance)
+			}
+			port := basePort + index*PortStep + instance - 1
+			b.AddComponentBlock(newProcessBlock(p, name, port, envFiles))
 		}
-		attrs := []*hclsyntax.Attribute{
-			{
-				Name:     "program",
-				Expr:     hclgen.NewStringLiteral(p.Program, p.Range),
-				SrcRange: p.Range,
-			},
-			{
-				Name:     "arguments",
-				Expr:     hclgen.NewTuple(args, p.Range),
-				SrcRange: p.CommandRange,
-			},
+	}
+	return b.Build()
+}
+
+func newProcessBlock(p Process, name string, port int, envFiles []string) *hclgen.Block {
+	// Assign default PORT, merge in specified environment. Both take
+	// precedence over the env files, which are read when the process starts.
+	environment := make(map[string]string, len(p.Environment)+1)
+	environment["PORT"] = strconv.Itoa(port)
+	for k, v := range p.Environment {
+		environment[k] = v
+	}
+
+	// Build HCL attributes.
+	args := make([]hclsyntax.Expression, len(p.Arguments))
+	for i, arg := range p.Arguments {
+		args[i] = hclgen.NewStringLiteral(arg, p.Range)
+	}
+	attrs := []*hclsyntax.Attribute{
+		{
+			Name:     "program",
+			Expr:     hclgen.NewStringLiteral(p.Program, p.Range),
+			SrcRange: p.Range,
+		},
+		{
+			Name:     "arguments",
+			Expr:     hclgen.NewTuple(args, p.Range),
+			SrcRange: p.CommandRange,
+		},
+	}
+	if len(environment) > 0 {
+		envExpr := &hclsyntax.ObjectConsExpr{
+			SrcRange: p.Range,
 		}
-		if len(environment) > 0 {
-			envExpr := &hclsyntax.ObjectConsExpr{
-				SrcRange: p.Range,
-			}
 
-			environmentKeys := make([]string, 0, len(environment))
-			for k := range environment {
-				environmentKeys = append(environmentKeys, k)
-			}
-			sort.Strings(environmentKeys)
+		environmentKeys := make([]string, 0, len(environment))
+		for k := range environment {
+			environmentKeys = append(environmentKeys, k)
+		}
+		sort.Strings(environmentKeys)
 
-			for _, k := range environmentKeys {
-				envExpr.Items = append(envExpr.Items, hclsyntax.ObjectConsItem{
-					KeyExpr:   hclgen.NewObjStringKey(k, p.Range),
-					ValueExpr: hclgen.NewStringLiteral(environment[k], p.Range),
-				})
-			}
-			attrs = append(attrs, &hclsyntax.Attribute{
-				Name:     "environment",
-				Expr:     envExpr,
-				SrcRange: p.Range,
+		for _, k := range environmentKeys {
+			envExpr.Items = append(envExpr.Items, hclsyntax.ObjectConsItem{
+				KeyExpr:   hclgen.NewObjStringKey(k, p.Range),
+				ValueExpr: hclgen.NewStringLiteral(environment[k], p.Range),
 			})
 		}
+		attrs = append(attrs, &hclsyntax.Attribute{
+			Name:     "environment",
+			Expr:     envExpr,
+			SrcRange: p.Range,
+		})
+	}
+	if len(envFiles) > 0 {
+		paths := make([]hclsyntax.Expression, len(envFiles))
+		for i, path := range envFiles {
+			paths[i] = hclgen.NewStringLiteral(path, p.Range)
+		}
+		attrs = append(attrs, &hclsyntax.Attribute{
+			Name:     "envFiles",
+			Expr:     hclgen.NewTuple(paths, p.Range),
+			SrcRange: p.Range,
+		})
+	}
+
+	return &hclgen.Block{
+		Type:   "process",
+		Labels: []string{name},
+		Body: &hclgen.Body{
+			Attributes: attrs,
+		},
+	}
+}
 
-		b.AddComponentBlock(&hclgen.Block{
-			Type:   "process",
-			Labels: []string{name},
-			Body: &hclgen.Body{
-				Attributes: attrs,
-			},
+// loadOptions merges the options of the importer over those of the .foreman
+// file, if there is one.
+func (imp *Importer) loadOptions(ctx *exohcl.AnalysisContext) Options {
+	if imp.Dir == "" {
+		return imp.Options
+	}
+	bs, err := ioutil.ReadFile(filepath.Join(imp.Dir, ForemanFile))
+	if os.IsNotExist(err) {
+		return imp.Options
+	}
+	var opts *Options
+	if err == nil {
+		opts, err = ParseForemanFile(bs)
+	}
+	if err != nil {
+		ctx.AppendDiags(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "invalid .foreman file",
+			Detail:   err.Error(),
 		})
+		return imp.Options
 	}
-	return b.Build()
+	return opts.Merge(imp.Options)
+}
+
+// checkFormation warns about formation entries that match no process, since
+// they are likely to be typos.
+func (imp *Importer) checkFormation(ctx *exohcl.AnalysisContext, procfile *Procfile, formation *Formation) {
+	declared := make(map[string]bool, len(procfile.Processes))
+	for _, p := range procfile.Processes {
+		declared[p.Name] = true
+	}
+	names := make([]string, 0, len(formation.Counts))
+	for name := range formation.Counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !declared[name] {
+			ctx.AppendDiags(&hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "unknown process in formation",
+				Detail:   fmt.Sprintf("The formation sets the count of process %q, which is not in the Procfile.", name),
+			})
+		}
+	}
+}
+
+// resolveEnvFiles returns the paths of the given env files relative to the
+// directory of the Procfile. Env files are given to processes by reference,
+// so that their values are not stored in component specs, but are checked
+// here so that missing files are reported when the Procfile is applied.
+func (imp *Importer) resolveEnvFiles(ctx *exohcl.AnalysisContext, paths []string) []string {
+	resolved := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if imp.Dir != "" && !filepath.IsAbs(path) {
+			path = filepath.Join(imp.Dir, path)
+		}
+		if _, err := godotenv.Read(path); err != nil {
+			ctx.AppendDiags(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "cannot read env file",
+				Detail:   err.Error(),
+			})
+			continue
+		}
+		resolved = append(resolved, path)
+	}
+	return resolved
 }
@@ -3,6 +3,10 @@ package procfile
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -32,12 +36,12 @@ chain: true && thing
 exo = "0.1"
 components {
   process "thing" {
-    program     = "/bin/sh"
+    program     = "bash"
     arguments   = ["-c", "if make think; then X=1 ./thing \"$@\"; fi"]
     environment = { PORT = "5000" }
   }
   process "chain" {
-    program     = "/bin/sh"
+    program     = "bash"
     arguments   = ["-c", "true && thing"]
     environment = { PORT = "5100" }
   }
@@ -45,10 +49,84 @@ components {
 `)
 }
 
+func TestImportForeman(t *testing.T) {
+	dir, err := ioutil.TempDir("", "procfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, ".foreman"), []byte(`
+port: 3000
+formation: web=2,worker=1
+env: .env.local
+`), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, ".env.local"), []byte("PORT=1\nRAILS_ENV=development\n"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, ".env.test"), []byte("RAILS_ENV=test\n"), 0644))
+
+	procfile := `
+web: bundle exec rails server
+worker: RAILS_ENV=production bundle exec sidekiq
+clock: bundle exec clockwork
+`
+	// Env files are passed by reference, so their values are not part of the
+	// component specs.
+	local := filepath.Join(dir, ".env.local")
+	test := filepath.Join(dir, ".env.test")
+	testImportWith(t, "foreman file", &Importer{Dir: dir}, procfile, fmt.Sprintf(`
+exo = "0.1"
+components {
+  process "web-1" {
+    program     = "bundle"
+    arguments   = ["exec", "rails", "server"]
+    environment = { PORT = "3000" }
+    envFiles    = [%[1]q]
+  }
+  process "web-2" {
+    program     = "bundle"
+    arguments   = ["exec", "rails", "server"]
+    environment = { PORT = "3001" }
+    envFiles    = [%[1]q]
+  }
+  process "worker" {
+    program     = "bundle"
+    arguments   = ["exec", "sidekiq"]
+    environment = { PORT = "3100", RAILS_ENV = "production" }
+    envFiles    = [%[1]q]
+  }
+}
+`, local))
+	testImportWith(t, "options override foreman file", &Importer{
+		Dir: dir,
+		Options: Options{
+			Formation: "all=1,web=0",
+			EnvFiles:  []string{".env.local", ".env.test"},
+		},
+	}, procfile, fmt.Sprintf(`
+exo = "0.1"
+components {
+  process "worker" {
+    program     = "bundle"
+    arguments   = ["exec", "sidekiq"]
+    environment = { PORT = "3100", RAILS_ENV = "production" }
+    envFiles    = [%q, %q]
+  }
+  process "clock" {
+    program     = "bundle"
+    arguments   = ["exec", "clockwork"]
+    environment = { PORT = "3200" }
+    envFiles    = [%q, %q]
+  }
+}
+`, local, test, local, test))
+}
+
 func testImport(t *testing.T, name, procfile, hcl string) {
+	testImportWith(t, name, &Importer{}, procfile, hcl)
+}
+
+func testImportWith(t *testing.T, name string, importer *Importer, procfile, hcl string) {
 	t.Run(name, func(t *testing.T) {
 		ctx := context.Background()
-		importer := &Importer{}
 		analysisContext := &exohcl.AnalysisContext{
 			Context: ctx,
 		}
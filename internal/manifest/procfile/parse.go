@@ -87,6 +87,19 @@ func Parse(r io.Reader) (*Procfile, hcl.Diagnostics) {
 		}
 		name := string(parts[0]) // TODO: Validate name is alphanumeric.
 		process, err := ParseCommand(bytes.NewReader(parts[1]))
+		var unsupportedErr *unsupportedError
+		if errors.As(err, &unsupportedErr) {
+			// The command is valid shell code, but cannot be converted to a
+			// structured process, so pass it through to the shell unchanged.
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "unsupported shell syntax",
+				Detail:   fmt.Sprintf("The command of process %q cannot be imported as a program with arguments (%v), so it is run by bash instead.", name, err),
+				Subject:  &rng, // TODO: Constrain to range after the colon.
+			})
+			process = shellProcess(string(parts[1]))
+			err = nil
+		}
 		if err != nil {
 			diags = append(diags, &hcl.Diagnostic{
 				Severity: hcl.DiagError,
@@ -104,6 +117,30 @@ func Parse(r io.Reader) (*Procfile, hcl.Diagnostics) {
 	return &procfile, diags
 }
 
+// unsupportedError is an error converting valid shell code to a process, as
+// opposed to an error in the command itself, such as invalid syntax or a
+// missing program.
+type unsupportedError struct {
+	err error
+}
+
+func (err *unsupportedError) Error() string {
+	return err.err.Error()
+}
+
+func (err *unsupportedError) Unwrap() error {
+	return err.err
+}
+
+func unsupportedf(format string, v ...interface{}) error {
+	return &unsupportedError{fmt.Errorf(format, v...)}
+}
+
+// ParseCommand parses a command in to a program, arguments and environment.
+// Shell code that cannot be represented that way is an error, unless it is a
+// compound command, such as `a && b`, which is passed through to the shell.
+// Errors for valid shell code that is merely unsupported are
+// *unsupportedError.
 func ParseCommand(r io.Reader) (*Process, error) {
 	parser := syntax.NewParser(syntax.Variant(syntax.LangBash))
 	name := ""
@@ -111,22 +148,28 @@ func ParseCommand(r io.Reader) (*Process, error) {
 	if err != nil {
 		return nil, err
 	}
+	if len(file.Stmts) == 0 {
+		return nil, errors.New("expected command")
+	}
 	if len(file.Stmts) != 1 {
-		return nil, errors.New("expected exactly one bash statement")
+		return nil, unsupportedf("expected exactly one bash statement")
 	}
 
 	stmt := file.Stmts[0]
 	if len(stmt.Comments) > 0 {
-		return nil, errors.New("unexpected comments")
+		return nil, unsupportedf("unexpected comments")
 	}
 	if stmt.Semicolon.IsValid() {
-		return nil, fmt.Errorf("unsupported syntax at column %d", stmt.Semicolon.Col())
+		return nil, unsupportedf("unsupported syntax at column %d", stmt.Semicolon.Col())
 	}
 	if stmt.Negated {
-		return nil, fmt.Errorf("unsupported: command negation")
+		return nil, unsupportedf("unsupported: command negation")
+	}
+	if stmt.Background || stmt.Coprocess {
+		return nil, unsupportedf("unsupported: asynchronous command")
 	}
 	if len(stmt.Redirs) > 0 {
-		return nil, errors.New("unsupported: redirection")
+		return nil, unsupportedf("unsupported: redirection")
 	}
 
 	process := Process{
@@ -134,11 +177,16 @@ func ParseCommand(r io.Reader) (*Process, error) {
 	}
 
 	if call, ok := stmt.Cmd.(*syntax.CallExpr); ok {
+		// A command of only assignments runs nothing.
+		if len(call.Args) < 1 {
+			return nil, errors.New("expected program path")
+		}
+
 		// Parse environment variable assignments.
 		for _, assign := range call.Assigns {
 			name := assign.Name.Value
 			if assign.Append || assign.Naked || assign.Index != nil || assign.Array != nil {
-				return nil, fmt.Errorf("unsupported assignment for %q", name)
+				return nil, unsupportedf("unsupported assignment for %q", name)
 			}
 			value, err := wordToString(assign.Value)
 			if err != nil {
@@ -148,9 +196,6 @@ func ParseCommand(r io.Reader) (*Process, error) {
 		}
 
 		// Parse program.
-		if len(call.Args) < 1 {
-			return nil, errors.New("expected program path")
-		}
 		process.Program, err = wordToString(call.Args[0])
 		if err != nil {
 			return nil, fmt.Errorf("parsing program path: %w", err)
@@ -175,14 +220,22 @@ func ParseCommand(r io.Reader) (*Process, error) {
 		if err := printer.Print(&sb, stmt); err != nil {
 			panic(fmt.Errorf("printing shell node: %w", err))
 		}
-		code := sb.String()
-
-		process.Program = "/bin/sh"
-		process.Arguments = []string{"-c", code}
+		process = *shellProcess(sb.String())
 	}
 	return &process, nil
 }
 
+// shellProcess returns a process that runs code with bash, since the code is
+// parsed as bash and may use syntax that /bin/sh does not support. Heroku
+// also runs Procfile commands with bash.
+func shellProcess(code string) *Process {
+	return &Process{
+		Program:     "bash",
+		Arguments:   []string{"-c", code},
+		Environment: make(map[string]string),
+	}
+}
+
 func wordToString(word *syntax.Word) (string, error) {
 	strs, err := wordsToStrings([]*syntax.Word{word})
 	if err != nil {
@@ -191,8 +244,15 @@ func wordToString(word *syntax.Word) (string, error) {
 	return strings.Join(strs, " "), nil
 }
 
+// wordsToStrings expands words without a shell environment. Words that need
+// one to expand, such as those with variables or command substitutions, are
+// unsupported.
 func wordsToStrings(words []*syntax.Word) ([]string, error) {
-	return expand.Fields(expandConfig, words...)
+	fields, err := expand.Fields(expandConfig, words...)
+	if err != nil {
+		return nil, &unsupportedError{err}
+	}
+	return fields, nil
 }
 
 var expandConfig = &expand.Config{
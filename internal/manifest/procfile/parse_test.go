@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/hcl/v2"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -65,3 +66,54 @@ func TestParseCommand(t *testing.T) {
 	assertNoParse("unsupported: glob patterns", `foo *`)
 	assertNoParse("unbound variable", `$X`)
 }
+
+func TestParseShellFallback(t *testing.T) {
+	procfile, diags := Parse(strings.NewReader(`
+web: bundle exec puma -p $PORT
+release: ./migrate && ./seed
+log: tail -f log/*.log > /dev/null
+version: echo $(git rev-parse HEAD)
+check: ! test -f maintenance
+maintenance: [[ -f maintenance ]] && ./wait
+bad: "unclosed
+empty:
+assign: RAILS_ENV=production
+`))
+	type summary struct {
+		Name      string
+		Program   string
+		Arguments []string
+	}
+	var actual []summary
+	for _, p := range procfile.Processes {
+		actual = append(actual, summary{p.Name, p.Program, p.Arguments})
+	}
+	assert.Equal(t, []summary{
+		{"web", "bash", []string{"-c", "bundle exec puma -p $PORT"}},
+		{"release", "bash", []string{"-c", "./migrate && ./seed"}},
+		{"log", "bash", []string{"-c", "tail -f log/*.log > /dev/null"}},
+		{"version", "bash", []string{"-c", "echo $(git rev-parse HEAD)"}},
+		{"check", "bash", []string{"-c", "! test -f maintenance"}},
+		{"maintenance", "bash", []string{"-c", "[[ -f maintenance ]] && ./wait"}},
+	}, actual)
+
+	var warnings, errors []string
+	for _, diag := range diags {
+		if diag.Severity == hcl.DiagWarning {
+			warnings = append(warnings, diag.Detail)
+		} else {
+			errors = append(errors, diag.Summary)
+		}
+	}
+	if assert.Len(t, warnings, 4) {
+		assert.Contains(t, warnings[0], `process "web"`)
+		assert.Contains(t, warnings[0], "unbound variable")
+		assert.Contains(t, warnings[1], "unsupported: redirection")
+	}
+	// Commands that run nothing are errors rather than shell code.
+	if assert.Len(t, errors, 3) {
+		assert.Contains(t, errors[0], "reached EOF without closing quote")
+		assert.Equal(t, "expected command", errors[1])
+		assert.Equal(t, "expected program path", errors[2])
+	}
+}
@@ -2,8 +2,12 @@ package core
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/deref/exo/internal/chrono"
 	"github.com/deref/exo/internal/core/api"
+	eventd "github.com/deref/exo/internal/eventd/api"
+	"github.com/deref/exo/internal/providers/core/components/log"
 	"github.com/deref/exo/internal/util/logging"
 )
 
@@ -20,6 +24,10 @@ type ComponentBase struct {
 	WorkspaceRoot        string
 	WorkspaceEnvironment map[string]string
 	Logger               logging.Logger
+
+	// Describes another component in the same workspace. Returns nil if no
+	// component matches ref.
+	LookupComponent func(ctx context.Context, ref string) (*api.ComponentDescription, error)
 }
 
 func (c ComponentBase) GetComponentID() string {
@@ -34,3 +42,31 @@ func (c *ComponentBase) Build(ctx context.Context, input *api.BuildInput) (*api.
 	// Default no-op implemention.
 	return &api.BuildOutput{}, nil
 }
+
+func (c *ComponentBase) PlanUpdate(ctx context.Context, input *api.PlanUpdateInput) (*api.PlanUpdateOutput, error) {
+	// Default implementation replaces the component on any change of spec.
+	return &api.PlanUpdateOutput{
+		Replace: input.OldSpec != input.NewSpec,
+	}, nil
+}
+
+func (c *ComponentBase) Update(ctx context.Context, input *api.UpdateInput) (*api.UpdateOutput, error) {
+	// Default no-op implementation. Only reached when the spec is unchanged.
+	return &api.UpdateOutput{}, nil
+}
+
+// Warnf reports a non-fatal problem with this component to the workspace's
+// system event stream.
+func (c ComponentBase) Warnf(ctx context.Context, format string, v ...interface{}) {
+	message := fmt.Sprintf("warning: %s: %s", c.ComponentName, fmt.Sprintf(format, v...))
+	eventStore := log.CurrentEventStore(ctx)
+	_, err := eventStore.AddEvent(ctx, &eventd.AddEventInput{
+		Stream:    c.WorkspaceID,
+		Timestamp: chrono.NowString(ctx),
+		Message:   message,
+	})
+	if err != nil {
+		c.Logger.Infof("error adding workspace event: %v", err)
+		c.Logger.Infof("event message was: %s", message)
+	}
+}
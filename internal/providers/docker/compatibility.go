@@ -0,0 +1,90 @@
+package docker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Compatibility accumulates the differences between a spec and an existing
+// Docker resource that is being considered for adoption. Errors are
+// differences that would change the behavior of the resource, and so prevent
+// adoption. Warnings are differences in metadata only.
+type Compatibility struct {
+	Errors   []string
+	Warnings []string
+}
+
+func (compat *Compatibility) Errorf(format string, v ...interface{}) {
+	compat.Errors = append(compat.Errors, fmt.Sprintf(format, v...))
+}
+
+func (compat *Compatibility) Warnf(format string, v ...interface{}) {
+	compat.Warnings = append(compat.Warnings, fmt.Sprintf(format, v...))
+}
+
+// CheckString records an error if an explicitly specified value differs from
+// the existing one. The zero value is treated as the default.
+func (compat *Compatibility) CheckString(field string, want, got, defaultValue string) {
+	if want == "" {
+		want = defaultValue
+	}
+	if got == "" {
+		got = defaultValue
+	}
+	if want != got {
+		compat.Errorf("%s is %q, but spec requires %q", field, got, want)
+	}
+}
+
+func (compat *Compatibility) CheckBool(field string, want, got bool) {
+	if want != got {
+		compat.Errorf("%s is %t, but spec requires %t", field, got, want)
+	}
+}
+
+// CheckOptions records an error for every option in want that is missing from
+// or different in got, and a warning for every option only in got.
+func (compat *Compatibility) CheckOptions(field string, want, got map[string]string) {
+	for _, k := range sortedKeys(want) {
+		if gotValue, ok := got[k]; !ok {
+			compat.Errorf("%s %q is not set, but spec requires %q", field, k, want[k])
+		} else if gotValue != want[k] {
+			compat.Errorf("%s %q is %q, but spec requires %q", field, k, gotValue, want[k])
+		}
+	}
+	for _, k := range sortedKeys(got) {
+		if _, ok := want[k]; !ok {
+			compat.Warnf("%s %q is set to %q, but is not in spec", field, k, got[k])
+		}
+	}
+}
+
+// CheckLabels records a warning for every label in want that is missing from
+// or different in got. Labels cannot be changed without recreating the
+// resource, but do not affect its behavior.
+func (compat *Compatibility) CheckLabels(want, got map[string]string) {
+	for _, k := range sortedKeys(want) {
+		if gotValue, ok := got[k]; !ok {
+			compat.Warnf("label %q is missing", k)
+		} else if gotValue != want[k] {
+			compat.Warnf("label %q is %q, but spec has %q", k, gotValue, want[k])
+		}
+	}
+}
+
+func (compat *Compatibility) Err(kind, name string) error {
+	if len(compat.Errors) == 0 {
+		return nil
+	}
+	return fmt.Errorf("existing %s %q is incompatible with spec: %s; remove it or declare it as external", kind, name, strings.Join(compat.Errors, "; "))
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,24 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompatibility(t *testing.T) {
+	var compat Compatibility
+	compat.CheckString("driver", "", "local", "local")
+	compat.CheckOptions("driver option", map[string]string{"type": "tmpfs"}, map[string]string{"type": "tmpfs", "o": "size=100m"})
+	compat.CheckLabels(map[string]string{"team": "data"}, map[string]string{})
+	assert.Empty(t, compat.Errors)
+	assert.Equal(t, []string{
+		`driver option "o" is set to "size=100m", but is not in spec`,
+		`label "team" is missing`,
+	}, compat.Warnings)
+	assert.NoError(t, compat.Err("volume", "db"))
+
+	compat.CheckString("driver", "nfs", "local", "local")
+	compat.CheckBool("internal", true, false)
+	assert.EqualError(t, compat.Err("volume", "db"), `existing volume "db" is incompatible with spec: driver is "local", but spec requires "nfs"; internal is false, but spec requires true; remove it or declare it as external`)
+}
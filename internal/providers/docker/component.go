@@ -4,12 +4,11 @@ import (
 	"github.com/deref/exo/internal/providers/core"
 	"github.com/deref/exo/internal/providers/docker/compose"
 	"github.com/deref/exo/internal/util/yamlutil"
-	dockerclient "github.com/docker/docker/client"
 )
 
 type ComponentBase struct {
 	core.ComponentBase
-	Docker *dockerclient.Client
+	Docker Runtime
 }
 
 func (c ComponentBase) GetExoLabels() map[string]string {
@@ -0,0 +1,291 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/deref/exo/internal/providers/docker"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-connections/nat"
+	"mvdan.cc/sh/v3/shell"
+)
+
+// NOTE [ADOPT COMPOSE CONTAINERS]:
+// When adoption is requested, a container that docker-compose created for the
+// same project and service is taken over instead of being replaced, so that
+// migrating a running project does not restart it. The image, command,
+// entrypoint, environment, published ports and mounts must match what exo
+// would have created; otherwise the container is replaced. Unlike adopted
+// networks and volumes, adopted containers are owned by exo from then on, and
+// are removed when the component is disposed. Since labels and logging
+// configuration cannot be changed without recreating the container, an
+// adopted container lacks exo's labels and its logs are not collected until
+// it is next re-created. See also NOTE: [ADOPT COMPOSE RESOURCES].
+
+// adoptComposeContainer takes over the container that docker-compose created
+// for spec's service, if there is one and it is compatible with spec. A
+// stopped container is started if start is true.
+func (c *Container) adoptComposeContainer(ctx context.Context, spec *Spec, start bool) (adopted bool, err error) {
+	labels := spec.Labels.Map()
+	project := labels["com.docker.compose.project"]
+	service := labels["com.docker.compose.service"]
+	if project == "" || service == "" {
+		return false, nil
+	}
+
+	containers, err := c.Docker.ContainerList(ctx, types.ContainerListOptions{
+		All: true,
+		Filters: filters.NewArgs(
+			filters.Arg("label", "com.docker.compose.project="+project),
+			filters.Arg("label", "com.docker.compose.service="+service),
+			filters.Arg("label", "com.docker.compose.oneoff=False"),
+		),
+	})
+	if err != nil {
+		return false, fmt.Errorf("listing containers: %w", err)
+	}
+	if len(containers) == 0 {
+		return false, nil
+	}
+	if len(containers) > 1 {
+		c.Warnf(ctx, "found %d docker-compose containers for service %q, adopting only the first", len(containers), service)
+	}
+
+	inspection, err := c.Docker.ContainerInspect(ctx, containers[0].ID)
+	if err != nil {
+		return false, fmt.Errorf("inspecting container: %w", err)
+	}
+	imageInspection, _, err := c.Docker.ImageInspectWithRaw(ctx, inspection.Image)
+	if err != nil {
+		return false, fmt.Errorf("inspecting image: %w", err)
+	}
+	c.setImageState(imageInspection)
+
+	name := strings.TrimPrefix(inspection.Name, "/")
+	compat := c.checkAdoptable(spec, inspection, imageInspection)
+	if len(compat.Errors) > 0 {
+		c.Warnf(ctx, "replacing existing container %q: %s", name, strings.Join(compat.Errors, "; "))
+		return false, nil
+	}
+	for _, warning := range compat.Warnings {
+		c.Warnf(ctx, "adopting existing container %q: %s", name, warning)
+	}
+
+	c.State.ContainerID = inspection.ID
+	c.State.Running = inspection.State.Running
+	c.Warnf(ctx, "adopted existing container %q; its logs will be collected once it is re-created", name)
+
+	if start && !c.State.Running {
+		if err := c.start(ctx); err != nil {
+			c.Logger.Infof("starting container %q: %v", c.State.ContainerID, err)
+		}
+	}
+	return true, nil
+}
+
+// checkAdoptable compares an existing container with the container that spec
+// would create. The image state must have been set from the container's image.
+func (c *Container) checkAdoptable(spec *Spec, inspection types.ContainerJSON, image types.ImageInspect) *docker.Compatibility {
+	var compat docker.Compatibility
+	if inspection.Config == nil || inspection.HostConfig == nil {
+		compat.Errorf("container has no configuration")
+		return &compat
+	}
+	if spec.Image.Value != "" {
+		compat.CheckString("image", spec.Image.Value, inspection.Config.Image, "")
+	}
+	if spec.ContainerName.Value != "" {
+		name := strings.TrimPrefix(inspection.Name, "/")
+		if name != spec.ContainerName.Value {
+			compat.Warnf("name is %q, but spec has %q", name, spec.ContainerName.Value)
+		}
+	}
+	compat.CheckLabels(spec.Labels.Map(), inspection.Config.Labels)
+	c.checkAdoptableCommand(&compat, spec, inspection.Config)
+	var imageEnv []string
+	if image.Config != nil {
+		imageEnv = image.Config.Env
+	}
+	c.checkAdoptableEnvironment(&compat, spec, inspection.Config.Env, imageEnv)
+	checkAdoptablePorts(&compat, spec, inspection.HostConfig.PortBindings)
+	var imageVolumes map[string]struct{}
+	if image.Config != nil {
+		imageVolumes = image.Config.Volumes
+	}
+	c.checkAdoptableMounts(&compat, spec, inspection.Mounts, imageVolumes)
+	return &compat
+}
+
+func (c *Container) checkAdoptableCommand(compat *docker.Compatibility, spec *Spec, config *container.Config) {
+	want := c.command(spec)
+	// docker-compose splits string commands into words rather than running
+	// them with the image's shell.
+	matches := stringSlicesEqual(want, config.Cmd)
+	if !matches && spec.Command.IsShellForm {
+		if words, err := shell.Fields(spec.Command.Parts[0].Value, nil); err == nil {
+			matches = stringSlicesEqual(words, config.Cmd)
+		}
+	}
+	if !matches {
+		compat.Errorf("command is %q, but spec requires %q", config.Cmd, want)
+	}
+
+	wantEntrypoint := spec.Entrypoint.Parts.Values()
+	if len(wantEntrypoint) == 0 {
+		wantEntrypoint = c.State.Image.Entrypoint
+	}
+	if !stringSlicesEqual(wantEntrypoint, config.Entrypoint) {
+		compat.Errorf("entrypoint is %q, but spec requires %q", config.Entrypoint, wantEntrypoint)
+	}
+}
+
+// checkAdoptableEnvironment compares environment variables. The container's
+// environment includes the image's, so only variables that differ from the
+// image are expected to be in the spec.
+func (c *Container) checkAdoptableEnvironment(compat *docker.Compatibility, spec *Spec, env, imageEnv []string) {
+	want, err := c.environment(spec)
+	if err != nil {
+		compat.Errorf("resolving environment: %v", err)
+		return
+	}
+	got := parseEnv(env)
+	inherited := parseEnv(imageEnv)
+	for _, k := range sortedKeys(want) {
+		if gotValue, ok := got[k]; !ok {
+			compat.Errorf("environment variable %q is not set, but spec requires it", k)
+		} else if gotValue != want[k] {
+			compat.Errorf("environment variable %q differs from spec", k)
+		}
+	}
+	for _, k := range sortedKeys(got) {
+		if _, ok := want[k]; ok {
+			continue
+		}
+		if imageValue, ok := inherited[k]; ok && imageValue == got[k] {
+			continue
+		}
+		compat.Errorf("environment variable %q is set, but is not in spec", k)
+	}
+}
+
+func checkAdoptablePorts(compat *docker.Compatibility, spec *Spec, got nat.PortMap) {
+	want, err := portBindings(spec)
+	if err != nil {
+		compat.Errorf("resolving ports: %v", err)
+		return
+	}
+	wantPorts := formatPortBindings(want)
+	gotPorts := formatPortBindings(got)
+	if !stringSlicesEqual(wantPorts, gotPorts) {
+		compat.Errorf("published ports are %q, but spec requires %q", gotPorts, wantPorts)
+	}
+}
+
+// formatPortBindings renders bindings in a canonical form for comparison,
+// sorted and with defaults made explicit.
+func formatPortBindings(portMap nat.PortMap) []string {
+	var formatted []string
+	for port, bindings := range portMap {
+		proto := port.Proto()
+		for _, binding := range bindings {
+			hostPort := binding.HostPort
+			if i := strings.Index(hostPort, "/"); i >= 0 {
+				proto = hostPort[i+1:]
+				hostPort = hostPort[:i]
+			}
+			if hostPort == "0" {
+				hostPort = ""
+			}
+			hostIP := binding.HostIP
+			if hostIP == "0.0.0.0" {
+				hostIP = ""
+			}
+			formatted = append(formatted, fmt.Sprintf("%s:%s->%s/%s", hostIP, hostPort, port.Port(), proto))
+		}
+	}
+	sort.Strings(formatted)
+	return formatted
+}
+
+// checkAdoptableMounts compares mounts by target. Anonymous volumes for the
+// image's declared volumes are expected in addition to those in the spec.
+func (c *Container) checkAdoptableMounts(compat *docker.Compatibility, spec *Spec, got []types.MountPoint, imageVolumes map[string]struct{}) {
+	want, err := c.mounts(spec)
+	if err != nil {
+		compat.Errorf("resolving mounts: %v", err)
+		return
+	}
+	gotByTarget := make(map[string]types.MountPoint, len(got))
+	for _, mnt := range got {
+		gotByTarget[mnt.Destination] = mnt
+	}
+	wantTargets := make(map[string]bool, len(want))
+	for _, mnt := range want {
+		wantTargets[mnt.Target] = true
+		existing, ok := gotByTarget[mnt.Target]
+		if !ok {
+			compat.Errorf("mount %q is missing", mnt.Target)
+			continue
+		}
+		source := existing.Source
+		if existing.Type == mount.TypeVolume {
+			source = existing.Name
+		}
+		switch {
+		case existing.Type != mnt.Type:
+			compat.Errorf("mount %q is of type %q, but spec requires %q", mnt.Target, existing.Type, mnt.Type)
+		case mnt.Type != mount.TypeTmpfs && source != mnt.Source:
+			compat.Errorf("mount %q is from %q, but spec requires %q", mnt.Target, source, mnt.Source)
+		case existing.RW == mnt.ReadOnly:
+			compat.Errorf("mount %q has read-only %t, but spec requires %t", mnt.Target, !existing.RW, mnt.ReadOnly)
+		}
+	}
+	for _, mnt := range got {
+		if wantTargets[mnt.Destination] {
+			continue
+		}
+		if _, ok := imageVolumes[mnt.Destination]; ok && mnt.Type == mount.TypeVolume {
+			continue
+		}
+		compat.Errorf("mount %q is not in spec", mnt.Destination)
+	}
+}
+
+func parseEnv(env []string) map[string]string {
+	m := make(map[string]string, len(env))
+	for _, kv := range env {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) == 2 {
+			m[parts[0]] = parts[1]
+		} else {
+			m[parts[0]] = ""
+		}
+	}
+	return m
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func stringSlicesEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
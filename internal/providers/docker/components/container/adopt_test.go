@@ -0,0 +1,135 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-connections/nat"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestCheckAdoptable(t *testing.T) {
+	var spec Spec
+	if !assert.NoError(t, yaml.Unmarshal([]byte(`
+image: postgres:13
+container_name: app_db_1
+command: ["postgres", "-c", "fsync=off"]
+environment:
+  POSTGRES_PASSWORD: secret
+ports:
+  - "5432:5432"
+volumes:
+  - data:/var/lib/postgresql/data
+labels:
+  com.docker.compose.project: app
+  com.docker.compose.service: db
+`), &spec)) {
+		return
+	}
+	image := types.ImageInspect{
+		Config: &container.Config{
+			Env:     []string{"PATH=/usr/bin"},
+			Volumes: map[string]struct{}{"/var/lib/postgresql/data": {}},
+		},
+	}
+	inspect := func(image, name string) types.ContainerJSON {
+		return types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{
+				Name: "/" + name,
+				HostConfig: &container.HostConfig{
+					PortBindings: nat.PortMap{
+						"5432/tcp": {{HostPort: "5432"}},
+					},
+				},
+			},
+			Config: &container.Config{
+				Image: image,
+				Cmd:   []string{"postgres", "-c", "fsync=off"},
+				Env:   []string{"PATH=/usr/bin", "POSTGRES_PASSWORD=secret"},
+				Labels: map[string]string{
+					"com.docker.compose.project": "app",
+					"com.docker.compose.service": "db",
+				},
+			},
+			Mounts: []types.MountPoint{{
+				Type:        mount.TypeVolume,
+				Name:        "data",
+				Source:      "/var/lib/docker/volumes/data/_data",
+				Destination: "/var/lib/postgresql/data",
+				RW:          true,
+			}},
+		}
+	}
+	c := &Container{}
+
+	compat := c.checkAdoptable(&spec, inspect("postgres:13", "app_db_1"), image)
+	assert.Empty(t, compat.Errors)
+	assert.Empty(t, compat.Warnings)
+
+	// Compose v2 separates name parts with hyphens.
+	compat = c.checkAdoptable(&spec, inspect("postgres:13", "app-db-1"), image)
+	assert.Empty(t, compat.Errors)
+	assert.Len(t, compat.Warnings, 1)
+
+	compat = c.checkAdoptable(&spec, inspect("postgres:12", "app_db_1"), image)
+	assert.Len(t, compat.Errors, 1)
+
+	mismatches := map[string]func(*types.ContainerJSON){
+		"environment": func(inspection *types.ContainerJSON) {
+			inspection.Config.Env = []string{"PATH=/usr/bin", "POSTGRES_PASSWORD=other"}
+		},
+		"extra environment": func(inspection *types.ContainerJSON) {
+			inspection.Config.Env = append(inspection.Config.Env, "DEBUG=1")
+		},
+		"ports": func(inspection *types.ContainerJSON) {
+			inspection.HostConfig.PortBindings = nat.PortMap{
+				"5432/tcp": {{HostPort: "5433"}},
+			}
+		},
+		"mount source": func(inspection *types.ContainerJSON) {
+			inspection.Mounts[0].Name = "app_data"
+		},
+		"extra mount": func(inspection *types.ContainerJSON) {
+			inspection.Mounts = append(inspection.Mounts, types.MountPoint{
+				Type:        mount.TypeBind,
+				Source:      "/tmp",
+				Destination: "/tmp",
+			})
+		},
+		"command": func(inspection *types.ContainerJSON) {
+			inspection.Config.Cmd = []string{"postgres"}
+		},
+	}
+	for name, mutate := range mismatches {
+		inspection := inspect("postgres:13", "app_db_1")
+		mutate(&inspection)
+		compat := c.checkAdoptable(&spec, inspection, image)
+		assert.Len(t, compat.Errors, 1, name)
+	}
+}
+
+func TestCheckAdoptableShellCommand(t *testing.T) {
+	var spec Spec
+	if !assert.NoError(t, yaml.Unmarshal([]byte(`
+image: redis
+command: redis-server --appendonly yes
+`), &spec)) {
+		return
+	}
+	inspection := types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			HostConfig: &container.HostConfig{},
+		},
+		Config: &container.Config{
+			Image: "redis",
+			Cmd:   []string{"redis-server", "--appendonly", "yes"},
+		},
+	}
+	c := &Container{}
+	c.State.Image.Shell = []string{"/bin/sh", "-c"}
+	compat := c.checkAdoptable(&spec, inspection, types.ImageInspect{})
+	assert.Empty(t, compat.Errors)
+}
@@ -3,12 +3,15 @@
 package container
 
 import (
-	"bufio"
+	"archive/tar"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -16,13 +19,17 @@ import (
 	"github.com/deref/exo/internal/core/api"
 	"github.com/deref/exo/internal/providers/docker"
 	"github.com/deref/exo/internal/providers/docker/components/image"
+	"github.com/deref/exo/internal/providers/docker/registry"
 	"github.com/deref/exo/internal/task"
 	"github.com/deref/exo/internal/util/pathutil"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	dockerclient "github.com/docker/docker/client"
 	"github.com/moby/moby/builder/dockerignore"
 	"github.com/moby/moby/pkg/archive"
 	"github.com/moby/moby/pkg/fileutils"
+	"gopkg.in/yaml.v3"
 )
 
 func (c *Container) Build(ctx context.Context, input *api.BuildInput) (*api.BuildOutput, error) {
@@ -35,7 +42,11 @@ func (c *Container) Build(ctx context.Context, input *api.BuildInput) (*api.Buil
 		return nil, fmt.Errorf("loading image spec: %w", err)
 	}
 	if c.canBuild(&spec) {
-		if err := c.buildImage(ctx, &spec); err != nil {
+		opts := buildOptions{
+			NoCache: input.NoCache,
+			Pull:    input.Pull,
+		}
+		if err := c.buildImage(ctx, &spec, opts); err != nil {
 			return nil, err
 		}
 	}
@@ -46,7 +57,16 @@ func (c *Container) canBuild(spec *image.Spec) bool {
 	return spec.Build.Context.Value != ""
 }
 
-func (c *Container) buildImage(ctx context.Context, spec *image.Spec) error {
+type buildOptions struct {
+	NoCache bool
+	Pull    bool
+}
+
+// buildImage builds the image described by spec and tags it in the
+// component's repository with a hash of the build inputs. If an image with
+// that tag already exists, the build is skipped, unless opts requires that
+// the cache be bypassed.
+func (c *Container) buildImage(ctx context.Context, spec *image.Spec, opts buildOptions) error {
 	buildTask := task.CurrentTask(ctx)
 	if buildTask == nil {
 		panic("No build task")
@@ -60,21 +80,45 @@ func (c *Container) buildImage(ctx context.Context, spec *image.Spec) error {
 	if dockerfile.Value == "" {
 		dockerfile.Value = "Dockerfile"
 	}
-	buildContext, err := getArchive(contextPath, dockerfile.Value)
+
+	// The context is archived only once. It is hashed while being spooled to a
+	// temporary file, which is sent to the engine if a build is needed.
+	buildContext, inputsHash, err := spoolBuildContext(contextPath, dockerfile.Value, spec)
 	if err != nil {
-		return fmt.Errorf("getting build context: %w", err)
+		return err
 	}
-	defer buildContext.Close()
+	defer func() {
+		buildContext.Close()
+		os.Remove(buildContext.Name())
+	}()
+	repository := docker.ImageRepository(c.WorkspaceID, c.ComponentName)
+	tag := repository + ":" + inputsHash
+	if !opts.NoCache && !opts.Pull {
+		inspection, _, err := c.Docker.ImageInspectWithRaw(ctx, tag)
+		if err == nil {
+			buildTask.ReportMessage("build inputs unchanged, using " + tag)
+			c.State.Image.ID = inspection.ID
+			return nil
+		}
+		if !dockerclient.IsErrNotFound(err) {
+			return fmt.Errorf("inspecting image %q: %w", tag, err)
+		}
+	}
+
+	// Only the workspace is labeled, since cached images outlive the
+	// components that built them.
+	labels := spec.Build.Labels.Map()
+	labels["io.deref.exo.workspace"] = c.WorkspaceID
 
-	opts := types.ImageBuildOptions{
-		//Tags           []string
+	buildOpts := types.ImageBuildOptions{
+		Tags: []string{tag},
 		//SuppressOutput bool
 		//RemoteContext  string
-		//NoCache        bool
-		//Remove         bool
+		NoCache: opts.NoCache,
+		Remove:  true,
 		//ForceRemove    bool
-		//PullParent     bool
-		Isolation: container.Isolation(spec.Build.Isolation.Value),
+		PullParent: opts.Pull,
+		Isolation:  container.Isolation(spec.Build.Isolation.Value),
 		//CPUSetCPUs     string
 		//CPUSetMems     string
 		//CPUShares      int64
@@ -88,9 +132,8 @@ func (c *Container) buildImage(ctx context.Context, spec *image.Spec) error {
 		Dockerfile: spec.Build.Dockerfile.Value,
 		//Ulimits        []*units.Ulimit
 		BuildArgs: spec.Build.Args.MapOfPtr(),
-		//AuthConfigs map[string]AuthConfig
 		//Context     io.Reader
-		Labels: spec.Build.Labels.Map(),
+		Labels: labels,
 		//// squash the resulting image's layers to the parent
 		//// preserves the original image and creates a new one from the parent with all
 		//// the changes applied to a single layer
@@ -113,18 +156,55 @@ func (c *Container) buildImage(ctx context.Context, spec *image.Spec) error {
 		//// in BuildKit mode
 		//Outputs []ImageBuildOutput
 	}
-	resp, err := c.Docker.ImageBuild(ctx, buildContext, opts)
+
+	useBuildKit, err := c.useBuildKit(ctx, spec)
+	if err != nil {
+		return err
+	}
+	registryConfig, err := registry.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("loading registry credentials: %w", err)
+	}
+	progress := newBuildkitProgress(buildTask)
+	defer progress.finish()
+	if useBuildKit {
+		// BuildKit requests credentials over the session as it needs them.
+		sess, err := c.startBuildSession(ctx, contextPath, spec, registryConfig)
+		if err != nil {
+			return fmt.Errorf("starting build session: %w", err)
+		}
+		defer sess.Close()
+		buildOpts.Version = types.BuilderBuildKit
+		buildOpts.SessionID = sess.ID()
+	} else {
+		buildOpts.AuthConfigs, err = registryConfig.All()
+		if err != nil {
+			return fmt.Errorf("loading registry credentials: %w", err)
+		}
+	}
+
+	var builtID string
+	resp, err := c.Docker.ImageBuild(ctx, buildContext, buildOpts)
 	if resp.Body != nil {
 		defer resp.Body.Close()
 		subtasks := make(map[string]*task.Task)
 
-		scanner := bufio.NewScanner(resp.Body)
-		for scanner.Scan() {
+		// Lines of BuildKit trace output can be larger than a bufio.Scanner's
+		// default buffer.
+		decoder := json.NewDecoder(resp.Body)
+		for decoder.More() {
 			var event buildEvent
-			if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			if err := decoder.Decode(&event); err != nil {
 				return fmt.Errorf("failed to unmarshal docker build log: %w", err)
 			}
 
+			if event.ID == "moby.buildkit.trace" {
+				if err := progress.handleTrace(event.Aux); err != nil {
+					return err
+				}
+				continue
+			}
+
 			if event.ErrorDetail.Message != "" {
 				// TODO: Report error code too.
 				return fmt.Errorf("docker build error: " + event.ErrorDetail.Message)
@@ -156,19 +236,113 @@ func (c *Container) buildImage(ctx context.Context, spec *image.Spec) error {
 				buildTask.ReportMessage(message)
 			}
 
-			if strings.HasPrefix(event.Aux.ID, "sha256:") {
-				c.State.Image.ID = event.Aux.ID
+			// Reported by the classic builder with no ID, and by BuildKit with
+			// the ID "moby.image.id".
+			if len(event.Aux) > 0 {
+				var result struct {
+					ID string `json:"ID"`
+				}
+				if err := json.Unmarshal(event.Aux, &result); err == nil && strings.HasPrefix(result.ID, "sha256:") {
+					builtID = result.ID
+				}
 			}
 		}
 	}
 	if err != nil {
 		return err
 	}
-	if c.State.Image.ID == "" {
+	if builtID == "" {
 		return fmt.Errorf("did not build an image")
 	}
+	c.State.Image.ID = builtID
+	c.removeSupersededImages(ctx, repository, tag)
 	return nil
+}
 
+// removeSupersededImages removes tags in repository other than the current
+// one. Images that are still in use by a container cannot be removed, and are
+// left for a subsequent build or for workspace garbage collection.
+func (c *Container) removeSupersededImages(ctx context.Context, repository, current string) {
+	images, err := c.Docker.ImageList(ctx, types.ImageListOptions{
+		Filters: filters.NewArgs(filters.KeyValuePair{
+			Key:   "reference",
+			Value: repository,
+		}),
+	})
+	if err != nil {
+		c.Logger.Infof("listing images in %q: %v", repository, err)
+		return
+	}
+	for _, img := range images {
+		for _, ref := range img.RepoTags {
+			if refRepository, _ := docker.SplitImageTag(ref); refRepository != repository || ref == current {
+				continue
+			}
+			if _, err := c.Docker.ImageRemove(ctx, ref, types.ImageRemoveOptions{
+				PruneChildren: true,
+			}); err != nil {
+				c.Logger.Infof("removing superseded image %q: %v", ref, err)
+			}
+		}
+	}
+}
+
+// spoolBuildContext archives the build context in to a temporary file, which
+// is returned positioned at its start, along with the hash of the build
+// inputs. The caller is responsible for closing and removing the file.
+func spoolBuildContext(contextPath, relDockerfile string, spec *image.Spec) (*os.File, string, error) {
+	buildContext, err := getArchive(contextPath, relDockerfile)
+	if err != nil {
+		return nil, "", fmt.Errorf("getting build context: %w", err)
+	}
+	defer buildContext.Close()
+
+	f, err := ioutil.TempFile("", "exo-build-context-*.tar")
+	if err != nil {
+		return nil, "", fmt.Errorf("creating build context file: %w", err)
+	}
+	inputsHash, err := hashBuildInputs(io.TeeReader(buildContext, f), spec)
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, "", err
+	}
+	return f, inputsHash, nil
+}
+
+// hashBuildInputs returns a digest of everything that determines the result
+// of a build: the image spec and the names, modes and contents of the files
+// in the build context archive, which is read to its end. Modification times
+// are deliberately excluded so that touching a file does not invalidate the
+// image.
+func hashBuildInputs(buildContext io.Reader, spec *image.Spec) (string, error) {
+	h := sha256.New()
+	if err := yaml.NewEncoder(h).Encode(spec); err != nil {
+		return "", fmt.Errorf("encoding spec: %w", err)
+	}
+
+	tr := tar.NewReader(buildContext)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("reading build context: %w", err)
+		}
+		fmt.Fprintf(h, "%s\x00%o\x00%s\x00%d\x00", hdr.Name, hdr.Mode, hdr.Linkname, hdr.Size)
+		if _, err := io.Copy(h, tr); err != nil {
+			return "", fmt.Errorf("reading %q from build context: %w", hdr.Name, err)
+		}
+	}
+	// Consume the end of archive padding, so that all of it is spooled.
+	if _, err := io.Copy(ioutil.Discard, buildContext); err != nil {
+		return "", fmt.Errorf("reading build context: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12], nil
 }
 
 // See <github.com/docker/docker/pkg/jsonmessage>.
@@ -191,10 +365,9 @@ type buildEvent struct {
 		Code    int    `json:"code"`
 		Message string `json:"message"`
 	} `json:"errorDetail"`
-	// Reports a built ID.
-	Aux struct {
-		ID string `json:"ID"`
-	} `json:"aux"`
+	// Reports a built ID, or for BuildKit, progress as a base64 encoded
+	// protobuf. Interpretation depends on "id".
+	Aux json.RawMessage `json:"aux"`
 }
 
 func getArchive(contextDir, relDockerfile string) (io.ReadCloser, error) {
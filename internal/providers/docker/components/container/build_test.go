@@ -0,0 +1,118 @@
+package container
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/deref/exo/internal/providers/docker/components/image"
+	"github.com/deref/exo/internal/providers/docker/compose"
+	"github.com/deref/exo/internal/providers/podman"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashBuildInputs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exo-build-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name, content string) {
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		assert.NoError(t, err)
+	}
+	write("Dockerfile", "FROM scratch\nCOPY app /\n")
+	write("app", "v1")
+	write(".dockerignore", "*.log\n")
+	write("debug.log", "one")
+
+	spec := &image.Spec{}
+	hash := func() string {
+		f, h, err := spoolBuildContext(dir, "Dockerfile", spec)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+
+		// The spooled archive is the one that was hashed.
+		rehashed, err := hashBuildInputs(f, spec)
+		assert.NoError(t, err)
+		assert.Equal(t, h, rehashed)
+		return h
+	}
+	original := hash()
+	assert.Len(t, original, 12)
+
+	// Ignored files and modification times do not affect the hash.
+	write("debug.log", "two")
+	future := time.Now().Add(time.Hour)
+	assert.NoError(t, os.Chtimes(filepath.Join(dir, "app"), future, future))
+	assert.Equal(t, original, hash())
+
+	write("app", "v2")
+	changed := hash()
+	assert.NotEqual(t, original, changed)
+
+	spec.Platform = "linux/arm64"
+	assert.NotEqual(t, changed, hash())
+}
+
+func TestLoadBuildSecrets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exo-build-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, ".npmrc"), []byte("registry"), 0600))
+
+	c := &Container{}
+	c.WorkspaceRoot = dir
+	c.WorkspaceEnvironment = map[string]string{"TOKEN": "hunter2"}
+
+	var spec image.Spec
+	spec.Build.Secrets = []compose.BuildSecret{
+		{
+			Source: compose.MakeString("npmrc"),
+			BuildSecretLongForm: compose.BuildSecretLongForm{
+				Target: compose.MakeString("npm"),
+				File:   compose.MakeString(".npmrc"),
+			},
+		},
+		{
+			Source: compose.MakeString("token"),
+			BuildSecretLongForm: compose.BuildSecretLongForm{
+				Environment: compose.MakeString("TOKEN"),
+			},
+		},
+	}
+	secrets, err := c.loadBuildSecrets(&spec)
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string][]byte{
+			"npm":   []byte("registry"),
+			"token": []byte("hunter2"),
+		}, secrets)
+	}
+
+	spec.Build.Secrets[1].Environment = compose.MakeString("MISSING")
+	_, err = c.loadBuildSecrets(&spec)
+	assert.Error(t, err)
+}
+
+func TestUseBuildKitPodmanSecrets(t *testing.T) {
+	c := &Container{}
+	c.Docker = &podman.Client{}
+
+	var spec image.Spec
+	spec.Build.Secrets = []compose.BuildSecret{
+		{Source: compose.MakeString("token")},
+	}
+	_, err := c.useBuildKit(context.Background(), &spec)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "not supported with the podman runtime")
+	}
+}
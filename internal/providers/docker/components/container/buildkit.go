@@ -0,0 +1,206 @@
+// SEE NOTE: [IMAGE_SUBCOMPONENT].
+
+package container
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/deref/exo/internal/providers/docker/components/image"
+	"github.com/deref/exo/internal/providers/docker/registry"
+	"github.com/deref/exo/internal/providers/podman"
+	"github.com/deref/exo/internal/task"
+	"github.com/docker/docker/api/types"
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/session/secrets/secretsprovider"
+	"github.com/moby/buildkit/session/sshforward/sshprovider"
+	digest "github.com/opencontainers/go-digest"
+)
+
+// useBuildKit reports whether an image should be built with BuildKit rather
+// than the classic builder. As with the docker CLI, the DOCKER_BUILDKIT
+// environment variable takes precedence over the daemon's default. Builds
+// that need secrets or SSH agent forwarding always use BuildKit, so are not
+// supported with Podman, which cannot serve BuildKit sessions.
+func (c *Container) useBuildKit(ctx context.Context, spec *image.Spec) (bool, error) {
+	if len(spec.Build.Secrets) > 0 || len(spec.Build.SSH.Items) > 0 {
+		if _, isPodman := c.Docker.(*podman.Client); isPodman {
+			return false, errors.New("build secrets are not supported with the podman runtime")
+		}
+		return true, nil
+	}
+	if s := os.Getenv("DOCKER_BUILDKIT"); s != "" {
+		enabled, err := strconv.ParseBool(s)
+		if err != nil {
+			return false, fmt.Errorf("DOCKER_BUILDKIT environment variable expects boolean value: %w", err)
+		}
+		return enabled, nil
+	}
+	ping, err := c.Docker.Ping(ctx)
+	if err != nil {
+		return false, fmt.Errorf("pinging daemon: %w", err)
+	}
+	return ping.BuilderVersion == types.BuilderBuildKit, nil
+}
+
+// startBuildSession starts a BuildKit session that serves registry
+// credentials and the secrets and SSH agents declared in spec. The caller must
+// close the returned session once the build has completed.
+func (c *Container) startBuildSession(ctx context.Context, contextPath string, spec *image.Spec, registryConfig *registry.Config) (*session.Session, error) {
+	// The shared key lets BuildKit reuse state between builds of the same
+	// context directory.
+	sharedKey := sha256.Sum256([]byte(contextPath))
+	sess, err := session.NewSession(ctx, "exo", hex.EncodeToString(sharedKey[:]))
+	if err != nil {
+		return nil, fmt.Errorf("creating session: %w", err)
+	}
+
+	secrets, err := c.loadBuildSecrets(spec)
+	if err != nil {
+		sess.Close()
+		return nil, err
+	}
+	sess.Allow(secretsprovider.FromMap(secrets))
+	sess.Allow(&registry.AuthProvider{Config: registryConfig})
+
+	if len(spec.Build.SSH.Items) > 0 {
+		agents := make([]sshprovider.AgentConfig, len(spec.Build.SSH.Items))
+		for i, item := range spec.Build.SSH.Items {
+			agents[i].ID = item.Key
+			if !item.NoValue {
+				agents[i].Paths = strings.Split(item.Value, ",")
+			}
+		}
+		sshProvider, err := sshprovider.NewSSHAgentProvider(agents)
+		if err != nil {
+			sess.Close()
+			return nil, fmt.Errorf("configuring ssh forwarding: %w", err)
+		}
+		sess.Allow(sshProvider)
+	}
+
+	dialSession := func(ctx context.Context, proto string, meta map[string][]string) (net.Conn, error) {
+		return c.Docker.DialHijack(ctx, "/session", proto, meta)
+	}
+	go func() {
+		if err := sess.Run(ctx, dialSession); err != nil {
+			c.Logger.Infof("build session: %v", err)
+		}
+	}()
+	return sess, nil
+}
+
+// loadBuildSecrets reads secrets eagerly, so that environment variables come
+// from the workspace rather than from the daemon's own environment.
+func (c *Container) loadBuildSecrets(spec *image.Spec) (map[string][]byte, error) {
+	secrets := make(map[string][]byte, len(spec.Build.Secrets))
+	for _, secret := range spec.Build.Secrets {
+		id := secret.ID()
+		switch {
+		case secret.Environment.Value != "":
+			value, ok := c.WorkspaceEnvironment[secret.Environment.Value]
+			if !ok {
+				return nil, fmt.Errorf("secret %q refers to undefined environment variable %q", id, secret.Environment.Value)
+			}
+			secrets[id] = []byte(value)
+		case secret.File.Value != "":
+			path := secret.File.Value
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(c.WorkspaceRoot, path)
+			}
+			content, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("reading secret %q: %w", id, err)
+			}
+			secrets[id] = content
+		default:
+			return nil, fmt.Errorf("secret %q must specify file or environment", id)
+		}
+	}
+	return secrets, nil
+}
+
+// buildkitProgress reports BuildKit solve status as subtasks of a build task,
+// one per vertex of the build graph.
+type buildkitProgress struct {
+	task     *task.Task
+	vertexes map[digest.Digest]*task.Task
+}
+
+func newBuildkitProgress(t *task.Task) *buildkitProgress {
+	return &buildkitProgress{
+		task:     t,
+		vertexes: make(map[digest.Digest]*task.Task),
+	}
+}
+
+// handleTrace decodes the payload of a "moby.buildkit.trace" message, which
+// is a base64 encoded StatusResponse protobuf.
+func (p *buildkitProgress) handleTrace(aux json.RawMessage) error {
+	var bs []byte
+	if err := json.Unmarshal(aux, &bs); err != nil {
+		return fmt.Errorf("decoding trace: %w", err)
+	}
+	var status controlapi.StatusResponse
+	if err := status.Unmarshal(bs); err != nil {
+		return fmt.Errorf("unmarshalling trace: %w", err)
+	}
+
+	for _, vertex := range status.Vertexes {
+		subtask := p.vertexes[vertex.Digest]
+		if subtask == nil {
+			if vertex.Started == nil {
+				continue
+			}
+			subtask = p.task.StartChild(vertex.Name)
+			p.vertexes[vertex.Digest] = subtask
+		}
+		if vertex.Cached {
+			subtask.ReportMessage("cached")
+		}
+		if vertex.Error != "" {
+			subtask.Fail(errors.New(vertex.Error))
+		}
+		if vertex.Completed != nil {
+			_ = subtask.Finish()
+		}
+	}
+	for _, vertexStatus := range status.Statuses {
+		subtask := p.vertexes[vertexStatus.Vertex]
+		if subtask == nil {
+			continue
+		}
+		if vertexStatus.Total > 0 {
+			subtask.ReportProgress(int(vertexStatus.Current), int(vertexStatus.Total))
+		}
+	}
+	for _, log := range status.Logs {
+		subtask := p.vertexes[log.Vertex]
+		if subtask == nil {
+			subtask = p.task
+		}
+		for _, line := range strings.Split(strings.TrimSpace(string(log.Msg)), "\n") {
+			subtask.ReportMessage(line)
+		}
+	}
+	return nil
+}
+
+// finish completes vertexes that BuildKit never reported as completed, such
+// as when the build is cancelled.
+func (p *buildkitProgress) finish() {
+	for _, subtask := range p.vertexes {
+		_ = subtask.Finish()
+	}
+}
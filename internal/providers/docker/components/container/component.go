@@ -15,6 +15,8 @@ type Container struct {
 	State State
 
 	SyslogPort uint
+	// Either "syslog" or "follow". See config.LogConfig.ContainerMode.
+	LogMode string
 }
 
 func (c *Container) ProjectName() string {
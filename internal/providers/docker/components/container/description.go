@@ -4,18 +4,20 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/deref/exo/internal/core/api"
 	"github.com/deref/exo/internal/providers/docker"
 	"github.com/deref/exo/internal/util/jsonutil"
-	dockerclient "github.com/docker/docker/client"
+	"github.com/docker/docker/api/types"
 	"github.com/moby/moby/errdefs"
 	"golang.org/x/sync/errgroup"
 )
 
-func GetProcessDescription(ctx context.Context, dockerClient *dockerclient.Client, component api.ComponentDescription) (api.ProcessDescription, error) {
+func GetProcessDescription(ctx context.Context, dockerClient docker.Runtime, component api.ComponentDescription) (api.ProcessDescription, error) {
 	if component.Type != "container" {
 		return api.ProcessDescription{}, fmt.Errorf("component not a container")
 	}
@@ -28,6 +30,11 @@ func GetProcessDescription(ctx context.Context, dockerClient *dockerclient.Clien
 		ID:       component.ID,
 		Name:     component.Name,
 		Provider: "docker",
+		Spec:     component.Spec,
+	}
+
+	if state.ContainerID == "" {
+		return process, nil
 	}
 
 	containerInfo, err := dockerClient.ContainerInspect(ctx, state.ContainerID)
@@ -38,7 +45,18 @@ func GetProcessDescription(ctx context.Context, dockerClient *dockerclient.Clien
 		return process, nil
 	}
 
+	process.EnvVars = map[string]string{}
+	for _, env := range containerInfo.Config.Env {
+		decomposedEnv := strings.SplitN(env, "=", 2)
+		if len(decomposedEnv) == 2 {
+			process.EnvVars[decomposedEnv[0]] = decomposedEnv[1]
+		}
+	}
+
 	process.Running = containerInfo.State.Running
+	if !process.Running {
+		return process, nil
+	}
 
 	startTime, err := time.Parse(time.RFC3339Nano, containerInfo.State.StartedAt)
 	if err != nil {
@@ -47,34 +65,31 @@ func GetProcessDescription(ctx context.Context, dockerClient *dockerclient.Clien
 	createTime := startTime.UnixNano() / 1e6
 	process.CreateTime = &createTime
 
-	process.EnvVars = map[string]string{}
-	for _, env := range containerInfo.Config.Env {
-		decomposedEnv := strings.SplitN(env, "=", 2)
-		process.EnvVars[decomposedEnv[0]] = decomposedEnv[1]
-	}
-
-	process.Ports = []uint32{}
-	for p := range containerInfo.NetworkSettings.Ports {
-		process.Ports = append(process.Ports, uint32(p.Int()))
-	}
+	// Report published host ports, since those are the ports that are reachable
+	// in the same way as the listening ports of unix processes.
+	process.Ports = publishedPorts(containerInfo.NetworkSettings)
 
 	var eg errgroup.Group
 	eg.Go(func() error {
-		statRequest, err := dockerClient.ContainerStats(ctx, state.ContainerID, false)
-		if err != nil && !errdefs.IsConflict(err) { // ignore not running errors
+		statsResponse, err := dockerClient.ContainerStats(ctx, state.ContainerID, false)
+		if errdefs.IsConflict(err) || errdefs.IsNotFound(err) {
+			// Container stopped or was removed since it was inspected.
+			return nil
+		}
+		if err != nil {
 			return fmt.Errorf("getting stats for container: %w", err)
 		}
-		defer statRequest.Body.Close()
+		defer statsResponse.Body.Close()
 
 		var containerStats docker.ContainerStats
-		decoder := json.NewDecoder(statRequest.Body)
+		decoder := json.NewDecoder(statsResponse.Body)
 		if err := decoder.Decode(&containerStats); err != nil {
 			return fmt.Errorf("could not unmarshal container stats: %s", err)
 		}
 
-		process.ResidentMemory = &containerStats.MemoryStats.Usage
-		if containerStats.CPUStats.SystemCPUUsage != 0 {
-			cpuPercent := float64(containerStats.CPUStats.CPUUsage.TotalUsage) / 1e9
+		residentMemory := containerStats.ResidentMemory()
+		process.ResidentMemory = &residentMemory
+		if cpuPercent, ok := containerStats.CPUPercent(); ok {
 			process.CPUPercent = &cpuPercent
 		}
 		return nil
@@ -82,7 +97,10 @@ func GetProcessDescription(ctx context.Context, dockerClient *dockerclient.Clien
 
 	eg.Go(func() error {
 		topBody, err := dockerClient.ContainerTop(ctx, state.ContainerID, []string{})
-		if err != nil && !errdefs.IsConflict(err) { // ignore not running errors
+		if errdefs.IsConflict(err) || errdefs.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
 			return fmt.Errorf("running top in container: %w", err)
 		}
 
@@ -98,3 +116,25 @@ func GetProcessDescription(ctx context.Context, dockerClient *dockerclient.Clien
 	err = eg.Wait()
 	return process, err
 }
+
+func publishedPorts(settings *types.NetworkSettings) []uint32 {
+	ports := []uint32{}
+	if settings == nil {
+		return ports
+	}
+	seen := make(map[uint32]bool)
+	for _, bindings := range settings.Ports {
+		for _, binding := range bindings {
+			port, err := strconv.ParseUint(binding.HostPort, 10, 16)
+			if err != nil || port == 0 || seen[uint32(port)] {
+				continue
+			}
+			seen[uint32(port)] = true
+			ports = append(ports, uint32(port))
+		}
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		return ports[i] < ports[j]
+	})
+	return ports
+}
@@ -0,0 +1,26 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/go-connections/nat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishedPorts(t *testing.T) {
+	settings := &types.NetworkSettings{
+		NetworkSettingsBase: types.NetworkSettingsBase{
+			Ports: nat.PortMap{
+				"80/tcp": []nat.PortBinding{
+					{HostIP: "0.0.0.0", HostPort: "8080"},
+					{HostIP: "::", HostPort: "8080"},
+				},
+				"443/tcp":  []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "8443"}},
+				"5432/tcp": nil,
+			},
+		},
+	}
+	assert.Equal(t, []uint32{8080, 8443}, publishedPorts(settings))
+	assert.Equal(t, []uint32{}, publishedPorts(nil))
+}
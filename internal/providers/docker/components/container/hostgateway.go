@@ -0,0 +1,90 @@
+package container
+
+import (
+	"context"
+	"runtime"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/versions"
+)
+
+// NOTE [HOST GATEWAY]: Containers reach services on the host, such as exo
+// processes and exod's syslog port, through the host.docker.internal name.
+// Docker Desktop (macOS and WSL) resolves this name itself. Native Linux
+// engines do not, but since Docker 20.10 they accept "host-gateway" in extra
+// hosts as an alias for the address of the host on the default bridge. Older
+// engines reject the alias, so it is left out, and the name does not resolve.
+
+const hostGatewayName = "host.docker.internal"
+
+// Docker 20.10 introduced "host-gateway", along with version 1.41 of the API.
+const minHostGatewayAPIVersion = "1.41"
+
+func hasBuiltinHostGateway(info types.Info) bool {
+	return runtime.GOOS == "darwin" || strings.Contains(info.KernelVersion, "microsoft")
+}
+
+func supportsHostGatewayAlias(apiVersion string) bool {
+	return apiVersion != "" && versions.GreaterThanOrEqualTo(apiVersion, minHostGatewayAPIVersion)
+}
+
+// needsHostGateway reports whether a host.docker.internal entry should be
+// added to the extra hosts of the container.
+func (c *Container) needsHostGateway(ctx context.Context, info types.Info) bool {
+	if hasBuiltinHostGateway(info) {
+		return false
+	}
+	version, err := c.Docker.ServerVersion(ctx)
+	if err != nil {
+		c.Logger.Infof("getting engine version: %v", err)
+		return false
+	}
+	for _, component := range version.Components {
+		// The Podman adapter removes the alias, since Podman provides
+		// host.containers.internal instead.
+		if component.Name == "Podman Engine" {
+			return false
+		}
+	}
+	if !supportsHostGatewayAlias(version.APIVersion) {
+		c.Warnf(ctx, "container engine API version %s does not support host-gateway, so %s will not resolve in containers; upgrade to Docker 20.10 or later", version.APIVersion, hostGatewayName)
+		return false
+	}
+	return true
+}
+
+// withHostGateway adds a host.docker.internal entry to extraHosts, if needed
+// and unless the spec already declares one.
+func withHostGateway(extraHosts []string, needed bool) []string {
+	if !needed {
+		return extraHosts
+	}
+	for _, extraHost := range extraHosts {
+		if strings.HasPrefix(extraHost, hostGatewayName+":") {
+			return extraHosts
+		}
+	}
+	return append(append([]string{}, extraHosts...), hostGatewayName+":host-gateway")
+}
+
+// syslogHost returns the address that the engine should send container logs
+// to. Logging drivers run in the engine, rather than in the container, so
+// host.docker.internal is only resolvable when the engine provides it.
+// Otherwise, use the address that "host-gateway" resolves to.
+func (c *Container) syslogHost(ctx context.Context, info types.Info) string {
+	if hasBuiltinHostGateway(info) {
+		return hostGatewayName
+	}
+	bridge, err := c.Docker.NetworkInspect(ctx, "bridge", types.NetworkInspectOptions{})
+	if err != nil {
+		c.Logger.Infof("inspecting bridge network: %v", err)
+		return "localhost"
+	}
+	for _, ipamConfig := range bridge.IPAM.Config {
+		if ipamConfig.Gateway != "" {
+			return ipamConfig.Gateway
+		}
+	}
+	return "localhost"
+}
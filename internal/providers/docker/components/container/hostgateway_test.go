@@ -0,0 +1,27 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithHostGateway(t *testing.T) {
+	assert.Equal(t, []string{"db:10.0.0.2"}, withHostGateway([]string{"db:10.0.0.2"}, false))
+	assert.Equal(t, []string{"host.docker.internal:host-gateway"}, withHostGateway(nil, true))
+	assert.Equal(t,
+		[]string{"db:10.0.0.2", "host.docker.internal:host-gateway"},
+		withHostGateway([]string{"db:10.0.0.2"}, true),
+	)
+	assert.Equal(t,
+		[]string{"host.docker.internal:192.168.1.1"},
+		withHostGateway([]string{"host.docker.internal:192.168.1.1"}, true),
+	)
+}
+
+func TestSupportsHostGatewayAlias(t *testing.T) {
+	assert.True(t, supportsHostGatewayAlias("1.41"))
+	assert.True(t, supportsHostGatewayAlias("1.42"))
+	assert.False(t, supportsHostGatewayAlias("1.40"))
+	assert.False(t, supportsHostGatewayAlias(""))
+}
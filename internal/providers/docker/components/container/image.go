@@ -8,6 +8,7 @@ import (
 	"fmt"
 
 	"github.com/deref/exo/internal/providers/docker/components/image"
+	"github.com/deref/exo/internal/providers/docker/registry"
 	"github.com/deref/exo/internal/task"
 	"github.com/docker/docker/api/types"
 	docker "github.com/docker/docker/client"
@@ -26,7 +27,7 @@ func (c *Container) ensureImage(ctx context.Context, spec *Spec) error {
 	var inspection types.ImageInspect
 	var err error
 	if c.canBuild(imageSpec) {
-		if err := c.buildImage(ctx, imageSpec); err != nil {
+		if err := c.buildImage(ctx, imageSpec, buildOptions{}); err != nil {
 			return fmt.Errorf("building image: %w", err)
 		}
 		inspection, _, err = c.Docker.ImageInspectWithRaw(ctx, c.State.Image.ID)
@@ -55,6 +56,13 @@ func (c *Container) ensureImage(ctx context.Context, spec *Spec) error {
 		}
 	}
 
+	c.setImageState(inspection)
+	return nil
+}
+
+// setImageState copies the image configuration that is needed to describe
+// the container's process in to the component's state.
+func (c *Container) setImageState(inspection types.ImageInspect) {
 	c.State.Image.ID = inspection.ID
 	c.State.Image.Command = inspection.Config.Cmd
 	c.State.Image.WorkingDir = inspection.Config.WorkingDir
@@ -67,9 +75,7 @@ func (c *Container) ensureImage(ctx context.Context, spec *Spec) error {
 			// For Windows — this is untested but it is what docker does.
 			c.State.Image.Shell = []string{"cmd", "/S", "/C"}
 		}
-
 	}
-	return nil
 }
 
 type dockerPullStatus struct {
@@ -88,9 +94,14 @@ func (c *Container) pullImage(ctx context.Context, spec *Spec) error {
 		panic("No build task")
 	}
 
+	auth, err := registryAuth(spec.Image.Value)
+	if err != nil {
+		return fmt.Errorf("loading registry credentials: %w", err)
+	}
+
 	image, err := c.Docker.ImagePull(ctx, spec.Image.Value, types.ImagePullOptions{
 		//All           bool
-		//RegistryAuth  string // RegistryAuth is the base64 encoded credentials for the registry
+		RegistryAuth: auth,
 		//PrivilegeFunc RequestPrivilegeFunc
 		//Platform      string
 	})
@@ -109,3 +120,24 @@ func (c *Container) pullImage(ctx context.Context, spec *Spec) error {
 	}
 	return err
 }
+
+// registryAuth returns the encoded credentials for the registry that image is
+// pulled from, or an empty string if there are none.
+func registryAuth(image string) (string, error) {
+	host, err := registry.ImageHost(image)
+	if err != nil {
+		return "", fmt.Errorf("parsing image reference: %w", err)
+	}
+	cfg, err := registry.LoadConfig()
+	if err != nil {
+		return "", err
+	}
+	auth, err := cfg.Lookup(host)
+	if err != nil {
+		return "", err
+	}
+	if auth == (types.AuthConfig{}) {
+		return "", nil
+	}
+	return registry.EncodeAuth(auth)
+}
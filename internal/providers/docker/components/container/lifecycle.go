@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"os/user"
 	"path"
-	"runtime"
 	"strconv"
 	"strings"
 	"time"
@@ -14,6 +13,8 @@ import (
 	core "github.com/deref/exo/internal/core/api"
 	"github.com/deref/exo/internal/providers/docker/components/image"
 	"github.com/deref/exo/internal/providers/docker/compose"
+	"github.com/deref/exo/internal/providers/docker/logs"
+	"github.com/deref/exo/internal/util/jsonutil"
 	"github.com/deref/exo/internal/util/pathutil"
 	"github.com/deref/exo/internal/util/yamlutil"
 	"github.com/docker/docker/api/types"
@@ -53,6 +54,12 @@ func (c *Container) Dependencies(ctx context.Context, input *core.DependenciesIn
 	for _, link := range spec.Links {
 		addDep(link.Service)
 	}
+	// Namespace sharing requires the target service's container to exist.
+	for _, mode := range []string{spec.NetworkMode.Value, spec.PidMode.Value, spec.IPC.Value} {
+		if service, ok := parseServiceReference(mode); ok {
+			addDep(service)
+		}
+	}
 	return &core.DependenciesOutput{Components: deps}, nil
 }
 
@@ -69,6 +76,17 @@ func (c *Container) Initialize(ctx context.Context, input *core.InitializeInput)
 		Build:    spec.Build,
 	})
 
+	// See NOTE: [ADOPT COMPOSE CONTAINERS].
+	if input.Adopt {
+		adopted, err := c.adoptComposeContainer(ctx, &spec, !input.NoStart)
+		if err != nil {
+			return nil, fmt.Errorf("adopting existing container: %w", err)
+		}
+		if adopted {
+			return &core.InitializeOutput{}, nil
+		}
+	}
+
 	if err := c.ensureImage(ctx, &spec); err != nil {
 		return nil, fmt.Errorf("ensuring image: %w", err)
 	}
@@ -81,8 +99,10 @@ func (c *Container) Initialize(ctx context.Context, input *core.InitializeInput)
 		return nil, fmt.Errorf("creating container: %w", err)
 	}
 
-	if err := c.start(ctx); err != nil {
-		c.Logger.Infof("starting container %q: %v", c.State.ContainerID, err)
+	if !input.NoStart {
+		if err := c.start(ctx); err != nil {
+			c.Logger.Infof("starting container %q: %v", c.State.ContainerID, err)
+		}
 	}
 
 	return &core.InitializeOutput{}, nil
@@ -110,30 +130,9 @@ func (c *Container) create(ctx context.Context, spec *Spec) error {
 		labels[k] = v
 	}
 
-	envMap := map[string]string{}
-	for _, envFilePath := range spec.EnvFile.Items {
-		if !path.IsAbs(envFilePath.Value) {
-			envFilePath.Value = path.Join(c.WorkspaceRoot, envFilePath.Value)
-		}
-		if !pathutil.HasPathPrefix(envFilePath.Value, c.WorkspaceRoot) {
-			return fmt.Errorf("env file %s is not contained within the workspace", envFilePath.Value)
-		}
-		envFileVars, err := godotenv.Read(envFilePath.Value)
-		if err != nil {
-			return fmt.Errorf("reading env file %s: %w", envFilePath.Value, err)
-		}
-		for k, v := range envFileVars {
-			envMap[k] = v
-		}
-	}
-	for _, item := range spec.Environment.Items {
-		if item.Value == "" {
-			if v, ok := c.WorkspaceEnvironment[item.Key]; ok {
-				envMap[item.Key] = v
-			}
-		} else {
-			envMap[item.Key] = item.Value
-		}
+	envMap, err := c.environment(spec)
+	if err != nil {
+		return err
 	}
 	envSlice := []string{}
 	for k, v := range envMap {
@@ -164,15 +163,7 @@ func (c *Container) create(ctx context.Context, spec *Spec) error {
 		// Shell           strslice.StrSlice   `json:",omitempty"` // Shell for shell-form of RUN, CMD, ENTRYPOINT
 	}
 
-	if spec.Command.IsShellForm {
-		containerCfg.Cmd = append(append([]string{}, c.State.Image.Shell...), spec.Command.Parts[0].Value)
-	} else {
-		containerCfg.Cmd = spec.Command.Parts.Values()
-	}
-	if len(containerCfg.Cmd) == 0 {
-		containerCfg.Cmd = c.State.Image.Command
-	}
-
+	containerCfg.Cmd = c.command(spec)
 	if len(containerCfg.Entrypoint) == 0 {
 		containerCfg.Entrypoint = c.State.Image.Entrypoint
 	}
@@ -200,18 +191,17 @@ func (c *Container) create(ctx context.Context, spec *Spec) error {
 	}
 
 	logCfg := container.LogConfig{}
-	if spec.Logging.Driver.Value == "" && len(spec.Logging.Options.Items) == 0 {
+	if spec.Logging.Driver.Value == "" && len(spec.Logging.Options.Items) == 0 && c.LogMode == "follow" {
+		// No logging configuration specified, so use the engine's default
+		// driver and let exod follow the logs. See the logs package.
+		labels[logs.FollowLabel] = "true"
+	} else if spec.Logging.Driver.Value == "" && len(spec.Logging.Options.Items) == 0 {
 		// No logging configuration specified, so default to logging to exo's
 		// syslog service.
+		// See NOTE: [HOST GATEWAY].
 		logCfg.Type = "syslog"
-		syslogHost := "localhost"
-		// TODO: Find an OS-agnostic way to figure out the "gateway-host" name as
-		// reachable from the dockerd process.
-		if runtime.GOOS == "darwin" || strings.Contains(dockerInfo.KernelVersion, "microsoft") {
-			syslogHost = "host.docker.internal"
-		}
 		logCfg.Config = map[string]string{
-			"syslog-address":  fmt.Sprintf("udp://%s:%d", syslogHost, c.SyslogPort),
+			"syslog-address":  fmt.Sprintf("udp://%s:%d", c.syslogHost(ctx, dockerInfo), c.SyslogPort),
 			"syslog-facility": "1", // "user-level messages"
 			"tag":             c.ComponentID,
 			"syslog-format":   "rfc5424micro",
@@ -221,25 +211,13 @@ func (c *Container) create(ctx context.Context, spec *Spec) error {
 		logCfg.Config = spec.Logging.Options.Map()
 	}
 
-	blkioWeightDevice := make([]*blkiodev.WeightDevice, len(spec.BlkioConfig.WeightDevice))
-	for i, weightDevice := range spec.BlkioConfig.WeightDevice {
-		blkioWeightDevice[i] = &blkiodev.WeightDevice{
-			Path:   weightDevice.Path.Value,
-			Weight: weightDevice.Weight.Uint16(),
-		}
-	}
-
 	hostCfg := &container.HostConfig{
 		//// Applicable to all platforms
 		//Binds           []string      // List of volume bindings for this container
 		//ContainerIDFile string        // File (path) where the containerId is written
 		LogConfig: logCfg,
 		//NetworkMode     NetworkMode   // Network mode to use for the container
-		PortBindings: make(nat.PortMap),
-		// TODO: Potentially inherit from deploy's restart_policy.
-		RestartPolicy: container.RestartPolicy{
-			Name: spec.Restart.Value,
-		},
+		RestartPolicy: restartPolicy(spec),
 		//AutoRemove      bool          // Automatically remove container when it exits
 		//VolumeDriver    string        // Name of the volume driver used to mount volumes
 
@@ -250,7 +228,7 @@ func (c *Container) create(ctx context.Context, spec *Spec) error {
 		DNS:        spec.DNS.Values(),
 		DNSOptions: spec.DNSOptions.Values(),
 		DNSSearch:  spec.DNSSearch.Values(),
-		ExtraHosts: spec.ExtraHosts.Values(),
+		ExtraHosts: withHostGateway(spec.ExtraHosts.Values(), c.needsHostGateway(ctx, dockerInfo)), // See NOTE: [HOST GATEWAY].
 		GroupAdd:   spec.GroupAdd.Values(),
 		//Cgroup          CgroupSpec        // Cgroup to use for the container
 
@@ -272,32 +250,7 @@ func (c *Container) create(ctx context.Context, spec *Spec) error {
 		//ConsoleSize [2]uint   // Initial console size (height,width)
 
 		//// Contains container's resources (cgroups, ulimits)
-		Resources: container.Resources{
-			CPUCount:             spec.CPUCount.Value,
-			CPUPercent:           spec.CPUPercent.Value,
-			CPUShares:            spec.CPUShares.Value,
-			CPUPeriod:            spec.CPUPeriod.Value,
-			CPUQuota:             spec.CPUQuota.Value,
-			Memory:               spec.MemoryLimit.Int64(),
-			MemoryReservation:    spec.MemoryReservation.Int64(),
-			MemorySwappiness:     spec.MemorySwappiness.Int64Ptr(),
-			MemorySwap:           spec.MemswapLimit.Int64(),
-			CPURealtimePeriod:    spec.CPURealtimePeriod.Duration.Microseconds(),
-			CPURealtimeRuntime:   spec.CPURealtimeRuntime.Duration.Microseconds(),
-			BlkioWeight:          spec.BlkioConfig.Weight.Uint16(),
-			BlkioWeightDevice:    blkioWeightDevice,
-			BlkioDeviceReadBps:   convertThrottleDevice(spec.BlkioConfig.DeviceReadBPS),
-			BlkioDeviceReadIOps:  convertThrottleDevice(spec.BlkioConfig.DeviceReadIOPS),
-			BlkioDeviceWriteBps:  convertThrottleDevice(spec.BlkioConfig.DeviceWriteBPS),
-			BlkioDeviceWriteIOps: convertThrottleDevice(spec.BlkioConfig.DeviceWriteIOPS),
-			CpusetCpus:           spec.CPUSet.Value,
-			CgroupParent:         spec.CgroupParent.Value,
-			DeviceCgroupRules:    spec.DeviceCgroupRules.Values(),
-			Devices:              convertDeviceMappings(spec.Devices),
-			OomKillDisable:       spec.OomKillDisable.Ptr(),
-			PidsLimit:            spec.PidsLimit.Int64Ptr(),
-			Ulimits:              convertUlimits(spec.Ulimits),
-		},
+		Resources: containerResources(spec),
 
 		OomScoreAdj: spec.OomScoreAdj.Int(),
 
@@ -311,7 +264,7 @@ func (c *Container) create(ctx context.Context, spec *Spec) error {
 		Init: spec.Init.Ptr(),
 	}
 
-	if hostCfg.IpcMode, err = c.parseIPCMode(spec.IPC.Value); err != nil {
+	if hostCfg.IpcMode, err = c.parseIPCMode(ctx, spec.IPC.Value); err != nil {
 		return err
 	}
 
@@ -319,11 +272,11 @@ func (c *Container) create(ctx context.Context, spec *Spec) error {
 		return err
 	}
 
-	if hostCfg.NetworkMode, err = c.parseNetworkMode(spec.NetworkMode.Value); err != nil {
+	if hostCfg.NetworkMode, err = c.parseNetworkMode(ctx, spec.NetworkMode.Value); err != nil {
 		return err
 	}
 
-	if hostCfg.PidMode, err = c.parsePIDMode(spec.PidMode.Value); err != nil {
+	if hostCfg.PidMode, err = c.parsePIDMode(ctx, spec.PidMode.Value); err != nil {
 		return err
 	}
 
@@ -339,45 +292,12 @@ func (c *Container) create(ctx context.Context, spec *Spec) error {
 		}
 	}
 
-	// TODO: make the user home directory a parameter of the container.
-	user, err := user.Current()
-	if err != nil {
-		return fmt.Errorf("could not get user %w", err)
-	}
-	userHomeDir := user.HomeDir
-
-	hostCfg.Mounts = make([]mount.Mount, len(spec.Volumes))
-	for i, v := range spec.Volumes {
-		mnt, err := makeMountFromVolumeMount(c.WorkspaceRoot, userHomeDir, v)
-		if err != nil {
-			return fmt.Errorf("invalid mount at index %d: %w", i, err)
-		}
-		hostCfg.Mounts[i] = mnt
+	if hostCfg.Mounts, err = c.mounts(spec); err != nil {
+		return err
 	}
 
-	for _, mapping := range spec.Ports {
-		targetLow, targetHigh := int(mapping.Target.Min), int(mapping.Target.Max)
-		for targetPort := targetLow; targetPort <= targetHigh; targetPort += 1 {
-			publishedLow, publishedHigh := int(mapping.Published.Min), int(mapping.Published.Max)
-			publishedDiff, targetDiff := publishedHigh-publishedLow, targetHigh-targetLow
-			if publishedDiff != 1 && publishedDiff != targetDiff {
-				return fmt.Errorf("unexpected number of ports")
-			}
-
-			target := nat.Port(strconv.Itoa(targetPort))
-			hostPort := strconv.Itoa(publishedLow + publishedDiff)
-			if mapping.Protocol != "" {
-				hostPort += "/" + mapping.Protocol
-			}
-			bindings := hostCfg.PortBindings[target]
-			bindings = append(bindings, nat.PortBinding{
-				HostIP:   mapping.HostIP,
-				HostPort: hostPort,
-			})
-
-			// TODO: Handle mapping.Mode
-			hostCfg.PortBindings[target] = bindings
-		}
+	if hostCfg.PortBindings, err = portBindings(spec); err != nil {
+		return err
 	}
 
 	networkCfg := &network.NetworkingConfig{
@@ -386,7 +306,9 @@ func (c *Container) create(ctx context.Context, spec *Spec) error {
 	// Docker only allows a single network to be specified when creating a container. The other networks must be
 	// connected after the container is started. See https://github.com/moby/moby/issues/29265#issuecomment-265909198.
 	var remainingNetworks []compose.ServiceNetwork
-	if len(spec.Networks.Items) > 0 {
+	// Containers that share another container's network namespace cannot be
+	// connected to networks of their own.
+	if len(spec.Networks.Items) > 0 && !hostCfg.NetworkMode.IsContainer() {
 		firstNetwork := spec.Networks.Items[0]
 		remainingNetworks = spec.Networks.Items[1:]
 		networkCfg.EndpointsConfig[firstNetwork.Key] = c.endpointSettings(firstNetwork, spec)
@@ -429,6 +351,99 @@ func (c *Container) create(ctx context.Context, spec *Spec) error {
 	return netConnects.Wait()
 }
 
+// environment returns the environment variables for the container, not
+// including those from the image.
+func (c *Container) environment(spec *Spec) (map[string]string, error) {
+	envMap := map[string]string{}
+	for _, envFilePath := range spec.EnvFile.Items {
+		if !path.IsAbs(envFilePath.Value) {
+			envFilePath.Value = path.Join(c.WorkspaceRoot, envFilePath.Value)
+		}
+		if !pathutil.HasPathPrefix(envFilePath.Value, c.WorkspaceRoot) {
+			return nil, fmt.Errorf("env file %s is not contained within the workspace", envFilePath.Value)
+		}
+		envFileVars, err := godotenv.Read(envFilePath.Value)
+		if err != nil {
+			return nil, fmt.Errorf("reading env file %s: %w", envFilePath.Value, err)
+		}
+		for k, v := range envFileVars {
+			envMap[k] = v
+		}
+	}
+	for _, item := range spec.Environment.Items {
+		if item.Value == "" {
+			if v, ok := c.WorkspaceEnvironment[item.Key]; ok {
+				envMap[item.Key] = v
+			}
+		} else {
+			envMap[item.Key] = item.Value
+		}
+	}
+	return envMap, nil
+}
+
+// command returns the command for the container, defaulting to the image's.
+func (c *Container) command(spec *Spec) []string {
+	var cmd []string
+	if spec.Command.IsShellForm {
+		cmd = append(append([]string{}, c.State.Image.Shell...), spec.Command.Parts[0].Value)
+	} else {
+		cmd = spec.Command.Parts.Values()
+	}
+	if len(cmd) == 0 {
+		cmd = c.State.Image.Command
+	}
+	return cmd
+}
+
+func (c *Container) mounts(spec *Spec) ([]mount.Mount, error) {
+	// TODO: make the user home directory a parameter of the container.
+	user, err := user.Current()
+	if err != nil {
+		return nil, fmt.Errorf("could not get user %w", err)
+	}
+	userHomeDir := user.HomeDir
+
+	mounts := make([]mount.Mount, len(spec.Volumes))
+	for i, v := range spec.Volumes {
+		mnt, err := makeMountFromVolumeMount(c.WorkspaceRoot, userHomeDir, v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mount at index %d: %w", i, err)
+		}
+		mounts[i] = mnt
+	}
+	return mounts, nil
+}
+
+func portBindings(spec *Spec) (nat.PortMap, error) {
+	portMap := make(nat.PortMap)
+	for _, mapping := range spec.Ports {
+		targetLow, targetHigh := int(mapping.Target.Min), int(mapping.Target.Max)
+		for targetPort := targetLow; targetPort <= targetHigh; targetPort += 1 {
+			publishedLow, publishedHigh := int(mapping.Published.Min), int(mapping.Published.Max)
+			publishedDiff, targetDiff := publishedHigh-publishedLow, targetHigh-targetLow
+			if publishedDiff != 1 && publishedDiff != targetDiff {
+				return nil, fmt.Errorf("unexpected number of ports")
+			}
+
+			target := nat.Port(strconv.Itoa(targetPort))
+			hostPort := strconv.Itoa(publishedLow + publishedDiff)
+			if mapping.Protocol != "" {
+				hostPort += "/" + mapping.Protocol
+			}
+			bindings := portMap[target]
+			bindings = append(bindings, nat.PortBinding{
+				HostIP:   mapping.HostIP,
+				HostPort: hostPort,
+			})
+
+			// TODO: Handle mapping.Mode
+			portMap[target] = bindings
+		}
+	}
+	return portMap, nil
+}
+
 func (c *Container) Refresh(ctx context.Context, input *core.RefreshInput) (*core.RefreshOutput, error) {
 	{
 		// NOTE [MIGRATE_CONTAINER_STATE]: Data migration that copies additional
@@ -553,6 +568,94 @@ func convertDeviceMappings(in []compose.DeviceMapping) []container.DeviceMapping
 	return out
 }
 
+// containerResources returns the cgroup settings for a container running spec.
+func containerResources(spec *Spec) container.Resources {
+	blkioWeightDevice := make([]*blkiodev.WeightDevice, len(spec.BlkioConfig.WeightDevice))
+	for i, weightDevice := range spec.BlkioConfig.WeightDevice {
+		blkioWeightDevice[i] = &blkiodev.WeightDevice{
+			Path:   weightDevice.Path.Value,
+			Weight: weightDevice.Weight.Uint16(),
+		}
+	}
+
+	resources := container.Resources{
+		CPUCount:             spec.CPUCount.Value,
+		CPUPercent:           spec.CPUPercent.Value,
+		CPUShares:            spec.CPUShares.Value,
+		CPUPeriod:            spec.CPUPeriod.Value,
+		CPUQuota:             spec.CPUQuota.Value,
+		Memory:               spec.MemoryLimit.Int64(),
+		MemoryReservation:    spec.MemoryReservation.Int64(),
+		MemorySwappiness:     spec.MemorySwappiness.Int64Ptr(),
+		MemorySwap:           spec.MemswapLimit.Int64(),
+		CPURealtimePeriod:    spec.CPURealtimePeriod.Duration.Microseconds(),
+		CPURealtimeRuntime:   spec.CPURealtimeRuntime.Duration.Microseconds(),
+		BlkioWeight:          spec.BlkioConfig.Weight.Uint16(),
+		BlkioWeightDevice:    blkioWeightDevice,
+		BlkioDeviceReadBps:   convertThrottleDevice(spec.BlkioConfig.DeviceReadBPS),
+		BlkioDeviceReadIOps:  convertThrottleDevice(spec.BlkioConfig.DeviceReadIOPS),
+		BlkioDeviceWriteBps:  convertThrottleDevice(spec.BlkioConfig.DeviceWriteBPS),
+		BlkioDeviceWriteIOps: convertThrottleDevice(spec.BlkioConfig.DeviceWriteIOPS),
+		CpusetCpus:           spec.CPUSet.Value,
+		CgroupParent:         spec.CgroupParent.Value,
+		DeviceCgroupRules:    spec.DeviceCgroupRules.Values(),
+		Devices:              convertDeviceMappings(spec.Devices),
+		OomKillDisable:       spec.OomKillDisable.Ptr(),
+		PidsLimit:            spec.PidsLimit.Int64Ptr(),
+		Ulimits:              convertUlimits(spec.Ulimits),
+	}
+	applyDeployResources(&resources, spec.Deploy)
+	return resources
+}
+
+// restartPolicy returns the service-level restart policy if set, otherwise
+// the one from the deploy section.
+func restartPolicy(spec *Spec) container.RestartPolicy {
+	if spec.Restart.Value != "" || spec.Deploy == nil || spec.Deploy.RestartPolicy == nil {
+		return container.RestartPolicy{
+			Name: spec.Restart.Value,
+		}
+	}
+	deployPolicy := spec.Deploy.RestartPolicy
+	policy := container.RestartPolicy{}
+	switch deployPolicy.Condition.Value {
+	case "none":
+		policy.Name = "no"
+	case "on-failure":
+		policy.Name = "on-failure"
+		if deployPolicy.MaxAttempts != nil {
+			policy.MaximumRetryCount = deployPolicy.MaxAttempts.Int()
+		}
+	default:
+		// Swarm's default condition is "any".
+		policy.Name = "always"
+	}
+	return policy
+}
+
+// applyDeployResources overrides the service-level resource settings with
+// those from the deploy section, as docker-compose does. CPU reservations
+// have no equivalent outside of swarm and are ignored.
+func applyDeployResources(resources *container.Resources, deploy *compose.Deploy) {
+	if deploy == nil {
+		return
+	}
+	limits := deploy.Resources.Limits
+	if limits.CPUs.Value != 0 {
+		resources.NanoCPUs = limits.NanoCPUs()
+	}
+	if limits.Memory.Int64() != 0 {
+		resources.Memory = limits.Memory.Int64()
+	}
+	if limits.Pids != nil {
+		resources.PidsLimit = limits.Pids.Int64Ptr()
+	}
+	reservations := deploy.Resources.Reservations
+	if reservations.Memory.Int64() != 0 {
+		resources.MemoryReservation = reservations.Memory.Int64()
+	}
+}
+
 func convertUlimits(in compose.Ulimits) []*units.Ulimit {
 	if in == nil {
 		return nil
@@ -569,7 +672,7 @@ func convertUlimits(in compose.Ulimits) []*units.Ulimit {
 	return out
 }
 
-func (c *Container) parseIPCMode(in string) (container.IpcMode, error) {
+func (c *Container) parseIPCMode(ctx context.Context, in string) (container.IpcMode, error) {
 	switch in {
 	case "", "none", "private", "shareable", "host":
 		return container.IpcMode(in), nil
@@ -581,10 +684,12 @@ func (c *Container) parseIPCMode(in string) (container.IpcMode, error) {
 
 	// Note that all services that share an IPC namespace with another service actually shares a namespace
 	// with that service's first container. See https://github.com/docker/compose/blob/v2.0.0-rc.3/compose/service.py#L1379.
-	if strings.HasPrefix(in, "service:") {
-		// XXX: We need to be able to look up the container name(s) for the referenced service to be able to
-		// convert this to the `container:name` format supported by Docker.
-		return container.IpcMode(""), errors.New("service IPC mode not yet supported")
+	if service, ok := parseServiceReference(in); ok {
+		resolved, err := c.resolveServiceContainer(ctx, service)
+		if err != nil {
+			return container.IpcMode(""), fmt.Errorf("resolving IPC mode: %w", err)
+		}
+		return container.IpcMode(resolved), nil
 	}
 
 	return container.IpcMode(""), fmt.Errorf("unsupported IPC mode: %q", in)
@@ -600,14 +705,18 @@ func parseIsolation(in string) (container.Isolation, error) {
 	}
 }
 
-func (c *Container) parseNetworkMode(in string) (container.NetworkMode, error) {
-	if strings.HasPrefix(in, "service:") {
-		return container.NetworkMode(""), errors.New("service network mode not yet supported")
+func (c *Container) parseNetworkMode(ctx context.Context, in string) (container.NetworkMode, error) {
+	if service, ok := parseServiceReference(in); ok {
+		resolved, err := c.resolveServiceContainer(ctx, service)
+		if err != nil {
+			return container.NetworkMode(""), fmt.Errorf("resolving network mode: %w", err)
+		}
+		return container.NetworkMode(resolved), nil
 	}
 	return container.NetworkMode(in), nil
 }
 
-func (c *Container) parsePIDMode(in string) (container.PidMode, error) {
+func (c *Container) parsePIDMode(ctx context.Context, in string) (container.PidMode, error) {
 	switch in {
 	case "", "host":
 		return container.PidMode(in), nil
@@ -617,9 +726,49 @@ func (c *Container) parsePIDMode(in string) (container.PidMode, error) {
 		return container.PidMode(in), nil
 	}
 
+	if service, ok := parseServiceReference(in); ok {
+		resolved, err := c.resolveServiceContainer(ctx, service)
+		if err != nil {
+			return container.PidMode(""), fmt.Errorf("resolving PID mode: %w", err)
+		}
+		return container.PidMode(resolved), nil
+	}
+
 	return container.PidMode(""), fmt.Errorf("Invalid PID mode: %q", in)
 }
 
+// Extracts the service name from namespace modes of the form "service:<name>".
+func parseServiceReference(in string) (service string, ok bool) {
+	if !strings.HasPrefix(in, "service:") {
+		return "", false
+	}
+	service = strings.TrimPrefix(in, "service:")
+	return service, service != ""
+}
+
+// Resolves a service name to a namespace mode of the form "container:<id>",
+// referencing the container of the container component with that name.
+func (c *Container) resolveServiceContainer(ctx context.Context, service string) (string, error) {
+	component, err := c.LookupComponent(ctx, service)
+	if err != nil {
+		return "", fmt.Errorf("looking up service %q: %w", service, err)
+	}
+	if component == nil {
+		return "", fmt.Errorf("no such service: %q", service)
+	}
+	if component.Type != "container" {
+		return "", fmt.Errorf("service %q is a %s, not a container", service, component.Type)
+	}
+	var state State
+	if err := jsonutil.UnmarshalStringOrEmpty(component.State, &state); err != nil {
+		return "", fmt.Errorf("unmarshalling state of service %q: %w", service, err)
+	}
+	if state.ContainerID == "" {
+		return "", fmt.Errorf("service %q has no container", service)
+	}
+	return "container:" + state.ContainerID, nil
+}
+
 func (c *Container) parseVolumesFrom(in []string) ([]string, error) {
 	for _, v := range in {
 		if !strings.HasPrefix(v, "container:") {
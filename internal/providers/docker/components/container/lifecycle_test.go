@@ -0,0 +1,113 @@
+package container
+
+import (
+	"context"
+	"testing"
+
+	core "github.com/deref/exo/internal/core/api"
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestNamespaceDependencies(t *testing.T) {
+	c := &Container{}
+	output, err := c.Dependencies(context.Background(), &core.DependenciesInput{
+		Spec: `
+image: app
+depends_on: [db]
+network_mode: service:vpn
+pid: service:db
+ipc: host
+`,
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"db", "vpn"}, output.Components)
+	}
+}
+
+func TestParseServiceReference(t *testing.T) {
+	service, ok := parseServiceReference("service:vpn")
+	assert.True(t, ok)
+	assert.Equal(t, "vpn", service)
+
+	_, ok = parseServiceReference("container:abc")
+	assert.False(t, ok)
+
+	_, ok = parseServiceReference("service:")
+	assert.False(t, ok)
+}
+
+func TestDeployRestartPolicy(t *testing.T) {
+	check := func(spec string, expected container.RestartPolicy) {
+		var service Spec
+		if assert.NoError(t, yaml.Unmarshal([]byte(spec), &service)) {
+			assert.Equal(t, expected, restartPolicy(&service))
+		}
+	}
+	check(`restart: unless-stopped`, container.RestartPolicy{Name: "unless-stopped"})
+	check(`
+restart: "no"
+deploy:
+  restart_policy:
+    condition: any
+`, container.RestartPolicy{Name: "no"})
+	check(`
+deploy:
+  restart_policy:
+    condition: on-failure
+    max_attempts: 3
+`, container.RestartPolicy{Name: "on-failure", MaximumRetryCount: 3})
+	check(`
+deploy:
+  restart_policy:
+    condition: none
+`, container.RestartPolicy{Name: "no"})
+	check(`
+deploy:
+  restart_policy: {}
+`, container.RestartPolicy{Name: "always"})
+}
+
+func TestApplyDeployResources(t *testing.T) {
+	resources := func(spec string) container.Resources {
+		var service Spec
+		if err := yaml.Unmarshal([]byte(spec), &service); err != nil {
+			t.Fatal(err)
+		}
+		return containerResources(&service)
+	}
+
+	// Deploy resources take precedence over the top-level fields.
+	res := resources(`
+mem_limit: 1g
+mem_reservation: 1g
+pids_limit: 100
+deploy:
+  resources:
+    limits:
+      cpus: "0.25"
+      memory: 50m
+      pids: 10
+    reservations:
+      memory: 20m
+`)
+	pids := int64(10)
+	assert.Equal(t, int64(250000000), res.NanoCPUs)
+	assert.Equal(t, int64(50*1024*1024), res.Memory)
+	assert.Equal(t, int64(20*1024*1024), res.MemoryReservation)
+	assert.Equal(t, &pids, res.PidsLimit)
+
+	// Top-level fields not given by deploy are kept.
+	res = resources(`
+mem_limit: 1g
+mem_reservation: 512m
+deploy:
+  resources:
+    limits:
+      cpus: "0.25"
+`)
+	assert.Equal(t, int64(250000000), res.NanoCPUs)
+	assert.Equal(t, int64(1024*1024*1024), res.Memory)
+	assert.Equal(t, int64(512*1024*1024), res.MemoryReservation)
+}
@@ -0,0 +1,183 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	core "github.com/deref/exo/internal/core/api"
+	"github.com/deref/exo/internal/providers/docker/compose"
+	"github.com/docker/docker/api/types/container"
+)
+
+// PlanUpdate reports whether the container must be replaced to apply a new
+// spec. Changes to resource limits, the restart policy, and network
+// attachments can be applied to a running container. Changes to depends_on
+// only affect exo's dependency graph.
+func (c *Container) PlanUpdate(ctx context.Context, input *core.PlanUpdateInput) (*core.PlanUpdateOutput, error) {
+	var oldSpec, newSpec Spec
+	if err := c.LoadSpec(input.OldSpec, &oldSpec); err != nil {
+		return nil, fmt.Errorf("loading old spec: %w", err)
+	}
+	if err := c.LoadSpec(input.NewSpec, &newSpec); err != nil {
+		return nil, fmt.Errorf("loading new spec: %w", err)
+	}
+	return &core.PlanUpdateOutput{
+		Replace: requiresReplacement(&oldSpec, &newSpec),
+	}, nil
+}
+
+func (c *Container) Update(ctx context.Context, input *core.UpdateInput) (*core.UpdateOutput, error) {
+	var oldSpec, newSpec Spec
+	if err := c.LoadSpec(input.OldSpec, &oldSpec); err != nil {
+		return nil, fmt.Errorf("loading old spec: %w", err)
+	}
+	if err := c.LoadSpec(input.NewSpec, &newSpec); err != nil {
+		return nil, fmt.Errorf("loading new spec: %w", err)
+	}
+	if requiresReplacement(&oldSpec, &newSpec) {
+		return nil, errors.New("spec change cannot be applied in place")
+	}
+	if c.State.ContainerID == "" {
+		return &core.UpdateOutput{}, nil
+	}
+
+	oldConfig := updateConfig(&oldSpec)
+	newConfig := updateConfig(&newSpec)
+	if !reflect.DeepEqual(oldConfig, newConfig) {
+		if _, err := c.Docker.ContainerUpdate(ctx, c.State.ContainerID, newConfig); err != nil {
+			return nil, fmt.Errorf("updating container: %w", err)
+		}
+	}
+
+	if err := c.updateNetworks(ctx, &oldSpec, &newSpec); err != nil {
+		return nil, fmt.Errorf("updating networks: %w", err)
+	}
+
+	return &core.UpdateOutput{}, nil
+}
+
+func (c *Container) updateNetworks(ctx context.Context, oldSpec, newSpec *Spec) error {
+	oldNetworks := make(map[string]compose.ServiceNetwork, len(oldSpec.Networks.Items))
+	for _, network := range oldSpec.Networks.Items {
+		oldNetworks[network.Key] = network
+	}
+	newNetworks := make(map[string]compose.ServiceNetwork, len(newSpec.Networks.Items))
+	for _, network := range newSpec.Networks.Items {
+		newNetworks[network.Key] = network
+	}
+
+	// Endpoint settings cannot be modified, so changed networks are
+	// disconnected and then connected again.
+	for _, network := range oldSpec.Networks.Items {
+		if newNetwork, ok := newNetworks[network.Key]; ok && reflect.DeepEqual(network, newNetwork) {
+			continue
+		}
+		if err := c.Docker.NetworkDisconnect(ctx, network.Key, c.State.ContainerID, false); err != nil {
+			return fmt.Errorf("disconnecting from %q: %w", network.Key, err)
+		}
+	}
+	for _, network := range newSpec.Networks.Items {
+		if oldNetwork, ok := oldNetworks[network.Key]; ok && reflect.DeepEqual(network, oldNetwork) {
+			continue
+		}
+		if err := c.Docker.NetworkConnect(ctx, network.Key, c.State.ContainerID, c.endpointSettings(network, newSpec)); err != nil {
+			return fmt.Errorf("connecting to %q: %w", network.Key, err)
+		}
+	}
+	return nil
+}
+
+// updateConfig returns the subset of a container's configuration that the
+// Docker Engine can change without recreating the container.
+func updateConfig(spec *Spec) container.UpdateConfig {
+	resources := containerResources(spec)
+	policy := restartPolicy(spec)
+	if policy.Name == "" {
+		// An empty name would leave the existing policy in place.
+		policy.Name = "no"
+	}
+	return container.UpdateConfig{
+		Resources: container.Resources{
+			BlkioWeight:        resources.BlkioWeight,
+			CPUShares:          resources.CPUShares,
+			CPUPeriod:          resources.CPUPeriod,
+			CPUQuota:           resources.CPUQuota,
+			CPURealtimePeriod:  resources.CPURealtimePeriod,
+			CPURealtimeRuntime: resources.CPURealtimeRuntime,
+			CpusetCpus:         resources.CpusetCpus,
+			Memory:             resources.Memory,
+			MemoryReservation:  resources.MemoryReservation,
+			MemorySwap:         resources.MemorySwap,
+			NanoCPUs:           resources.NanoCPUs,
+			PidsLimit:          resources.PidsLimit,
+		},
+		RestartPolicy: policy,
+	}
+}
+
+func requiresReplacement(oldSpec, newSpec *Spec) bool {
+	oldFixed := fixedSpec(oldSpec)
+	newFixed := fixedSpec(newSpec)
+	// Networks can be connected and disconnected, unless the container is
+	// attached to the default network or shares another container's network.
+	if len(oldSpec.Networks.Items) > 0 && len(newSpec.Networks.Items) > 0 {
+		oldFixed.Networks = compose.ServiceNetworks{}
+		newFixed.Networks = compose.ServiceNetworks{}
+	}
+	if !reflect.DeepEqual(oldFixed, newFixed) {
+		return true
+	}
+	return clearsResource(updateConfig(oldSpec).Resources, updateConfig(newSpec).Resources)
+}
+
+// clearsResource reports whether a resource setting is removed. The Docker
+// Engine interprets zero values in an update as "unchanged", so limits can be
+// changed but not removed from an existing container.
+func clearsResource(oldResources, newResources container.Resources) bool {
+	oldV := reflect.ValueOf(oldResources)
+	newV := reflect.ValueOf(newResources)
+	for i := 0; i < oldV.NumField(); i++ {
+		if !oldV.Field(i).IsZero() && newV.Field(i).IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// fixedSpec returns a copy of spec without the settings that can be changed
+// without replacing the container. See updateConfig.
+func fixedSpec(spec *Spec) Spec {
+	fixed := *spec
+	fixed.BlkioConfig.Weight = compose.Int{}
+	fixed.CPUShares = compose.Int{}
+	fixed.CPUPeriod = compose.Int{}
+	fixed.CPUQuota = compose.Int{}
+	fixed.CPURealtimePeriod = compose.Duration{}
+	fixed.CPURealtimeRuntime = compose.Duration{}
+	fixed.CPUSet = compose.String{}
+	fixed.MemoryLimit = compose.Bytes{}
+	fixed.MemoryReservation = compose.Bytes{}
+	fixed.MemswapLimit = compose.Bytes{}
+	fixed.PidsLimit = nil
+	fixed.Restart = compose.String{}
+	fixed.DependsOn = compose.ServiceDependencies{}
+	if spec.Deploy != nil {
+		deploy := *spec.Deploy
+		deploy.Resources.Limits.CPUs = compose.Float{}
+		deploy.Resources.Limits.Memory = compose.Bytes{}
+		deploy.Resources.Limits.Pids = nil
+		// CPU reservations are not applied, so changing them needs no replacement.
+		deploy.Resources.Reservations.CPUs = compose.Float{}
+		deploy.Resources.Reservations.Memory = compose.Bytes{}
+		deploy.RestartPolicy = nil
+		deploy.Replicas = nil
+		if reflect.DeepEqual(deploy, compose.Deploy{}) {
+			fixed.Deploy = nil
+		} else {
+			fixed.Deploy = &deploy
+		}
+	}
+	return fixed
+}
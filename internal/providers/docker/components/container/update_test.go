@@ -0,0 +1,111 @@
+package container
+
+import (
+	"context"
+	"testing"
+
+	core "github.com/deref/exo/internal/core/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanUpdate(t *testing.T) {
+	check := func(oldSpec, newSpec string, expected bool) {
+		c := &Container{}
+		output, err := c.PlanUpdate(context.Background(), &core.PlanUpdateInput{
+			OldSpec: oldSpec,
+			NewSpec: newSpec,
+		})
+		if assert.NoError(t, err) {
+			assert.Equal(t, expected, output.Replace, "%s\n=>\n%s", oldSpec, newSpec)
+		}
+	}
+
+	// Resource limits and restart policy.
+	check(`
+image: app
+mem_limit: 256m
+`, `
+image: app
+mem_limit: 512m
+restart: always
+cpu_shares: 512
+`, false)
+	check(`
+image: app
+deploy:
+  resources:
+    limits:
+      cpus: "0.5"
+`, `
+image: app
+deploy:
+  resources:
+    limits:
+      cpus: "1.5"
+  restart_policy:
+    condition: on-failure
+`, false)
+	check(`
+image: app
+deploy:
+  resources:
+    reservations:
+      cpus: "0.5"
+`, `
+image: app
+deploy:
+  resources:
+    reservations:
+      cpus: "1"
+`, false)
+
+	// Limits can be changed, but not removed.
+	check(`
+image: app
+mem_limit: 256m
+`, `
+image: app
+`, true)
+
+	// Metadata.
+	check(`
+image: app
+`, `
+image: app
+depends_on: [db]
+`, false)
+
+	// Networks.
+	check(`
+image: app
+networks: [front]
+`, `
+image: app
+networks:
+  front:
+  back:
+    aliases: [api]
+`, false)
+	check(`
+image: app
+`, `
+image: app
+networks: [front]
+`, true)
+
+	// Everything else.
+	check(`
+image: app
+`, `
+image: app:v2
+`, true)
+	check(`
+image: app
+environment:
+  A: 1
+`, `
+image: app
+environment:
+  A: 2
+`, true)
+}
@@ -14,4 +14,8 @@ type Spec = compose.Network
 
 type State struct {
 	NetworkID string `json:"networkId"`
+	// True if the network is external, and so is left in place when the component
+	// is disposed. Existing resources that are adopted without being declared
+	// external are removed like those that exo created.
+	Adopted bool `json:"adopted,omitempty"`
 }
@@ -6,9 +6,10 @@ import (
 	"fmt"
 
 	core "github.com/deref/exo/internal/core/api"
+	"github.com/deref/exo/internal/providers/docker"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
-	docker "github.com/docker/docker/client"
+	dockerclient "github.com/docker/docker/client"
 )
 
 var _ core.Lifecycle = (*Network)(nil)
@@ -38,6 +39,7 @@ func (n *Network) Initialize(ctx context.Context, input *core.InitializeInput) (
 		}
 
 		n.NetworkID = existing.ID
+		n.Adopted = true
 
 		return &core.InitializeOutput{}, nil
 	}
@@ -50,8 +52,22 @@ func (n *Network) Initialize(ctx context.Context, input *core.InitializeInput) (
 	// Another potential difficulty is that we do not add our exo labels to an adopted resource because
 	// that resouce would need to be recreated. This is not a problem for networks, but it could be
 	// problematic with volumes that may contain data that the user expects to persist across an import.
+	// Adopted resources are owned by the component from then on, and so are removed on dispose, as
+	// docker-compose would. Only external resources are left in place.
 	if existing != nil {
-		// TODO: Determine whether the existing network is compatible with the spec.
+		var compat docker.Compatibility
+		compat.CheckString("driver", spec.Driver.Value, existing.Driver, "bridge")
+		compat.CheckOptions("driver option", spec.DriverOpts.Map(), existing.Options)
+		compat.CheckBool("internal", spec.Internal.Value, existing.Internal)
+		compat.CheckBool("attachable", spec.Attachable.Value, existing.Attachable)
+		compat.CheckBool("enable_ipv6", spec.EnableIPv6.Value, existing.EnableIPv6)
+		compat.CheckLabels(spec.Labels.Map(), existing.Labels)
+		if err := compat.Err("network", existing.Name); err != nil {
+			return nil, err
+		}
+		for _, warning := range compat.Warnings {
+			n.Warnf(ctx, "adopting existing network %q: %s", existing.Name, warning)
+		}
 		n.NetworkID = existing.ID
 		return &core.InitializeOutput{}, nil
 	}
@@ -73,8 +89,8 @@ func (n *Network) Initialize(ctx context.Context, input *core.InitializeInput) (
 		//Ingress        bool
 		//ConfigOnly     bool
 		//ConfigFrom     *network.ConfigReference
-		//Options        map[string]string
-		Labels: labels,
+		Options: spec.DriverOpts.Map(),
+		Labels:  labels,
 	}
 	createdBody, err := n.Docker.NetworkCreate(ctx, spec.Name.Value, opts)
 	if err != nil {
@@ -94,8 +110,14 @@ func (n *Network) Dispose(ctx context.Context, input *core.DisposeInput) (*core.
 	if n.NetworkID == "" {
 		return &core.DisposeOutput{}, nil
 	}
+	if n.Adopted {
+		n.Logger.Infof("leaving adopted network in place: %q", n.NetworkID)
+		n.NetworkID = ""
+		n.Adopted = false
+		return &core.DisposeOutput{}, nil
+	}
 	err := n.Docker.NetworkRemove(ctx, n.NetworkID)
-	if docker.IsErrNotFound(err) {
+	if dockerclient.IsErrNotFound(err) {
 		n.Logger.Infof("network to be removed not found: %q", n.NetworkID)
 		err = nil
 	}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	core "github.com/deref/exo/internal/core/api"
+	"github.com/deref/exo/internal/providers/docker"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/volume"
 	dockerclient "github.com/docker/docker/client"
@@ -22,11 +23,33 @@ func (v *Volume) Initialize(ctx context.Context, input *core.InitializeInput) (o
 		return nil, fmt.Errorf("loading spec: %w", err)
 	}
 
-	// See NOTE: [ADOPT COMPOSE RESOURCES].
-	if existing, err := v.findExistingVolume(ctx, spec.Name.Value); err != nil {
+	existing, err := v.findExistingVolume(ctx, spec.Name.Value)
+	if err != nil {
 		return nil, fmt.Errorf("looking up existing volume: %w", err)
-	} else if existing != nil {
-		// TODO: Determine whether the existing volume is compatible with the spec.
+	}
+
+	if spec.External.Value {
+		if existing == nil {
+			return nil, fmt.Errorf("external volume %q not found", spec.Name.Value)
+		}
+		v.VolumeName = existing.Name
+		v.Adopted = true
+		return &core.InitializeOutput{}, nil
+	}
+
+	// See NOTE: [ADOPT COMPOSE RESOURCES].
+	if existing != nil {
+		var compat docker.Compatibility
+		compat.CheckString("driver", spec.Driver.Value, existing.Driver, "local")
+		compat.CheckOptions("driver option", spec.DriverOpts.Map(), existing.Options)
+		compat.CheckLabels(spec.Labels.Map(), existing.Labels)
+		if err := compat.Err("volume", existing.Name); err != nil {
+			return nil, err
+		}
+		for _, warning := range compat.Warnings {
+			v.Warnf(ctx, "adopting existing volume %q: %s", existing.Name, warning)
+		}
+		v.VolumeName = existing.Name
 		return &core.InitializeOutput{}, nil
 	}
 
@@ -59,6 +82,12 @@ func (v *Volume) Dispose(ctx context.Context, input *core.DisposeInput) (*core.D
 	if v.VolumeName == "" {
 		return &core.DisposeOutput{}, nil
 	}
+	if v.Adopted {
+		v.Logger.Infof("leaving adopted volume in place: %q", v.VolumeName)
+		v.VolumeName = ""
+		v.Adopted = false
+		return &core.DisposeOutput{}, nil
+	}
 	force := false
 	err := v.Docker.VolumeRemove(ctx, v.VolumeName, force)
 	if dockerclient.IsErrNotFound(err) {
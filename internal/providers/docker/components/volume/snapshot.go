@@ -0,0 +1,189 @@
+package volume
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/deref/exo/internal/providers/docker"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
+	dockerclient "github.com/docker/docker/client"
+)
+
+// NOTE [VOLUME HELPER CONTAINERS]: Volume contents are only accessible from
+// inside a container, and the daemon may not share a filesystem with exo.
+// Snapshots are therefore taken by creating, but not starting, a helper
+// container that mounts the volume, and streaming an archive of the mount
+// point through the engine API.
+
+const helperImage = "busybox:1.34"
+
+// Mount point of the volume in helper containers. Archives produced by
+// Snapshot contain entries prefixed with the base name of this path.
+const helperMountPoint = "/volume"
+
+// Snapshot writes a tar archive of the contents of a volume to w.
+func Snapshot(ctx context.Context, rt docker.Runtime, volumeName string, w io.Writer) error {
+	helperID, err := createHelper(ctx, rt, volumeName, true, nil)
+	if err != nil {
+		return err
+	}
+	defer removeHelper(ctx, rt, helperID)
+
+	rc, _, err := rt.CopyFromContainer(ctx, helperID, helperMountPoint)
+	if err != nil {
+		return fmt.Errorf("archiving volume: %w", err)
+	}
+	defer rc.Close()
+	if _, err := io.Copy(w, rc); err != nil {
+		return fmt.Errorf("archiving volume: %w", err)
+	}
+	return nil
+}
+
+// Restore replaces the contents of a volume with a tar archive produced by
+// Snapshot.
+func Restore(ctx context.Context, rt docker.Runtime, volumeName string, r io.Reader) error {
+	if err := clearVolume(ctx, rt, volumeName); err != nil {
+		return err
+	}
+	helperID, err := createHelper(ctx, rt, volumeName, false, nil)
+	if err != nil {
+		return err
+	}
+	defer removeHelper(ctx, rt, helperID)
+
+	if err := rt.CopyToContainer(ctx, helperID, "/", r, types.CopyToContainerOptions{}); err != nil {
+		return fmt.Errorf("extracting archive: %w", err)
+	}
+	return nil
+}
+
+// Clone replaces the contents of the target volume with those of the source
+// volume.
+func Clone(ctx context.Context, rt docker.Runtime, sourceVolumeName, targetVolumeName string) error {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(Snapshot(ctx, rt, sourceVolumeName, pw))
+	}()
+	err := Restore(ctx, rt, targetVolumeName, pr)
+	// Unblock the snapshot if the restore failed before consuming it.
+	pr.CloseWithError(err)
+	return err
+}
+
+// RunningContainers returns the names of running containers that mount a
+// volume. A snapshot taken while a process is writing to the volume may be
+// inconsistent.
+func RunningContainers(ctx context.Context, rt docker.Runtime, volumeName string) ([]string, error) {
+	containers, err := rt.ContainerList(ctx, types.ContainerListOptions{
+		Filters: filters.NewArgs(filters.KeyValuePair{
+			Key:   "volume",
+			Value: volumeName,
+		}),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing containers: %w", err)
+	}
+	names := make([]string, len(containers))
+	for i, c := range containers {
+		names[i] = c.ID
+		if len(c.Names) > 0 {
+			names[i] = strings.TrimPrefix(c.Names[0], "/")
+		}
+	}
+	return names, nil
+}
+
+func clearVolume(ctx context.Context, rt docker.Runtime, volumeName string) error {
+	// Overwriting files out from under a running process is unlikely to leave
+	// it in a consistent state.
+	running, err := RunningContainers(ctx, rt, volumeName)
+	if err != nil {
+		return err
+	}
+	if len(running) > 0 {
+		return fmt.Errorf("volume %q is in use by running container(s) %s; stop them first", volumeName, strings.Join(running, ", "))
+	}
+
+	// Globs also match hidden entries, but not "." or "..".
+	script := fmt.Sprintf("rm -rf %[1]s/..?* %[1]s/.[!.]* %[1]s/*", helperMountPoint)
+	helperID, err := createHelper(ctx, rt, volumeName, false, []string{"sh", "-c", script})
+	if err != nil {
+		return err
+	}
+	defer removeHelper(ctx, rt, helperID)
+
+	if err := rt.ContainerStart(ctx, helperID, types.ContainerStartOptions{}); err != nil {
+		return fmt.Errorf("starting helper container: %w", err)
+	}
+	statusC, errC := rt.ContainerWait(ctx, helperID, container.WaitConditionNotRunning)
+	select {
+	case status := <-statusC:
+		if status.StatusCode != 0 {
+			return fmt.Errorf("clearing volume exited with status %d", status.StatusCode)
+		}
+		return nil
+	case err := <-errC:
+		return fmt.Errorf("waiting for helper container: %w", err)
+	}
+}
+
+func createHelper(ctx context.Context, rt docker.Runtime, volumeName string, readOnly bool, cmd []string) (string, error) {
+	if err := ensureHelperImage(ctx, rt); err != nil {
+		return "", err
+	}
+	created, err := rt.ContainerCreate(ctx,
+		&container.Config{
+			Image: helperImage,
+			Cmd:   cmd,
+			Labels: map[string]string{
+				"io.deref.exo.helper": "volume",
+			},
+		},
+		&container.HostConfig{
+			Mounts: []mount.Mount{
+				{
+					Type:     mount.TypeVolume,
+					Source:   volumeName,
+					Target:   helperMountPoint,
+					ReadOnly: readOnly,
+				},
+			},
+		},
+		nil, nil, "")
+	if err != nil {
+		return "", fmt.Errorf("creating helper container: %w", err)
+	}
+	return created.ID, nil
+}
+
+func removeHelper(ctx context.Context, rt docker.Runtime, containerID string) {
+	_ = rt.ContainerRemove(ctx, containerID, types.ContainerRemoveOptions{
+		Force: true,
+	})
+}
+
+func ensureHelperImage(ctx context.Context, rt docker.Runtime) error {
+	_, _, err := rt.ImageInspectWithRaw(ctx, helperImage)
+	if err == nil {
+		return nil
+	}
+	if !dockerclient.IsErrNotFound(err) {
+		return fmt.Errorf("inspecting helper image: %w", err)
+	}
+	rc, err := rt.ImagePull(ctx, helperImage, types.ImagePullOptions{})
+	if err != nil {
+		return fmt.Errorf("pulling helper image: %w", err)
+	}
+	defer rc.Close()
+	if _, err := io.Copy(ioutil.Discard, rc); err != nil {
+		return fmt.Errorf("pulling helper image: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package volume
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/deref/exo/internal/providers/docker"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRuntime implements the parts of the engine API used by snapshots. Any
+// other method panics.
+type fakeRuntime struct {
+	docker.Runtime
+	// Running containers, keyed by the name of the volume they mount.
+	running  map[string][]types.Container
+	contents string
+	helpers  []string
+	removed  []string
+}
+
+func (rt *fakeRuntime) ContainerList(ctx context.Context, opts types.ContainerListOptions) ([]types.Container, error) {
+	return rt.running[opts.Filters.Get("volume")[0]], nil
+}
+
+func (rt *fakeRuntime) ImageInspectWithRaw(ctx context.Context, ref string) (types.ImageInspect, []byte, error) {
+	return types.ImageInspect{ID: ref}, nil, nil
+}
+
+func (rt *fakeRuntime) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *v1.Platform, containerName string) (container.ContainerCreateCreatedBody, error) {
+	id := "helper-" + hostConfig.Mounts[0].Source
+	rt.helpers = append(rt.helpers, id)
+	return container.ContainerCreateCreatedBody{ID: id}, nil
+}
+
+func (rt *fakeRuntime) CopyFromContainer(ctx context.Context, containerID, srcPath string) (io.ReadCloser, types.ContainerPathStat, error) {
+	return ioutil.NopCloser(strings.NewReader(rt.contents)), types.ContainerPathStat{}, nil
+}
+
+func (rt *fakeRuntime) ContainerRemove(ctx context.Context, containerID string, options types.ContainerRemoveOptions) error {
+	rt.removed = append(rt.removed, containerID)
+	return nil
+}
+
+func TestSnapshot(t *testing.T) {
+	rt := &fakeRuntime{contents: "archive"}
+	var out strings.Builder
+	assert.NoError(t, Snapshot(context.Background(), rt, "data", &out))
+	assert.Equal(t, "archive", out.String())
+	assert.Equal(t, []string{"helper-data"}, rt.helpers)
+	assert.Equal(t, rt.helpers, rt.removed)
+}
+
+func TestRunningContainers(t *testing.T) {
+	ctx := context.Background()
+	rt := &fakeRuntime{
+		running: map[string][]types.Container{
+			"data": {
+				{ID: "abc123", Names: []string{"/app_db_1"}},
+				{ID: "def456"},
+			},
+		},
+	}
+	running, err := RunningContainers(ctx, rt, "data")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"app_db_1", "def456"}, running)
+	}
+	running, err = RunningContainers(ctx, rt, "other")
+	if assert.NoError(t, err) {
+		assert.Empty(t, running)
+	}
+
+	// Restoring in to a volume that is in use is refused before anything is
+	// changed.
+	err = Restore(ctx, rt, "data", strings.NewReader(""))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "app_db_1")
+	}
+	assert.Empty(t, rt.helpers)
+}
@@ -17,6 +17,11 @@ type BuildLongForm struct {
 	Labels     Dictionary `yaml:"labels,omitempty"`
 	ShmSize    Bytes      `yaml:"shm_size,omitempty"`
 	Target     String     `yaml:"target,omitempty"`
+	// Secrets and SSH require BuildKit.
+	Secrets []BuildSecret `yaml:"secrets,omitempty"`
+	// SSH agent sockets or keys to expose to the build, in the form
+	// "default" or "id=path[,path...]".
+	SSH Dictionary `yaml:"ssh,omitempty"`
 }
 
 func (b Build) MarshalYAML() (interface{}, error) {
@@ -0,0 +1,68 @@
+package compose
+
+import "gopkg.in/yaml.v3"
+
+// BuildSecret grants a build access to a secret, which Dockerfile instructions
+// can mount with `RUN --mount=type=secret,id=<id>`. See ID.
+type BuildSecret struct {
+	IsShortSyntax bool
+	Source        String
+	BuildSecretLongForm
+}
+
+type BuildSecretLongForm struct {
+	Source String `yaml:"source,omitempty"`
+	// Target is the id of the secret within the build. Defaults to the source.
+	Target String `yaml:"target,omitempty"`
+	// File and Environment are not part of the compose specification for
+	// build secrets, which instead refer to a top-level secret definition.
+	// The compose importer copies them from there, so that the spec is self
+	// contained.
+	File        String `yaml:"file,omitempty"`
+	Environment String `yaml:"environment,omitempty"`
+}
+
+func (secret *BuildSecret) UnmarshalYAML(node *yaml.Node) error {
+	var err error
+	if node.Tag == "!!str" {
+		secret.IsShortSyntax = true
+		err = node.Decode(&secret.Source)
+	} else {
+		err = node.Decode(&secret.BuildSecretLongForm)
+		secret.Source = secret.BuildSecretLongForm.Source
+	}
+	_ = secret.Interpolate(ErrEnvironment)
+	return err
+}
+
+// ID returns the id by which the build refers to the secret.
+func (secret *BuildSecret) ID() string {
+	if secret.Target.Value != "" {
+		return secret.Target.Value
+	}
+	return secret.Source.Value
+}
+
+func (secret *BuildSecret) Interpolate(env Environment) error {
+	if secret.IsShortSyntax {
+		return secret.Source.Interpolate(env)
+	}
+	if err := secret.BuildSecretLongForm.Interpolate(env); err != nil {
+		return err
+	}
+	secret.Source = secret.BuildSecretLongForm.Source
+	return nil
+}
+
+func (secret BuildSecret) MarshalYAML() (interface{}, error) {
+	if secret.IsShortSyntax && secret.Target.Expression == "" && secret.File.Expression == "" && secret.Environment.Expression == "" {
+		return secret.Source, nil
+	}
+	longForm := secret.BuildSecretLongForm
+	longForm.Source = secret.Source
+	return longForm, nil
+}
+
+func (secret *BuildSecretLongForm) Interpolate(env Environment) error {
+	return interpolateStruct(secret, env)
+}
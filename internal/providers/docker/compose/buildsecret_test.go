@@ -0,0 +1,51 @@
+package compose
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildSecretYAML(t *testing.T) {
+	testYAML(t, "short", `npmrc`, BuildSecret{
+		IsShortSyntax: true,
+		Source:        MakeString("npmrc"),
+	})
+	testYAML(t, "long", `
+source: npmrc
+file: ./.npmrc
+`, BuildSecret{
+		Source: MakeString("npmrc"),
+		BuildSecretLongForm: BuildSecretLongForm{
+			Source: MakeString("npmrc"),
+			File:   MakeString("./.npmrc"),
+		},
+	})
+	testYAML(t, "target", `
+source: npmrc
+target: npm
+`, BuildSecret{
+		Source: MakeString("npmrc"),
+		BuildSecretLongForm: BuildSecretLongForm{
+			Source: MakeString("npmrc"),
+			Target: MakeString("npm"),
+		},
+	})
+	assertInterpolated(t, map[string]string{"name": "token"}, `
+source: ${name}
+environment: TOKEN
+`, BuildSecret{
+		Source: MakeString("${name}").WithValue("token"),
+		BuildSecretLongForm: BuildSecretLongForm{
+			Source:      MakeString("${name}").WithValue("token"),
+			Environment: MakeString("TOKEN"),
+		},
+	})
+}
+
+func TestBuildSecretID(t *testing.T) {
+	secret := BuildSecret{Source: MakeString("npmrc")}
+	assert.Equal(t, "npmrc", secret.ID())
+	secret.Target = MakeString("npm")
+	assert.Equal(t, "npm", secret.ID())
+}
@@ -0,0 +1,61 @@
+package compose
+
+// Deploy describes how a service should be deployed. Only the subset of
+// settings that apply to a single-host engine are interpreted. See NOTE
+// [DOCKER SWARM FEATURES].
+type Deploy struct {
+	Resources     DeployResources `yaml:"resources,omitempty"`
+	RestartPolicy *RestartPolicy  `yaml:"restart_policy,omitempty"`
+	Replicas      *Int            `yaml:"replicas,omitempty"`
+
+	EndpointMode   Ignored `yaml:"endpoint_mode,omitempty"`
+	Labels         Ignored `yaml:"labels,omitempty"`
+	Mode           Ignored `yaml:"mode,omitempty"`
+	Placement      Ignored `yaml:"placement,omitempty"`
+	RollbackConfig Ignored `yaml:"rollback_config,omitempty"`
+	UpdateConfig   Ignored `yaml:"update_config,omitempty"`
+}
+
+func (d *Deploy) Interpolate(env Environment) error {
+	return interpolateStruct(d, env)
+}
+
+type DeployResources struct {
+	Limits       ResourceLimits `yaml:"limits,omitempty"`
+	Reservations ResourceLimits `yaml:"reservations,omitempty"`
+}
+
+func (r *DeployResources) Interpolate(env Environment) error {
+	return interpolateStruct(r, env)
+}
+
+type ResourceLimits struct {
+	CPUs   Float `yaml:"cpus,omitempty"`
+	Memory Bytes `yaml:"memory,omitempty"`
+	Pids   *Int  `yaml:"pids,omitempty"`
+
+	// Generic resources and devices are only meaningful to a swarm scheduler.
+	Devices          Ignored `yaml:"devices,omitempty"`
+	GenericResources Ignored `yaml:"generic_resources,omitempty"`
+}
+
+func (r *ResourceLimits) Interpolate(env Environment) error {
+	return interpolateStruct(r, env)
+}
+
+// NanoCPUs returns the CPU quota in units of 10^-9 CPUs, as expected by the
+// Docker Engine API.
+func (r ResourceLimits) NanoCPUs() int64 {
+	return int64(r.CPUs.Value * 1e9)
+}
+
+type RestartPolicy struct {
+	Condition   String   `yaml:"condition,omitempty"`
+	Delay       Duration `yaml:"delay,omitempty"`
+	MaxAttempts *Int     `yaml:"max_attempts,omitempty"`
+	Window      Duration `yaml:"window,omitempty"`
+}
+
+func (rp *RestartPolicy) Interpolate(env Environment) error {
+	return interpolateStruct(rp, env)
+}
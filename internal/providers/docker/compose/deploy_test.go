@@ -0,0 +1,59 @@
+package compose
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeployYAML(t *testing.T) {
+	assertInterpolated(t, map[string]string{"cpus": "0.5"}, `
+resources:
+  limits:
+    cpus: ${cpus}
+    memory: 50m
+    pids: 100
+  reservations:
+    memory: 20m
+restart_policy:
+  condition: on-failure
+  delay: 5s
+  max_attempts: 3
+`, Deploy{
+		Resources: DeployResources{
+			Limits: ResourceLimits{
+				CPUs: Float{
+					String: MakeString("${cpus}").WithValue("0.5"),
+					Value:  0.5,
+				},
+				Memory: Bytes{
+					String:   MakeString("50m"),
+					Quantity: 50,
+					Unit:     ByteUnit{Suffix: "m", Scalar: 1024 * 1024},
+				},
+				Pids: NewInt(100),
+			},
+			Reservations: ResourceLimits{
+				Memory: Bytes{
+					String:   MakeString("20m"),
+					Quantity: 20,
+					Unit:     ByteUnit{Suffix: "m", Scalar: 1024 * 1024},
+				},
+			},
+		},
+		RestartPolicy: &RestartPolicy{
+			Condition: MakeString("on-failure"),
+			Delay: Duration{
+				String:   MakeString("5s"),
+				Duration: 5 * time.Second,
+			},
+			MaxAttempts: NewInt(3),
+		},
+	})
+}
+
+func TestNanoCPUs(t *testing.T) {
+	assert.Equal(t, int64(1500000000), ResourceLimits{CPUs: MakeFloat(1.5)}.NanoCPUs())
+	assert.Equal(t, int64(0), ResourceLimits{}.NanoCPUs())
+}
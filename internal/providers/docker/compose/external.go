@@ -0,0 +1,40 @@
+package compose
+
+import "gopkg.in/yaml.v3"
+
+// External marks a volume or network as created outside of Compose. Besides
+// a boolean, Compose accepts the deprecated long form `external: {name: x}`,
+// which implies true and names the existing resource.
+type External struct {
+	Bool
+	Name String
+}
+
+func (e External) MarshalYAML() (interface{}, error) {
+	if e.Name.Expression != "" {
+		return map[string]String{"name": e.Name}, nil
+	}
+	return e.Bool.String, nil
+}
+
+func (e *External) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return e.Bool.UnmarshalYAML(node)
+	}
+	var longForm struct {
+		Name String `yaml:"name"`
+	}
+	if err := node.Decode(&longForm); err != nil {
+		return err
+	}
+	e.Bool = MakeBool(true)
+	e.Name = longForm.Name
+	return nil
+}
+
+func (e *External) Interpolate(env Environment) error {
+	if err := e.Bool.Interpolate(env); err != nil {
+		return err
+	}
+	return e.Name.Interpolate(env)
+}
@@ -0,0 +1,19 @@
+package compose
+
+import "testing"
+
+func TestExternalYAML(t *testing.T) {
+	testYAML(t, "short", `true`, External{
+		Bool: MakeBool(true),
+	})
+	testYAML(t, "long", `name: shared`, External{
+		Bool: MakeBool(true),
+		Name: MakeString("shared"),
+	})
+	assertInterpolated(t, map[string]string{"network": "shared"}, `
+name: ${network}
+`, External{
+		Bool: MakeBool(true),
+		Name: MakeString("${network}").WithValue("shared"),
+	})
+}
@@ -10,7 +10,7 @@ type Network struct {
 	EnableIPv6 Bool       `yaml:"enable_ipv6,omitempty"`
 	Internal   Bool       `yaml:"internal,omitempty"`
 	Labels     Dictionary `yaml:"labels,omitempty"`
-	External   Bool       `yaml:"external,omitempty"`
+	External   External   `yaml:"external,omitempty"`
 }
 
 func (n *Network) Interpolate(env Environment) error {
@@ -62,3 +62,39 @@ func (i *Int) Interpolate(env Environment) error {
 	}
 	return err
 }
+
+type Float struct {
+	String
+	Value float64
+}
+
+func MakeFloat(f float64) Float {
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	return Float{
+		String: String{
+			Tag:        "!!float",
+			Expression: s,
+			Value:      s,
+		},
+		Value: f,
+	}
+}
+
+func (f *Float) UnmarshalYAML(node *yaml.Node) error {
+	if err := f.String.UnmarshalYAML(node); err != nil {
+		return err
+	}
+	_ = f.Interpolate(ErrEnvironment)
+	return nil
+}
+
+func (f *Float) Interpolate(env Environment) error {
+	if err := f.String.Interpolate(env); err != nil {
+		return err
+	}
+	var err error
+	if f.String.Value != "" {
+		f.Value, err = strconv.ParseFloat(f.String.Value, 64)
+	}
+	return err
+}
@@ -53,7 +53,8 @@ type Service struct {
 	MacAddress       String          `yaml:"mac_address,omitempty"`
 	MemorySwappiness *Int            `yaml:"mem_swappiness,omitempty"`
 	// MemoryLimit and MemoryReservation can be specified either as strings or integers.
-	// TODO: Deprecate these fields once we support `deploy.limits.memory` and `deploy.reservations.memory`.
+	// They are superseded by `deploy.resources.limits.memory` and
+	// `deploy.resources.reservations.memory` respectively.
 	MemoryLimit       Bytes `yaml:"mem_limit,omitempty"`
 	MemoryReservation Bytes `yaml:"mem_reservation,omitempty"`
 
@@ -91,8 +92,9 @@ type Service struct {
 	// Docker-Compose manages local, single-container deployments as well as Docker Swarm
 	// deployments. Since Swarm is not as widely used as Kubernetes, support for the Swarm
 	// features that Docker-Compose includes is not a top priority. The settings listed
-	// below are the ones that are applicable to a Swarm deployment.
-	Deploy  Ignored `yaml:"deploy,omitempty"`
+	// below are the ones that are applicable to a Swarm deployment. Of Deploy, only
+	// resources and restart_policy are applied to the container.
+	Deploy  *Deploy `yaml:"deploy,omitempty"`
 	Scale   Ignored `yaml:"scale,omitempty"`
 	Secrets Ignored `yaml:"secrets,omitempty"`
 }
@@ -5,9 +5,9 @@ type Volume struct {
 
 	Driver     String     `yaml:"driver,omitempty"`
 	DriverOpts Dictionary `yaml:"driver_opts,omitempty"`
-	// TODO: external
-	Labels Dictionary `yaml:"labels,omitempty"`
-	Name   String     `yaml:"name,omitempty"`
+	External   External   `yaml:"external,omitempty"`
+	Labels     Dictionary `yaml:"labels,omitempty"`
+	Name       String     `yaml:"name,omitempty"`
 }
 
 func (v *Volume) Interpolate(env Environment) error {
@@ -15,8 +15,8 @@ type ContainerStats struct {
 			UsageInKernelmode uint64 `json:"usage_in_kernelmode"`
 			UsageInUsermode   uint64 `json:"usage_in_usermode"`
 		} `json:"cpu_usage"`
-		OnlineCpus     int64 `json:"online_cpus"`
-		SystemCPUUsage int64 `json:"system_cpu_usage"`
+		OnlineCPUs     int64  `json:"online_cpus"`
+		SystemCPUUsage uint64 `json:"system_cpu_usage"`
 		ThrottlingData struct {
 			Periods          int64 `json:"periods"`
 			ThrottledPeriods int64 `json:"throttled_periods"`
@@ -31,6 +31,7 @@ type ContainerStats struct {
 			ActiveFile            int64 `json:"active_file"`
 			Anon                  int64 `json:"anon"`
 			AnonThp               int64 `json:"anon_thp"`
+			Cache                 int64 `json:"cache"`
 			File                  int64 `json:"file"`
 			FileDirty             int64 `json:"file_dirty"`
 			FileMapped            int64 `json:"file_mapped"`
@@ -54,6 +55,7 @@ type ContainerStats struct {
 			Sock                  int64 `json:"sock"`
 			ThpCollapseAlloc      int64 `json:"thp_collapse_alloc"`
 			ThpFaultAlloc         int64 `json:"thp_fault_alloc"`
+			TotalInactiveFile     int64 `json:"total_inactive_file"`
 			Unevictable           int64 `json:"unevictable"`
 			WorkingsetActivate    int64 `json:"workingset_activate"`
 			WorkingsetNodereclaim int64 `json:"workingset_nodereclaim"`
@@ -81,12 +83,12 @@ type ContainerStats struct {
 	} `json:"pids_stats"`
 	PreCPUStats struct {
 		CPUUsage struct {
-			TotalUsage        int64 `json:"total_usage"`
-			UsageInKernelmode int64 `json:"usage_in_kernelmode"`
-			UsageInUsermode   int64 `json:"usage_in_usermode"`
+			TotalUsage        uint64 `json:"total_usage"`
+			UsageInKernelmode uint64 `json:"usage_in_kernelmode"`
+			UsageInUsermode   uint64 `json:"usage_in_usermode"`
 		} `json:"cpu_usage"`
-		OnlineCPUs     int64 `json:"online_cpus"`
-		SystemCPUUsage int64 `json:"system_cpu_usage"`
+		OnlineCPUs     int64  `json:"online_cpus"`
+		SystemCPUUsage uint64 `json:"system_cpu_usage"`
 		ThrottlingData struct {
 			Periods          int64 `json:"periods"`
 			ThrottledPeriods int64 `json:"throttled_periods"`
@@ -97,3 +99,42 @@ type ContainerStats struct {
 	Read         string   `json:"read"`
 	StorageStats struct{} `json:"storage_stats"`
 }
+
+// CPUPercent computes CPU utilization between the previous and current
+// samples, scaled such that 100 percent represents one fully utilized core.
+// This matches the calculation performed by `docker stats`.
+func (stats *ContainerStats) CPUPercent() (float64, bool) {
+	if stats.CPUStats.SystemCPUUsage <= stats.PreCPUStats.SystemCPUUsage {
+		return 0, false
+	}
+	if stats.CPUStats.CPUUsage.TotalUsage < stats.PreCPUStats.CPUUsage.TotalUsage {
+		return 0, false
+	}
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage - stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemCPUUsage - stats.PreCPUStats.SystemCPUUsage)
+	onlineCPUs := stats.CPUStats.OnlineCPUs
+	if onlineCPUs == 0 {
+		onlineCPUs = 1
+	}
+	return cpuDelta / systemDelta * float64(onlineCPUs) * 100, true
+}
+
+// ResidentMemory approximates the container's resident set size by excluding
+// reclaimable page cache from total memory usage. This matches the calculation
+// performed by `docker stats`.
+func (stats *ContainerStats) ResidentMemory() uint64 {
+	usage := stats.MemoryStats.Usage
+	var cache uint64
+	switch {
+	// cgroup v1.
+	case stats.MemoryStats.Stats.TotalInactiveFile > 0:
+		cache = uint64(stats.MemoryStats.Stats.TotalInactiveFile)
+	// cgroup v2.
+	case stats.MemoryStats.Stats.InactiveFile > 0:
+		cache = uint64(stats.MemoryStats.Stats.InactiveFile)
+	}
+	if cache < usage {
+		return usage - cache
+	}
+	return usage
+}
@@ -0,0 +1,40 @@
+package docker
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainerStats(t *testing.T) {
+	var stats ContainerStats
+	err := json.Unmarshal([]byte(`{
+		"cpu_stats": {
+			"cpu_usage": {"total_usage": 3000000},
+			"online_cpus": 4,
+			"system_cpu_usage": 200000000
+		},
+		"precpu_stats": {
+			"cpu_usage": {"total_usage": 1000000},
+			"online_cpus": 4,
+			"system_cpu_usage": 100000000
+		},
+		"memory_stats": {
+			"usage": 10000,
+			"stats": {"inactive_file": 2500}
+		}
+	}`), &stats)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cpuPercent, ok := stats.CPUPercent()
+	assert.True(t, ok)
+	assert.InDelta(t, 8.0, cpuPercent, 0.0001)
+	assert.Equal(t, uint64(7500), stats.ResidentMemory())
+
+	var first ContainerStats
+	_, ok = first.CPUPercent()
+	assert.False(t, ok)
+}
@@ -0,0 +1,56 @@
+package docker
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ImageRepository returns the name of the repository that images built for a
+// component are tagged in. Repository names must be lowercase alphanumerics
+// joined by single separators, so the component name is sanitized to fit.
+func ImageRepository(workspaceID, componentName string) string {
+	return strings.ToLower(workspaceID) + "-" + sanitizeRepositoryComponent(componentName)
+}
+
+var (
+	invalidRepositoryChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+	repositorySeparators   = regexp.MustCompile(`[._-]+`)
+)
+
+// sanitizeRepositoryComponent maps a name on to the grammar of a repository
+// path component: `[a-z0-9]+((\.|_|__|-+)[a-z0-9]+)*`.
+func sanitizeRepositoryComponent(name string) string {
+	name = strings.ToLower(name)
+	name = invalidRepositoryChars.ReplaceAllString(name, "-")
+	name = repositorySeparators.ReplaceAllStringFunc(name, func(sep string) string {
+		if sep == "." || sep == "_" || sep == "__" || strings.Trim(sep, "-") == "" {
+			return sep
+		}
+		return "-"
+	})
+	name = strings.Trim(name, "._-")
+	if name == "" {
+		return "component"
+	}
+	return name
+}
+
+// ParseImageRepository is the inverse of ImageRepository. The returned
+// component name is sanitized, so may differ from the original.
+func ParseImageRepository(workspaceID, repository string) (componentName string, ok bool) {
+	prefix := strings.ToLower(workspaceID + "-")
+	if !strings.HasPrefix(repository, prefix) || len(repository) == len(prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(repository, prefix), true
+}
+
+// SplitImageTag splits a reference of the form "repository:tag". A colon
+// that is part of a registry host's port is not treated as a separator.
+func SplitImageTag(ref string) (repository, tag string) {
+	idx := strings.LastIndex(ref, ":")
+	if idx < 0 || strings.Contains(ref[idx:], "/") {
+		return ref, ""
+	}
+	return ref[:idx], ref[idx+1:]
+}
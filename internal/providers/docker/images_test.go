@@ -0,0 +1,39 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImageRepository(t *testing.T) {
+	repository := ImageRepository("ABC123", "Web_App")
+	assert.Equal(t, "abc123-web_app", repository)
+
+	name, ok := ParseImageRepository("ABC123", repository)
+	assert.True(t, ok)
+	assert.Equal(t, "web_app", name)
+
+	_, ok = ParseImageRepository("ABC123", "abc123-")
+	assert.False(t, ok)
+	_, ok = ParseImageRepository("ABC123", "other-web_app")
+	assert.False(t, ok)
+
+	assert.Equal(t, "abc123-api", ImageRepository("ABC123", "_api"))
+	assert.Equal(t, "abc123-web-app", ImageRepository("ABC123", "web._app-"))
+	assert.Equal(t, "abc123-my--app.v2", ImageRepository("ABC123", "My--App.v2"))
+	assert.Equal(t, "abc123-caf-bar", ImageRepository("ABC123", "café bar"))
+	assert.Equal(t, "abc123-component", ImageRepository("ABC123", "___"))
+}
+
+func TestSplitImageTag(t *testing.T) {
+	check := func(ref, expectedRepository, expectedTag string) {
+		repository, tag := SplitImageTag(ref)
+		assert.Equal(t, expectedRepository, repository)
+		assert.Equal(t, expectedTag, tag)
+	}
+	check("postgres", "postgres", "")
+	check("postgres:13", "postgres", "13")
+	check("localhost:5000/app", "localhost:5000/app", "")
+	check("localhost:5000/app:v1", "localhost:5000/app", "v1")
+}
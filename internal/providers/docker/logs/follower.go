@@ -0,0 +1,227 @@
+// Package logs collects container logs by following them through the
+// container engine's API, as an alternative to the syslog logging driver.
+//
+// Following logs works with rootless and remote engines, which may not be
+// able to reach exo's syslog port, and preserves which stdio stream each line
+// was written to.
+package logs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deref/exo/internal/chrono"
+	"github.com/deref/exo/internal/eventd/api"
+	"github.com/deref/exo/internal/providers/docker"
+	"github.com/deref/exo/internal/util/logging"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// FollowLabel marks containers whose logs should be followed.
+const FollowLabel = "io.deref.exo.follow-logs"
+
+// Follower ingests the logs of running containers that have FollowLabel in
+// to event streams named by their component IDs.
+type Follower struct {
+	Logger logging.Logger
+	Docker docker.Runtime
+	Store  api.Store
+	// How often to check for newly started containers.
+	PollInterval time.Duration
+
+	mx        sync.Mutex
+	following map[string]bool
+}
+
+func (f *Follower) Run(ctx context.Context) error {
+	f.following = make(map[string]bool)
+	interval := f.PollInterval
+	if interval == 0 {
+		interval = time.Second
+	}
+	for {
+		if err := f.followNewContainers(ctx); err != nil {
+			f.Logger.Infof("following container logs: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (f *Follower) followNewContainers(ctx context.Context) error {
+	containers, err := f.Docker.ContainerList(ctx, types.ContainerListOptions{
+		Filters: filters.NewArgs(filters.KeyValuePair{
+			Key:   "label",
+			Value: FollowLabel,
+		}),
+	})
+	if err != nil {
+		return fmt.Errorf("listing containers: %w", err)
+	}
+
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	for _, container := range containers {
+		containerID := container.ID
+		stream := container.Labels["io.deref.exo.component"]
+		if f.following[containerID] || stream == "" {
+			continue
+		}
+		f.following[containerID] = true
+		go func() {
+			defer func() {
+				f.mx.Lock()
+				defer f.mx.Unlock()
+				delete(f.following, containerID)
+			}()
+			if err := f.follow(ctx, containerID, stream); err != nil {
+				f.Logger.Infof("following logs of container %q: %v", containerID, err)
+			}
+		}()
+	}
+	return nil
+}
+
+// follow ingests logs until the container stops. Logs are requested from the
+// time of the last event in the stream, so that restarting exod or the
+// container neither loses nor duplicates lines.
+func (f *Follower) follow(ctx context.Context, containerID, stream string) error {
+	since, err := f.lastEventTime(ctx, stream)
+	if err != nil {
+		return err
+	}
+
+	inspection, err := f.Docker.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return fmt.Errorf("inspecting: %w", err)
+	}
+
+	opts := types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+		Timestamps: true,
+	}
+	if !since.IsZero() {
+		opts.Since = since.Format(time.RFC3339Nano)
+	}
+	rc, err := f.Docker.ContainerLogs(ctx, containerID, opts)
+	if err != nil {
+		return fmt.Errorf("getting logs: %w", err)
+	}
+	defer rc.Close()
+
+	stdout := f.newLineWriter(ctx, stream, "out", since)
+	stderr := f.newLineWriter(ctx, stream, "err", since)
+	if inspection.Config != nil && inspection.Config.Tty {
+		// With a TTY, output is not multiplexed and stderr is indistinguishable
+		// from stdout.
+		_, err = io.Copy(stdout, rc)
+	} else {
+		_, err = stdcopy.StdCopy(stdout, stderr, rc)
+	}
+	if err != nil && ctx.Err() == nil {
+		return err
+	}
+	if err := stdout.Flush(); err != nil {
+		return err
+	}
+	return stderr.Flush()
+}
+
+func (f *Follower) lastEventTime(ctx context.Context, stream string) (time.Time, error) {
+	output, err := f.Store.DescribeStreams(ctx, &api.DescribeStreamsInput{
+		Names: []string{stream},
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("describing stream: %w", err)
+	}
+	for _, description := range output.Streams {
+		if description.Name == stream && description.LastEventAt != nil {
+			return time.Parse(time.RFC3339Nano, *description.LastEventAt)
+		}
+	}
+	return time.Time{}, nil
+}
+
+func (f *Follower) newLineWriter(ctx context.Context, stream, stdio string, since time.Time) *lineWriter {
+	return &lineWriter{
+		since: since,
+		emit: func(timestamp time.Time, message string) error {
+			_, err := f.Store.AddEvent(ctx, &api.AddEventInput{
+				Stream:    stream,
+				Timestamp: timestamp.UTC().Format(chrono.RFC3339MicroUTC),
+				Message:   message,
+				Tags: map[string]string{
+					"stdio": stdio,
+				},
+			})
+			return err
+		},
+	}
+}
+
+// lineWriter splits timestamped log output in to lines and emits each line
+// that is newer than since.
+type lineWriter struct {
+	since time.Time
+	emit  func(timestamp time.Time, message string) error
+	buf   bytes.Buffer
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	for {
+		idx := bytes.IndexByte(w.buf.Bytes(), '\n')
+		if idx < 0 {
+			break
+		}
+		line := string(w.buf.Next(idx + 1))
+		if err := w.emitLine(line); err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush emits any trailing output that was not terminated by a newline.
+func (w *lineWriter) Flush() error {
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	line := w.buf.String()
+	w.buf.Reset()
+	return w.emitLine(line)
+}
+
+func (w *lineWriter) emitLine(line string) error {
+	line = strings.TrimRight(line, "\r\n")
+	parts := strings.SplitN(line, " ", 2)
+	timestamp, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return fmt.Errorf("parsing log timestamp: %w", err)
+	}
+	// The engine includes lines at exactly the since time, which were already
+	// ingested. Stored timestamps only have microsecond precision.
+	if !timestamp.Truncate(time.Microsecond).After(w.since) {
+		return nil
+	}
+	message := ""
+	if len(parts) > 1 {
+		message = parts[1]
+	}
+	if len(message) > api.MaxMessageSize {
+		message = message[:api.MaxMessageSize]
+	}
+	return w.emit(timestamp, message)
+}
@@ -0,0 +1,35 @@
+package logs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineWriter(t *testing.T) {
+	type line struct {
+		Timestamp time.Time
+		Message   string
+	}
+	var lines []line
+	since := time.Date(2021, 10, 1, 12, 0, 0, 1000, time.UTC)
+	w := &lineWriter{
+		since: since,
+		emit: func(timestamp time.Time, message string) error {
+			lines = append(lines, line{timestamp, message})
+			return nil
+		},
+	}
+
+	_, err := w.Write([]byte("2021-10-01T12:00:00.000001Z already seen\n2021-10-01T12:00:00.0000015Z also seen\n2021-10-"))
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("01T12:00:01.5Z hello world\r\n2021-10-01T12:00:02Z"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Flush())
+
+	assert.Equal(t, []line{
+		{time.Date(2021, 10, 1, 12, 0, 1, 500000000, time.UTC), "hello world"},
+		{time.Date(2021, 10, 1, 12, 0, 2, 0, time.UTC), ""},
+	}, lines)
+}
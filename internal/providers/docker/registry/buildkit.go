@@ -0,0 +1,35 @@
+package registry
+
+import (
+	"context"
+
+	"github.com/moby/buildkit/session/auth"
+	"google.golang.org/grpc"
+)
+
+// AuthProvider serves registry credentials to BuildKit over a build session,
+// for builds whose base images come from private registries.
+type AuthProvider struct {
+	auth.UnimplementedAuthServer
+	Config *Config
+}
+
+func (ap *AuthProvider) Register(server *grpc.Server) {
+	auth.RegisterAuthServer(server, ap)
+}
+
+func (ap *AuthProvider) Credentials(ctx context.Context, req *auth.CredentialsRequest) (*auth.CredentialsResponse, error) {
+	creds, err := ap.Config.Lookup(req.Host)
+	if err != nil {
+		return nil, err
+	}
+	if creds.IdentityToken != "" {
+		return &auth.CredentialsResponse{
+			Secret: creds.IdentityToken,
+		}, nil
+	}
+	return &auth.CredentialsResponse{
+		Username: creds.Username,
+		Secret:   creds.Password,
+	}, nil
+}
@@ -0,0 +1,267 @@
+// Package registry resolves container registry credentials from the docker
+// CLI's configuration file, including credentials held by credential helpers.
+package registry
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+)
+
+// The key under which Docker Hub credentials are stored.
+const dockerHubServer = "https://index.docker.io/v1/"
+
+// Config is the subset of ~/.docker/config.json that describes credentials.
+// See <https://docs.docker.com/engine/reference/commandline/login/#credentials-store>.
+type Config struct {
+	Auths       map[string]AuthEntry `json:"auths"`
+	CredHelpers map[string]string    `json:"credHelpers"`
+	CredsStore  string               `json:"credsStore"`
+}
+
+type AuthEntry struct {
+	// Base64 encoding of "username:password".
+	Auth          string `json:"auth"`
+	Username      string `json:"username"`
+	Password      string `json:"password"`
+	IdentityToken string `json:"identitytoken"`
+}
+
+// ConfigPath returns the path of the docker CLI's configuration file, which
+// may be overridden with the DOCKER_CONFIG environment variable.
+func ConfigPath() (string, error) {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return filepath.Join(dir, "config.json"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".docker", "config.json"), nil
+}
+
+// LoadConfig reads the docker CLI's configuration file. A missing file is
+// treated as empty.
+func LoadConfig() (*Config, error) {
+	path, err := ConfigPath()
+	if err != nil {
+		return nil, fmt.Errorf("locating docker config: %w", err)
+	}
+	bs, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(bs, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// ImageHost returns the registry host of an image reference, such as
+// "docker.io" for "postgres:13".
+func ImageHost(image string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", err
+	}
+	return reference.Domain(named), nil
+}
+
+// Lookup returns the credentials for a registry host. If there are none, the
+// zero AuthConfig is returned.
+func (cfg *Config) Lookup(host string) (types.AuthConfig, error) {
+	server := host
+	switch host {
+	// BuildKit asks for registry-1.docker.io, the host that Docker Hub images
+	// are actually pulled from.
+	case "docker.io", "index.docker.io", "registry-1.docker.io":
+		server = dockerHubServer
+	}
+	if helper := cfg.helperFor(server); helper != "" {
+		return helperGet(helper, server)
+	}
+	for key, entry := range cfg.Auths {
+		if normalizeHost(key) == normalizeHost(server) {
+			return entry.authConfig(key)
+		}
+	}
+	return types.AuthConfig{}, nil
+}
+
+// All returns the credentials for every registry that has any, keyed by
+// server address, as expected by ImageBuildOptions.AuthConfigs.
+func (cfg *Config) All() (map[string]types.AuthConfig, error) {
+	servers := make(map[string]bool)
+	for server := range cfg.Auths {
+		servers[server] = true
+	}
+	for server := range cfg.CredHelpers {
+		servers[server] = true
+	}
+	if cfg.CredsStore != "" {
+		listed, err := helperList(cfg.CredsStore)
+		if err != nil {
+			return nil, err
+		}
+		for _, server := range listed {
+			servers[server] = true
+		}
+	}
+	auths := make(map[string]types.AuthConfig, len(servers))
+	for server := range servers {
+		var auth types.AuthConfig
+		var err error
+		if helper := cfg.helperFor(server); helper != "" {
+			auth, err = helperGet(helper, server)
+		} else {
+			auth, err = cfg.Auths[server].authConfig(server)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("getting credentials for %s: %w", server, err)
+		}
+		if auth != (types.AuthConfig{}) {
+			auths[server] = auth
+		}
+	}
+	return auths, nil
+}
+
+func (cfg *Config) helperFor(server string) string {
+	if helper := cfg.CredHelpers[server]; helper != "" {
+		return helper
+	}
+	for key, helper := range cfg.CredHelpers {
+		if normalizeHost(key) == normalizeHost(server) {
+			return helper
+		}
+	}
+	return cfg.CredsStore
+}
+
+func (entry AuthEntry) authConfig(server string) (types.AuthConfig, error) {
+	auth := types.AuthConfig{
+		Username:      entry.Username,
+		Password:      entry.Password,
+		IdentityToken: entry.IdentityToken,
+		ServerAddress: server,
+	}
+	if entry.Auth != "" {
+		decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+		if err != nil {
+			return types.AuthConfig{}, fmt.Errorf("decoding auth for %s: %w", server, err)
+		}
+		parts := strings.SplitN(string(decoded), ":", 2)
+		if len(parts) != 2 {
+			return types.AuthConfig{}, fmt.Errorf("invalid auth for %s", server)
+		}
+		auth.Username = parts[0]
+		auth.Password = parts[1]
+	}
+	if auth.Username == "" && auth.Password == "" && auth.IdentityToken == "" {
+		return types.AuthConfig{}, nil
+	}
+	return auth, nil
+}
+
+// normalizeHost strips the scheme and path from a server address, since
+// config files contain a mix of hosts and URLs.
+func normalizeHost(server string) string {
+	server = strings.TrimPrefix(server, "https://")
+	server = strings.TrimPrefix(server, "http://")
+	if i := strings.Index(server, "/"); i >= 0 {
+		server = server[:i]
+	}
+	return server
+}
+
+// EncodeAuth encodes credentials for the X-Registry-Auth header, as expected
+// by ImagePullOptions.RegistryAuth.
+func EncodeAuth(auth types.AuthConfig) (string, error) {
+	bs, err := json.Marshal(auth)
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(bs), nil
+}
+
+// See <https://github.com/docker/docker-credential-helpers#development>.
+type helperCredentials struct {
+	ServerURL string `json:"ServerURL"`
+	Username  string `json:"Username"`
+	Secret    string `json:"Secret"`
+}
+
+// Credential helpers use this username to indicate that the secret is an
+// identity token rather than a password.
+const tokenUsername = "<token>"
+
+func helperGet(helper, server string) (types.AuthConfig, error) {
+	out, err := runHelper(helper, "get", server)
+	if err != nil {
+		if strings.Contains(err.Error(), "credentials not found") {
+			return types.AuthConfig{}, nil
+		}
+		return types.AuthConfig{}, err
+	}
+	var creds helperCredentials
+	if err := json.Unmarshal(out, &creds); err != nil {
+		return types.AuthConfig{}, fmt.Errorf("parsing output of docker-credential-%s: %w", helper, err)
+	}
+	auth := types.AuthConfig{
+		ServerAddress: server,
+	}
+	if creds.Username == tokenUsername {
+		auth.IdentityToken = creds.Secret
+	} else {
+		auth.Username = creds.Username
+		auth.Password = creds.Secret
+	}
+	return auth, nil
+}
+
+func helperList(helper string) ([]string, error) {
+	out, err := runHelper(helper, "list", "")
+	if err != nil {
+		return nil, err
+	}
+	var listed map[string]string
+	if err := json.Unmarshal(out, &listed); err != nil {
+		return nil, fmt.Errorf("parsing output of docker-credential-%s: %w", helper, err)
+	}
+	servers := make([]string, 0, len(listed))
+	for server := range listed {
+		servers = append(servers, server)
+	}
+	return servers, nil
+}
+
+func runHelper(helper, action, input string) ([]byte, error) {
+	cmd := exec.Command("docker-credential-"+helper, action)
+	cmd.Stdin = strings.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// Helpers report errors on stdout.
+		message := strings.TrimSpace(stdout.String() + stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return nil, fmt.Errorf("docker-credential-%s %s: %s", helper, action, message)
+	}
+	return stdout.Bytes(), nil
+}
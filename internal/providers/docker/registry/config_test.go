@@ -0,0 +1,111 @@
+package registry
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImageHost(t *testing.T) {
+	check := func(image, expected string) {
+		host, err := ImageHost(image)
+		if assert.NoError(t, err) {
+			assert.Equal(t, expected, host)
+		}
+	}
+	check("postgres:13", "docker.io")
+	check("deref/exo", "docker.io")
+	check("localhost:5000/app:latest", "localhost:5000")
+	check("registry.example.com/team/app", "registry.example.com")
+}
+
+func TestLookupAuths(t *testing.T) {
+	cfg := &Config{
+		Auths: map[string]AuthEntry{
+			"https://index.docker.io/v1/": {
+				Auth: base64.StdEncoding.EncodeToString([]byte("hub-user:hub-pass")),
+			},
+			"localhost:5000": {
+				Username: "user",
+				Password: "pass",
+			},
+		},
+	}
+
+	auth, err := cfg.Lookup("docker.io")
+	if assert.NoError(t, err) {
+		assert.Equal(t, types.AuthConfig{
+			Username:      "hub-user",
+			Password:      "hub-pass",
+			ServerAddress: "https://index.docker.io/v1/",
+		}, auth)
+	}
+
+	auth, err = cfg.Lookup("registry-1.docker.io")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "hub-user", auth.Username)
+	}
+
+	auth, err = cfg.Lookup("localhost:5000")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "user", auth.Username)
+	}
+
+	auth, err = cfg.Lookup("registry.example.com")
+	if assert.NoError(t, err) {
+		assert.Equal(t, types.AuthConfig{}, auth)
+	}
+}
+
+func TestCredentialHelper(t *testing.T) {
+	dir := t.TempDir()
+	script := `#!/bin/sh
+read server
+case "$1" in
+get)
+  if [ "$server" = "registry.example.com" ]; then
+    echo '{"ServerURL":"registry.example.com","Username":"<token>","Secret":"tok"}'
+  else
+    echo "credentials not found in native keychain"
+    exit 1
+  fi
+  ;;
+list)
+  echo '{"registry.example.com":"<token>"}'
+  ;;
+esac
+`
+	if !assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "docker-credential-test"), []byte(script), 0755)) {
+		return
+	}
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+	defer os.Setenv("PATH", path)
+
+	cfg := &Config{CredsStore: "test"}
+
+	auth, err := cfg.Lookup("registry.example.com")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "tok", auth.IdentityToken)
+	}
+
+	auth, err = cfg.Lookup("other.example.com")
+	if assert.NoError(t, err) {
+		assert.Equal(t, types.AuthConfig{}, auth)
+	}
+
+	all, err := cfg.All()
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]types.AuthConfig{
+			"registry.example.com": {
+				IdentityToken: "tok",
+				ServerAddress: "registry.example.com",
+			},
+		}, all)
+	}
+}
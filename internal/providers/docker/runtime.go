@@ -0,0 +1,53 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/deref/exo/internal/config"
+	"github.com/deref/exo/internal/providers/podman"
+	"github.com/docker/docker/api/types"
+	dockerclient "github.com/docker/docker/client"
+)
+
+// Runtime is the subset of the Docker Engine API that container, image,
+// network, and volume components depend on. The Docker client implements it
+// directly; other container engines provide adapters.
+type Runtime interface {
+	dockerclient.ContainerAPIClient
+	dockerclient.ImageAPIClient
+	dockerclient.NetworkAPIClient
+	dockerclient.VolumeAPIClient
+	dockerclient.SystemAPIClient
+	ServerVersion(ctx context.Context) (types.Version, error)
+	// DialHijack opens a connection upgraded to proto, such as the BuildKit
+	// session protocol.
+	DialHijack(ctx context.Context, url, proto string, meta map[string][]string) (net.Conn, error)
+	Close() error
+}
+
+var _ Runtime = (*dockerclient.Client)(nil)
+var _ Runtime = (*podman.Client)(nil)
+
+// NewRuntime constructs a client for the container runtime selected by cfg.
+func NewRuntime(cfg config.ContainerConfig) (Runtime, error) {
+	switch cfg.Runtime {
+	case "", "docker":
+		// Without a configured socket, respect DOCKER_HOST and the other
+		// variables that the docker CLI does.
+		opt := dockerclient.FromEnv
+		if cfg.Socket != "" {
+			opt = dockerclient.WithHost("unix://" + cfg.Socket)
+		}
+		return dockerclient.NewClientWithOpts(opt)
+	case "podman":
+		socket := cfg.Socket
+		if socket == "" {
+			socket = podman.DefaultSocketPath()
+		}
+		return podman.NewClient(socket)
+	default:
+		return nil, fmt.Errorf("unsupported container runtime: %q", cfg.Runtime)
+	}
+}
@@ -0,0 +1,259 @@
+// Package podman adapts the Podman service to the Docker Engine API that exo's
+// container components are written against.
+//
+// The Podman service exposes both its native libpod API and a
+// Docker-compatible API on the same Unix socket. Operations whose compat
+// behavior matches Docker's are delegated to a Docker client pointed at that
+// socket. Operations where the compat layer diverges, or where libpod reports
+// more accurate information, are implemented against libpod directly.
+package podman
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/deref/exo/internal/util/osutil"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	dockerclient "github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Version of the libpod API that requests are made against. Podman 3.0 is the
+// oldest release with a stable REST API.
+const libpodVersion = "v3.0.0"
+
+type Client struct {
+	*dockerclient.Client
+}
+
+func NewClient(socketPath string) (*Client, error) {
+	compat, err := dockerclient.NewClientWithOpts(
+		dockerclient.WithHost("unix://"+socketPath),
+		dockerclient.WithAPIVersionNegotiation(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		Client: compat,
+	}, nil
+}
+
+// DefaultSocketPath returns the path of the rootless user's Podman socket if
+// one exists, otherwise the path of the system-wide socket.
+func DefaultSocketPath() string {
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		rootless := filepath.Join(runtimeDir, "podman", "podman.sock")
+		if exists, _ := osutil.Exists(rootless); exists {
+			return rootless
+		}
+	}
+	return "/run/podman/podman.sock"
+}
+
+func (c *Client) libpod(ctx context.Context, method string, path string, query url.Values, body interface{}, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		bs, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshalling request: %w", err)
+		}
+		reqBody = bytes.NewReader(bs)
+	}
+	u := url.URL{
+		Scheme:   "http",
+		Host:     "d",
+		Path:     "/" + libpodVersion + "/libpod" + path,
+		RawQuery: query.Encode(),
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reqBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.HTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		var errResp struct {
+			Message string `json:"message"`
+		}
+		bs, _ := ioutil.ReadAll(resp.Body)
+		_ = json.Unmarshal(bs, &errResp)
+		if errResp.Message == "" {
+			errResp.Message = http.StatusText(resp.StatusCode)
+		}
+		err := fmt.Errorf("podman: %s", errResp.Message)
+		switch resp.StatusCode {
+		case http.StatusNotFound:
+			return errdefs.NotFound(err)
+		case http.StatusConflict:
+			return errdefs.Conflict(err)
+		default:
+			return err
+		}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// See <https://docs.podman.io/en/latest/_static/api.html#operation/SystemInfoLibpod>.
+type libpodInfo struct {
+	Host struct {
+		Arch         string `json:"arch"`
+		Hostname     string `json:"hostname"`
+		Kernel       string `json:"kernel"`
+		OS           string `json:"os"`
+		CPUs         int    `json:"cpus"`
+		MemTotal     int64  `json:"memTotal"`
+		Distribution struct {
+			Distribution string `json:"distribution"`
+			Version      string `json:"version"`
+		} `json:"distribution"`
+		Security struct {
+			Rootless bool `json:"rootless"`
+		} `json:"security"`
+	} `json:"host"`
+	Store struct {
+		GraphRoot   string `json:"graphRoot"`
+		GraphDriver string `json:"graphDriverName"`
+	} `json:"store"`
+	Version struct {
+		Version string `json:"Version"`
+	} `json:"version"`
+}
+
+func (c *Client) Info(ctx context.Context) (types.Info, error) {
+	var info libpodInfo
+	if err := c.libpod(ctx, http.MethodGet, "/info", nil, nil, &info); err != nil {
+		return types.Info{}, err
+	}
+	operatingSystem := info.Host.Distribution.Distribution
+	if info.Host.Distribution.Version != "" {
+		operatingSystem += " " + info.Host.Distribution.Version
+	}
+	securityOptions := []string{}
+	if info.Host.Security.Rootless {
+		securityOptions = append(securityOptions, "name=rootless")
+	}
+	return types.Info{
+		Name:            info.Host.Hostname,
+		KernelVersion:   info.Host.Kernel,
+		OperatingSystem: operatingSystem,
+		OSType:          info.Host.OS,
+		Architecture:    info.Host.Arch,
+		NCPU:            info.Host.CPUs,
+		MemTotal:        info.Host.MemTotal,
+		DockerRootDir:   info.Store.GraphRoot,
+		Driver:          info.Store.GraphDriver,
+		ServerVersion:   info.Version.Version,
+		SecurityOptions: securityOptions,
+	}, nil
+}
+
+func (c *Client) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *v1.Platform, containerName string) (container.ContainerCreateCreatedBody, error) {
+	if hostConfig != nil {
+		adjusted := *hostConfig
+		// Podman does not implement the syslog log driver. Fall back to the
+		// engine default, which retains logs for `podman logs`.
+		if adjusted.LogConfig.Type == "syslog" {
+			adjusted.LogConfig = container.LogConfig{}
+		}
+		// Podman does not understand Docker's "host-gateway" alias, but provides
+		// host.containers.internal instead.
+		adjusted.ExtraHosts = nil
+		for _, extraHost := range hostConfig.ExtraHosts {
+			if !strings.HasSuffix(extraHost, ":host-gateway") {
+				adjusted.ExtraHosts = append(adjusted.ExtraHosts, extraHost)
+			}
+		}
+		hostConfig = &adjusted
+	}
+	return c.Client.ContainerCreate(ctx, config, hostConfig, networkingConfig, platform, containerName)
+}
+
+// See <https://docs.podman.io/en/latest/_static/api.html#operation/VolumeCreateLibpod>.
+type libpodVolume struct {
+	Name       string            `json:"Name"`
+	Driver     string            `json:"Driver"`
+	Mountpoint string            `json:"Mountpoint"`
+	CreatedAt  string            `json:"CreatedAt"`
+	Labels     map[string]string `json:"Labels"`
+	Scope      string            `json:"Scope"`
+	Options    map[string]string `json:"Options"`
+}
+
+func (v libpodVolume) toDocker() types.Volume {
+	return types.Volume{
+		Name:       v.Name,
+		Driver:     v.Driver,
+		Mountpoint: v.Mountpoint,
+		CreatedAt:  v.CreatedAt,
+		Labels:     v.Labels,
+		Scope:      v.Scope,
+		Options:    v.Options,
+	}
+}
+
+func (c *Client) VolumeCreate(ctx context.Context, options volume.VolumeCreateBody) (types.Volume, error) {
+	body := struct {
+		Name    string            `json:"Name"`
+		Driver  string            `json:"Driver,omitempty"`
+		Label   map[string]string `json:"Label"`
+		Options map[string]string `json:"Options"`
+	}{
+		Name:    options.Name,
+		Driver:  options.Driver,
+		Label:   options.Labels,
+		Options: options.DriverOpts,
+	}
+	var created libpodVolume
+	if err := c.libpod(ctx, http.MethodPost, "/volumes/create", nil, body, &created); err != nil {
+		return types.Volume{}, err
+	}
+	return created.toDocker(), nil
+}
+
+func (c *Client) VolumeInspect(ctx context.Context, volumeID string) (types.Volume, error) {
+	var inspected libpodVolume
+	if err := c.libpod(ctx, http.MethodGet, "/volumes/"+url.PathEscape(volumeID)+"/json", nil, nil, &inspected); err != nil {
+		return types.Volume{}, err
+	}
+	return inspected.toDocker(), nil
+}
+
+func (c *Client) VolumeInspectWithRaw(ctx context.Context, volumeID string) (types.Volume, []byte, error) {
+	inspected, err := c.VolumeInspect(ctx, volumeID)
+	if err != nil {
+		return inspected, nil, err
+	}
+	raw, err := json.Marshal(inspected)
+	return inspected, raw, err
+}
+
+func (c *Client) VolumeRemove(ctx context.Context, volumeID string, force bool) error {
+	query := url.Values{
+		"force": []string{strconv.FormatBool(force)},
+	}
+	return c.libpod(ctx, http.MethodDelete, "/volumes/"+url.PathEscape(volumeID), query, nil, nil)
+}
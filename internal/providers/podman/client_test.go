@@ -0,0 +1,92 @@
+package podman
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types/volume"
+	dockerclient "github.com/docker/docker/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	socketPath := filepath.Join(t.TempDir(), "podman.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: handler}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(func() {
+		_ = server.Close()
+	})
+	client, err := NewClient(socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestInfo(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3.0.0/libpod/info", func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte(`{
+			"host": {
+				"arch": "amd64",
+				"hostname": "box",
+				"kernel": "5.14.0",
+				"os": "linux",
+				"distribution": {"distribution": "fedora", "version": "35"},
+				"security": {"rootless": true}
+			},
+			"version": {"Version": "3.4.2"}
+		}`))
+	})
+	client := newTestClient(t, mux)
+
+	info, err := client.Info(context.Background())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "box", info.Name)
+	assert.Equal(t, "5.14.0", info.KernelVersion)
+	assert.Equal(t, "fedora 35", info.OperatingSystem)
+	assert.Equal(t, "3.4.2", info.ServerVersion)
+	assert.Equal(t, []string{"name=rootless"}, info.SecurityOptions)
+}
+
+func TestVolumes(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3.0.0/libpod/volumes/create", func(w http.ResponseWriter, req *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(req.Body).Decode(&body)
+		assert.Equal(t, "data", body["Name"])
+		assert.Equal(t, map[string]interface{}{"a": "b"}, body["Label"])
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"Name": "data", "Driver": "local", "Labels": {"a": "b"}}`))
+	})
+	mux.HandleFunc("/v3.0.0/libpod/volumes/missing/json", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message": "no such volume"}`))
+	})
+	client := newTestClient(t, mux)
+	ctx := context.Background()
+
+	created, err := client.VolumeCreate(ctx, volume.VolumeCreateBody{
+		Name:   "data",
+		Labels: map[string]string{"a": "b"},
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "data", created.Name)
+		assert.Equal(t, "local", created.Driver)
+	}
+
+	_, err = client.VolumeInspect(ctx, "missing")
+	assert.True(t, dockerclient.IsErrNotFound(err))
+}
@@ -1,6 +1,9 @@
 package process
 
-import "github.com/deref/exo/internal/providers/core"
+import (
+	"github.com/deref/exo/internal/providers/core"
+	"github.com/deref/exo/internal/providers/unix/processspec"
+)
 
 type Process struct {
 	core.ComponentBase
@@ -9,19 +12,14 @@ type Process struct {
 	SyslogPort uint
 }
 
-type Spec struct {
-	Directory                  string            `json:"directory"`
-	Program                    string            `json:"program"`
-	Arguments                  []string          `json:"arguments"`
-	Environment                map[string]string `json:"environment"`
-	ShutdownGracePeriodSeconds *int              `json:"shutdownGracePeriodSeconds"`
-}
+type Spec = processspec.Spec
 
 type State struct {
 	Directory                  string            `json:"directory"`
 	Program                    string            `json:"program"`
 	Arguments                  []string          `json:"arguments"`
 	Environment                map[string]string `json:"environment"`
+	EnvFiles                   []string          `json:"envFiles,omitempty"`
 	ShutdownGracePeriodSeconds *int              `json:"shutdownGracePeriodSeconds"`
 
 	Pgid            int               `json:"pgid"`
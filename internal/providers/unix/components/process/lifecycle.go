@@ -29,11 +29,14 @@ func (p *Process) Initialize(ctx context.Context, input *core.InitializeInput) (
 	p.State.Program = spec.Program
 	p.State.Arguments = spec.Arguments
 	p.State.Environment = spec.Environment
+	p.State.EnvFiles = spec.EnvFiles
 	p.State.ShutdownGracePeriodSeconds = spec.ShutdownGracePeriodSeconds
 
 	// Processes are started by default.
-	if err := p.start(ctx); err != nil {
-		return nil, err
+	if !input.NoStart {
+		if err := p.start(ctx); err != nil {
+			return nil, err
+		}
 	}
 	return &core.InitializeOutput{}, nil
 }
@@ -62,6 +65,7 @@ func (p *Process) Refresh(ctx context.Context, input *core.RefreshInput) (*core.
 	p.State.Program = spec.Program
 	p.State.Arguments = spec.Arguments
 	p.State.Environment = spec.Environment
+	p.State.EnvFiles = spec.EnvFiles
 	p.State.ShutdownGracePeriodSeconds = spec.ShutdownGracePeriodSeconds
 
 	p.refresh()
@@ -8,11 +8,13 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"syscall"
 	"time"
 
 	core "github.com/deref/exo/internal/core/api"
+	"github.com/deref/exo/internal/providers/unix/processspec"
 	"github.com/deref/exo/internal/supervise"
 	"github.com/deref/exo/internal/util/errutil"
 	"github.com/deref/exo/internal/util/osutil"
@@ -34,6 +36,18 @@ func (p *Process) Start(ctx context.Context, input *core.StartInput) (*core.Star
 	return &core.StartOutput{}, nil
 }
 
+// workingDirectory returns the directory to run the process in. A relative
+// directory is relative to the workspace root.
+func (p *Process) workingDirectory() string {
+	if p.Directory == "" {
+		return p.WorkspaceRoot
+	}
+	if filepath.IsAbs(p.Directory) {
+		return p.Directory
+	}
+	return filepath.Join(p.WorkspaceRoot, p.Directory)
+}
+
 func (p *Process) start(ctx context.Context) error {
 	if p.Program == "" {
 		// SEE NOTE [PROCESS_STATE_MIGRATION].
@@ -44,10 +58,7 @@ func (p *Process) start(ctx context.Context) error {
 	whichQ := which.Query{
 		Program: p.Program,
 	}
-	whichQ.WorkingDirectory = p.Directory
-	if whichQ.WorkingDirectory == "" {
-		whichQ.WorkingDirectory = p.WorkspaceRoot
-	}
+	whichQ.WorkingDirectory = p.workingDirectory()
 	whichQ.PathVariable = p.Environment["PATH"]
 	if whichQ.PathVariable == "" {
 		// TODO: Daemon path from config.
@@ -65,10 +76,17 @@ func (p *Process) start(ctx context.Context) error {
 		Setsid: true, // Run in background.
 	}
 
+	envFileVars, err := processspec.ReadEnvFiles(p.workingDirectory(), p.EnvFiles)
+	if err != nil {
+		return errutil.WithHTTPStatus(http.StatusBadRequest, err)
+	}
 	envMap := make(map[string]string)
 	for key, val := range p.WorkspaceEnvironment {
 		envMap[key] = val
 	}
+	for key, val := range envFileVars {
+		envMap[key] = val
+	}
 	for key, val := range p.Environment {
 		envMap[key] = val
 	}
@@ -77,7 +95,7 @@ func (p *Process) start(ctx context.Context) error {
 	// Pipe JSON config to supervise on stdin.
 	configJSON := supervise.MustEncodeConfig(&supervise.Config{
 		ComponentID:      p.ComponentID,
-		WorkingDirectory: p.WorkspaceRoot,
+		WorkingDirectory: p.workingDirectory(),
 		SyslogPort:       p.SyslogPort,
 		Environment:      envMap,
 		Program:          program,
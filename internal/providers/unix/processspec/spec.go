@@ -0,0 +1,42 @@
+// Package processspec defines the spec of process components. It is separate
+// from the process component so that manifests can be analyzed without
+// depending on process supervision.
+package processspec
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/joho/godotenv"
+)
+
+type Spec struct {
+	Directory   string            `json:"directory"`
+	Program     string            `json:"program"`
+	Arguments   []string          `json:"arguments"`
+	Environment map[string]string `json:"environment"`
+	// EnvFiles are paths of env files whose variables are given to the
+	// process, read each time the process starts. Relative paths are relative
+	// to the working directory of the process. Environment takes precedence.
+	EnvFiles                   []string `json:"envFiles,omitempty"`
+	ShutdownGracePeriodSeconds *int     `json:"shutdownGracePeriodSeconds"`
+}
+
+// ReadEnvFiles reads the variables of the given env files, with later files
+// taking precedence. Relative paths are relative to dir.
+func ReadEnvFiles(dir string, paths []string) (map[string]string, error) {
+	vars := make(map[string]string)
+	for _, path := range paths {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		fileVars, err := godotenv.Read(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading env file: %w", err)
+		}
+		for name, value := range fileVars {
+			vars[name] = value
+		}
+	}
+	return vars, nil
+}
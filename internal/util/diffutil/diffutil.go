@@ -0,0 +1,38 @@
+package diffutil
+
+import (
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Unified returns a unified diff of the lines of a and b with three lines of
+// context, or an empty string if they are equal.
+func Unified(fromName, toName string, a, b string) string {
+	if a == b {
+		return ""
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(a),
+		B:        splitLines(b),
+		FromFile: fromName,
+		ToFile:   toName,
+		Context:  3,
+	})
+	if err != nil {
+		// Only writing to the string builder can fail.
+		panic(err)
+	}
+	return diff
+}
+
+// Like difflib.SplitLines, but an empty string has no lines and a missing
+// final newline is not reported as a difference.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := difflib.SplitLines(s)
+	if lines[len(lines)-1] == "\n" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,28 @@
+package diffutil_test
+
+import (
+	"testing"
+
+	"github.com/deref/exo/internal/util/diffutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnified(t *testing.T) {
+	assert.Equal(t, "", diffutil.Unified("old", "new", "a\nb\n", "a\nb\n"))
+
+	assert.Equal(t, `--- old
++++ new
+@@ -1,3 +1,3 @@
+ a
+-b
++B
+ c
+`, diffutil.Unified("old", "new", "a\nb\nc\n", "a\nB\nc"))
+
+	assert.Equal(t, `--- old
++++ new
+@@ -0,0 +1,2 @@
++a
++b
+`, diffutil.Unified("old", "new", "", "a\nb"))
+}